@@ -0,0 +1,235 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package acme 基于 dnsdk.Api 完成 ACME DNS-01 验证;
+// Solver 实现了 lego 的 challenge.Provider 与 challenge.ProviderTimeout, 可直接传给 SetDNS01Provider
+package acme
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-the-way/dnsdk"
+)
+
+const (
+	challengeLabel  = "_acme-challenge"
+	defaultTTL      = 120
+	defaultTimeout  = time.Minute * 2
+	defaultInterval = time.Second * 5
+)
+
+type (
+	// Checker 检查 fqdn 的 TXT 记录是否已包含 value
+	Checker func(ctx context.Context, fqdn, value string) (ok bool, err error)
+
+	Opts struct {
+		TTL      uint          // 验证记录的TTL, 默认120, 超出服务商范围时调整
+		Timeout  time.Duration // 等待记录生效的时长, 默认2分钟
+		Interval time.Duration // 检查间隔, 默认5秒
+		Check    Checker       // 生效检查, 默认查询域名的全部权威服务器
+	}
+
+	Solver struct {
+		api     dnsdk.Api
+		opts    Opts
+		mu      sync.Mutex
+		records map[string]record // fqdn + 记录值 => 添加的记录
+	}
+
+	record struct {
+		domainId string
+		domain   string
+		id       string
+	}
+)
+
+func NewSolver(api dnsdk.Api, opts Opts) *Solver {
+	if opts.TTL == 0 {
+		opts.TTL = defaultTTL
+	}
+	if caps := api.Capabilities(); caps.TTLMin > 0 && opts.TTL < caps.TTLMin {
+		opts.TTL = caps.TTLMin
+	} else if caps.TTLMax > 0 && opts.TTL > caps.TTLMax {
+		opts.TTL = caps.TTLMax
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+	s := &Solver{api: api, opts: opts, records: make(map[string]record)}
+	if s.opts.Check == nil {
+		s.opts.Check = s.verify
+	}
+	return s
+}
+
+// ChallengeRecord 返回 domain 的验证记录名与记录值, 通配符域名与其上级域名使用同一记录名
+func ChallengeRecord(domain, keyAuth string) (fqdn, value string) {
+	domain = strings.TrimSuffix(strings.TrimPrefix(domain, "*."), ".")
+	sum := sha256.Sum256([]byte(keyAuth))
+	return challengeLabel + "." + domain, base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (s *Solver) Present(domain, token, keyAuth string) (err error) {
+	return s.PresentContext(context.Background(), domain, token, keyAuth)
+}
+
+// PresentContext 在最长后缀匹配的域名下添加验证 TXT 记录, 并等待记录生效
+func (s *Solver) PresentContext(ctx context.Context, domain, token, keyAuth string) (err error) {
+	fqdn, value := ChallengeRecord(domain, keyAuth)
+	d, err := s.domain(ctx, fqdn)
+	if err != nil {
+		return
+	}
+	rec := record{domainId: d.Id, domain: d.Name}
+	resp, err := s.api.RecordAddContext(ctx, dnsdk.RecordAddReq{
+		DomainId: d.Id,
+		Domain:   d.Name,
+		Record:   relative(fqdn, d.Name),
+		Type:     "TXT",
+		Value:    value,
+		Line:     s.api.LineDefaultContext(ctx).Id,
+		TTL:      s.opts.TTL,
+	})
+	switch {
+	case errors.Is(err, dnsdk.ErrAlreadyExists):
+		if rec.id, err = s.find(ctx, d, fqdn, value); err != nil {
+			return
+		}
+	case err != nil:
+		return fmt.Errorf("%s: %w", fqdn, err)
+	default:
+		rec.id = resp.Id
+	}
+	s.mu.Lock()
+	s.records[fqdn+"\x00"+value] = rec
+	s.mu.Unlock()
+	return s.Wait(ctx, fqdn, value)
+}
+
+func (s *Solver) CleanUp(domain, token, keyAuth string) (err error) {
+	return s.CleanUpContext(context.Background(), domain, token, keyAuth)
+}
+
+// CleanUpContext 删除 PresentContext 添加的记录, 未记录时按记录名与记录值查找, 记录不存在时不返回错误
+func (s *Solver) CleanUpContext(ctx context.Context, domain, token, keyAuth string) (err error) {
+	fqdn, value := ChallengeRecord(domain, keyAuth)
+	key := fqdn + "\x00" + value
+	s.mu.Lock()
+	rec, ok := s.records[key]
+	s.mu.Unlock()
+	if !ok {
+		d, err0 := s.domain(ctx, fqdn)
+		if err = err0; err != nil {
+			return
+		}
+		rec = record{domainId: d.Id, domain: d.Name}
+		if rec.id, err = s.find(ctx, d, fqdn, value); errors.Is(err, dnsdk.ErrNotFound) {
+			return nil
+		} else if err != nil {
+			return
+		}
+	}
+	err = s.api.RecordDeleteContext(ctx, dnsdk.RecordDeleteReq{RecordId: rec.id, DomainId: rec.domainId})
+	if err != nil && !errors.Is(err, dnsdk.ErrNotFound) {
+		return fmt.Errorf("%s: %w", fqdn, err)
+	}
+	s.mu.Lock()
+	delete(s.records, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// Timeout 返回等待时长与检查间隔, 供 lego 的传播检查使用
+func (s *Solver) Timeout() (timeout, interval time.Duration) { return s.opts.Timeout, s.opts.Interval }
+
+// Wait 每隔 Interval 检查一次, 直到记录生效、超过 Timeout 或 ctx 结束
+func (s *Solver) Wait(ctx context.Context, fqdn, value string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+	defer cancel()
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		ok, err0 := s.opts.Check(ctx, fqdn, value)
+		if ok {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			if err0 != nil {
+				return fmt.Errorf("%s: waiting for TXT record: %w", fqdn, err0)
+			}
+			return fmt.Errorf("%s: waiting for TXT record: %w", fqdn, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// domain 返回与 fqdn 最长后缀匹配的域名
+func (s *Solver) domain(ctx context.Context, fqdn string) (resp dnsdk.DomainListRespDomain, err error) {
+	fqdn = strings.ToLower(fqdn)
+	for d, err0 := range dnsdk.AllDomainsContext(ctx, s.api, dnsdk.DomainListReq{}) {
+		if err = err0; err != nil {
+			return
+		}
+		name := strings.ToLower(strings.TrimSuffix(d.Name, "."))
+		if strings.HasSuffix(fqdn, "."+name) && len(name) > len(resp.Name) {
+			resp = d
+		}
+	}
+	if resp.Name == "" {
+		err = &dnsdk.Error{Kind: dnsdk.ErrNotFound, Err: fmt.Errorf("no domain found for %s", fqdn)}
+	}
+	return
+}
+
+func (s *Solver) find(ctx context.Context, d dnsdk.DomainListRespDomain, fqdn, value string) (id string, err error) {
+	req := dnsdk.RecordListReq{DomainId: d.Id, Domain: d.Name, Record: relative(fqdn, d.Name), Type: "TXT"}
+	for r, err0 := range dnsdk.AllRecordsContext(ctx, s.api, req) {
+		if err = err0; err != nil {
+			return
+		}
+		if strings.EqualFold(r.Record, req.Record) && strings.EqualFold(r.Type, "TXT") && strings.Trim(r.Value, `"`) == value {
+			return r.Id, nil
+		}
+	}
+	err = &dnsdk.Error{Kind: dnsdk.ErrNotFound, Err: fmt.Errorf("TXT record %s not found", fqdn)}
+	return
+}
+
+func relative(fqdn, domain string) string {
+	return fqdn[:len(fqdn)-len(strings.TrimSuffix(domain, "."))-1]
+}
+
+func (s *Solver) verify(ctx context.Context, fqdn, value string) (ok bool, err error) {
+	d, err := s.domain(ctx, fqdn)
+	if err != nil {
+		return
+	}
+	report, err := dnsdk.VerifyOnceContext(ctx, s.api, dnsdk.VerifyReq{
+		DomainId:    d.Id,
+		Domain:      d.Name,
+		Record:      relative(fqdn, d.Name),
+		Type:        "TXT",
+		Value:       value,
+		Nameservers: d.DnsServer,
+	})
+	return report.Propagated, err
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acme
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-the-way/dnsdk"
+	"github.com/go-the-way/dnsdk/dnsdktest"
+)
+
+func memoryApi(t *testing.T, domains ...string) dnsdk.Api {
+	t.Helper()
+	api, err := dnsdk.GetSupportApi(dnsdk.NewMemorySupportOpts(), dnsdk.MemorySupporter(func(o *dnsdk.MemorySupportOpts) *dnsdk.MemorySupportOpts { return o }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range domains {
+		if _, err = api.DomainAdd(dnsdk.DomainAddReq{Domain: d}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return api
+}
+
+func txtRecords(t *testing.T, api dnsdk.Api, domain string) (records map[string]string) {
+	t.Helper()
+	resp, err := api.RecordList(dnsdk.RecordListReq{Domain: domain, Type: "TXT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records = make(map[string]string)
+	for _, r := range resp.List {
+		records[r.Record] = r.Value
+	}
+	return
+}
+
+func TestChallengeRecord(t *testing.T) {
+	sum := sha256.Sum256([]byte("key-auth"))
+	want := base64.RawURLEncoding.EncodeToString(sum[:])
+	for _, domain := range []string{"www.example.com", "*.www.example.com", "www.example.com."} {
+		fqdn, value := ChallengeRecord(domain, "key-auth")
+		if fqdn != "_acme-challenge.www.example.com" || value != want {
+			t.Fatalf("ChallengeRecord(%q) = %q %q", domain, fqdn, value)
+		}
+	}
+}
+
+func TestSolver(t *testing.T) {
+	api := memoryApi(t, "example.com", "sub.example.com")
+	var checked []string
+	s := NewSolver(api, Opts{Interval: time.Millisecond, Check: func(_ context.Context, fqdn, value string) (bool, error) {
+		checked = append(checked, fqdn)
+		return len(checked) >= 2, nil
+	}})
+	_, value := ChallengeRecord("a.sub.example.com", "key-auth")
+	if err := s.Present("*.a.sub.example.com", "token", "key-auth"); err != nil {
+		t.Fatal(err)
+	}
+	if len(checked) != 2 || checked[0] != "_acme-challenge.a.sub.example.com" {
+		t.Fatalf("checked = %q, want two checks of the challenge record", checked)
+	}
+	if got := txtRecords(t, api, "sub.example.com"); got["_acme-challenge.a"] != value {
+		t.Fatalf("sub.example.com TXT = %v, want the challenge on the longest matching domain", got)
+	}
+	if got := txtRecords(t, api, "example.com"); len(got) != 0 {
+		t.Fatalf("example.com TXT = %v, want none", got)
+	}
+	if err := s.CleanUp("*.a.sub.example.com", "token", "key-auth"); err != nil {
+		t.Fatal(err)
+	}
+	if got := txtRecords(t, api, "sub.example.com"); len(got) != 0 {
+		t.Fatalf("TXT after CleanUp = %v, want none", got)
+	}
+	if err := s.CleanUp("*.a.sub.example.com", "token", "key-auth"); err != nil {
+		t.Fatalf("second CleanUp = %v, want nil", err)
+	}
+}
+
+func TestSolverExistingRecord(t *testing.T) {
+	api := memoryApi(t, "example.com")
+	ready := func(context.Context, string, string) (bool, error) { return true, nil }
+	fqdn, value := ChallengeRecord("example.com", "key-auth")
+	if _, err := api.RecordAdd(dnsdk.RecordAddReq{Domain: "example.com", Record: "_acme-challenge", Type: "TXT", Value: value, TTL: 600}); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewSolver(api, Opts{Check: ready}).Present("example.com", "token", "key-auth"); err != nil {
+		t.Fatalf("Present with existing record = %v", err)
+	}
+	// 新的 Solver 没有添加记录, 按记录名与记录值查找后删除
+	if err := NewSolver(api, Opts{Check: ready}).CleanUp("example.com", "token", "key-auth"); err != nil {
+		t.Fatal(err)
+	}
+	if got := txtRecords(t, api, "example.com"); len(got) != 0 {
+		t.Fatalf("TXT %s after CleanUp = %v, want none", fqdn, got)
+	}
+}
+
+func TestSolverErrors(t *testing.T) {
+	api := memoryApi(t, "example.com")
+	never := func(context.Context, string, string) (bool, error) { return false, errors.New("not yet") }
+	s := NewSolver(api, Opts{Timeout: time.Millisecond * 20, Interval: time.Millisecond * 5, Check: never})
+	if err := s.Present("example.com", "token", "key-auth"); err == nil || err.Error() != "_acme-challenge.example.com: waiting for TXT record: not yet" {
+		t.Fatalf("Present = %v, want timeout with the last check error", err)
+	}
+	if err := s.Present("example.org", "token", "key-auth"); !errors.Is(err, dnsdk.ErrNotFound) {
+		t.Fatalf("Present on unknown domain = %v, want ErrNotFound", err)
+	}
+	if timeout, interval := s.Timeout(); timeout != time.Millisecond*20 || interval != time.Millisecond*5 {
+		t.Fatalf("Timeout = %v %v", timeout, interval)
+	}
+}
+
+func TestSolverPropagation(t *testing.T) {
+	api := memoryApi(t, "example.com")
+	srv := dnsdktest.NewDNSServer(api)
+	defer srv.Close()
+	s := NewSolver(api, Opts{Timeout: time.Second * 5, Interval: time.Millisecond * 10, Check: func(ctx context.Context, fqdn, value string) (bool, error) {
+		report, err := dnsdk.VerifyOnceContext(ctx, api, dnsdk.VerifyReq{Domain: "example.com", Record: "_acme-challenge", Type: "TXT", Value: value, Nameservers: []string{srv.Addr}})
+		return report.Propagated, err
+	}})
+	if err := s.Present("example.com", "token", "key-auth"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CleanUp("example.com", "token", "key-auth"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdk
+
+import "github.com/go-the-way/dnsdk/internal"
+
+const (
+	OpPing          = internal.OpPing
+	OpLineList      = internal.OpLineList
+	OpLineDefault   = internal.OpLineDefault
+	OpDomainList    = internal.OpDomainList
+	OpDomainAdd     = internal.OpDomainAdd
+	OpDomainDelete  = internal.OpDomainDelete
+	OpDomainGet     = internal.OpDomainGet
+	OpDomainEnable  = internal.OpDomainEnable
+	OpDomainDisable = internal.OpDomainDisable
+	OpRecordList    = internal.OpRecordList
+	OpRecordAdd     = internal.OpRecordAdd
+	OpRecordUpdate  = internal.OpRecordUpdate
+	OpRecordDelete  = internal.OpRecordDelete
+	OpRecordEnable  = internal.OpRecordEnable
+	OpRecordDisable = internal.OpRecordDisable
+)
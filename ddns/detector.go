@@ -0,0 +1,104 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"os/exec"
+	"strings"
+)
+
+// Detector 检测当前的公网地址
+type Detector func(ctx context.Context) (ip netip.Addr, err error)
+
+// InterfaceDetector 返回网卡 name 上第一个全局单播地址, v6 为 true 时返回 IPv6 地址
+func InterfaceDetector(name string, v6 bool) Detector {
+	return func(ctx context.Context) (ip netip.Addr, err error) {
+		iface, err := net.InterfaceByName(name)
+		if err != nil {
+			return
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return
+		}
+		for _, addr := range addrs {
+			prefix, err0 := netip.ParsePrefix(addr.String())
+			if err0 != nil {
+				continue
+			}
+			if ip = prefix.Addr().Unmap(); ip.Is6() == v6 && ip.IsGlobalUnicast() && !ip.IsPrivate() {
+				return ip, nil
+			}
+		}
+		return netip.Addr{}, fmt.Errorf("no public address on interface %s", name)
+	}
+}
+
+// HTTPDetector 请求 url 并将响应内容解析为地址 => https://api4.ipify.org
+func HTTPDetector(url string) Detector {
+	return func(ctx context.Context) (ip netip.Addr, err error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return ip, fmt.Errorf("%s: %s", url, resp.Status)
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+		if err != nil {
+			return
+		}
+		return parseAddr(body)
+	}
+}
+
+// CommandDetector 执行命令并将标准输出解析为地址
+func CommandDetector(name string, args ...string) Detector {
+	return func(ctx context.Context) (ip netip.Addr, err error) {
+		out, err := exec.CommandContext(ctx, name, args...).Output()
+		if err != nil {
+			return
+		}
+		return parseAddr(out)
+	}
+}
+
+// FirstDetector 依次尝试 detectors, 返回第一个成功的结果
+func FirstDetector(detectors ...Detector) Detector {
+	return func(ctx context.Context) (ip netip.Addr, err error) {
+		var errs []error
+		for _, detect := range detectors {
+			if ip, err = detect(ctx); err == nil {
+				return
+			}
+			errs = append(errs, err)
+		}
+		return netip.Addr{}, errors.Join(errs...)
+	}
+}
+
+func parseAddr(b []byte) (ip netip.Addr, err error) {
+	ip, err = netip.ParseAddr(strings.TrimSpace(string(b)))
+	return ip.Unmap(), err
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddns
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestHTTPDetector(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v4":
+			_, _ = w.Write([]byte("192.0.2.1\n"))
+		case "/mapped":
+			_, _ = w.Write([]byte("::ffff:192.0.2.2"))
+		case "/garbage":
+			_, _ = w.Write([]byte("<html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"/v4", "192.0.2.1", false},
+		{"/mapped", "192.0.2.2", false},
+		{"/garbage", "", true},
+		{"/missing", "", true},
+	}
+	for _, tt := range tests {
+		ip, err := HTTPDetector(srv.URL + tt.path)(context.Background())
+		if (err != nil) != tt.wantErr || (err == nil && ip.String() != tt.want) {
+			t.Fatalf("%s = %v %v, want %q error %v", tt.path, ip, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCommandDetector(t *testing.T) {
+	ip, err := CommandDetector("echo", "2001:db8::1")(context.Background())
+	if err != nil || ip.String() != "2001:db8::1" {
+		t.Fatalf("CommandDetector = %v %v", ip, err)
+	}
+	if _, err = CommandDetector("false")(context.Background()); err == nil {
+		t.Fatal("failing command should return an error")
+	}
+}
+
+func TestFirstDetector(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	fail := func(err error) Detector {
+		return func(context.Context) (netip.Addr, error) { return netip.Addr{}, err }
+	}
+	ip, err := FirstDetector(fail(errA), fixed("192.0.2.1"), fail(errB))(context.Background())
+	if err != nil || ip.String() != "192.0.2.1" {
+		t.Fatalf("FirstDetector = %v %v", ip, err)
+	}
+	if _, err = FirstDetector(fail(errA), fail(errB))(context.Background()); !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Fatalf("FirstDetector err = %v, want both errors", err)
+	}
+}
+
+func TestInterfaceDetector(t *testing.T) {
+	if _, err := InterfaceDetector("dnsdk-missing0", false)(context.Background()); err == nil {
+		t.Fatal("missing interface should return an error")
+	}
+	if _, err := InterfaceDetector("lo", false)(context.Background()); err == nil {
+		t.Fatal("loopback has no public address")
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ddns 定期检测公网地址, 地址变化时通过 dnsdk.Api 更新解析记录
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/go-the-way/dnsdk"
+)
+
+const (
+	defaultInterval  = time.Minute * 5
+	defaultBaseDelay = time.Second * 10
+	defaultMaxDelay  = time.Minute * 10
+	defaultTTL       = 600
+)
+
+// Action 一次检查的结果
+type Action string
+
+const (
+	Unchanged Action = "unchanged"
+	Updated   Action = "updated"
+	Added     Action = "added"
+	Failed    Action = "failed"
+)
+
+type (
+	Target struct {
+		DomainId string   // 域名Id => xxxxxxxxxxxx
+		Domain   string   // 域名 => example.com
+		Record   string   // 主机记录, 为空时为 @ => home
+		Type     string   // A 或 AAAA, 为空时按检测到的地址确定
+		Line     string   // 线路, 为空时为默认线路 => default
+		TTL      uint     // 新增记录的TTL, 为空时为服务商最小值或600 => 600
+		Detector Detector // 地址检测
+	}
+
+	Status struct {
+		Target   Target        // 目标
+		Action   Action        // 结果
+		Value    string        // 检测到的地址 => 1.1.1.1
+		Previous string        // 更新前的记录值 => 2.2.2.2
+		Err      error         // 失败原因
+		Failures int           // 连续失败次数
+		Next     time.Duration // 距下次检查的时长
+		Time     time.Time     // 检查时间
+	}
+
+	Opts struct {
+		Interval  time.Duration  // 检查间隔, 默认5分钟
+		BaseDelay time.Duration  // 失败后首次重试的退避上限, 之后每次翻倍, 默认10s
+		MaxDelay  time.Duration  // 退避上限, 默认10分钟
+		OnStatus  func(s Status) // 每个目标每次检查后调用
+	}
+
+	Updater struct {
+		api      dnsdk.Api
+		opts     Opts
+		targets  []Target
+		failures []int
+	}
+)
+
+func NewUpdater(api dnsdk.Api, opts Opts, targets ...Target) *Updater {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = defaultBaseDelay
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = defaultMaxDelay
+	}
+	return &Updater{api: api, opts: opts, targets: targets, failures: make([]int, len(targets))}
+}
+
+// Run 立即检查所有目标, 之后每个目标按 Interval 检查, 失败的目标按退避时长重试; ctx 结束时返回
+func (u *Updater) Run(ctx context.Context) (err error) {
+	next := make([]time.Time, len(u.targets))
+	for {
+		now := time.Now()
+		wake := now.Add(u.opts.Interval)
+		for i := range u.targets {
+			if !next[i].After(now) {
+				next[i] = now.Add(u.update(ctx, i).Next)
+			}
+			if next[i].Before(wake) {
+				wake = next[i]
+			}
+		}
+		timer := time.NewTimer(time.Until(wake))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Update 检查一次所有目标并返回结果, 适用于由外部定时调用
+func (u *Updater) Update(ctx context.Context) (resp []Status) {
+	for i := range u.targets {
+		resp = append(resp, u.update(ctx, i))
+	}
+	return
+}
+
+func (u *Updater) update(ctx context.Context, i int) (s Status) {
+	s = Status{Target: u.targets[i], Time: time.Now()}
+	s.Action, s.Value, s.Previous, s.Err = u.check(ctx, u.targets[i])
+	if s.Err != nil {
+		s.Action = Failed
+		u.failures[i]++
+		s.Next = backoff(u.opts.BaseDelay, u.opts.MaxDelay, u.failures[i])
+	} else {
+		u.failures[i] = 0
+		s.Next = u.opts.Interval
+	}
+	s.Failures = u.failures[i]
+	if u.opts.OnStatus != nil {
+		u.opts.OnStatus(s)
+	}
+	return
+}
+
+// check 记录值与检测到的地址相同时不调用 RecordUpdate, 不存在时添加
+func (u *Updater) check(ctx context.Context, t Target) (action Action, value, previous string, err error) {
+	if t.Detector == nil {
+		return "", "", "", errors.New("detector is required")
+	}
+	ip, err := t.Detector(ctx)
+	if err != nil {
+		return "", "", "", fmt.Errorf("detect: %w", err)
+	}
+	value = ip.String()
+	typ := strings.ToUpper(t.Type)
+	if typ == "" {
+		typ = "A"
+		if ip.Is6() {
+			typ = "AAAA"
+		}
+	}
+	if (typ == "AAAA") != ip.Is6() {
+		return "", value, "", fmt.Errorf("detected %s does not match type %s", value, typ)
+	}
+	record := t.Record
+	if record == "" {
+		record = "@"
+	}
+	line := t.Line
+	if line == "" {
+		line = u.api.LineDefaultContext(ctx).Id
+	}
+	var current *dnsdk.RecordListRespRecord
+	for r, err0 := range dnsdk.AllRecordsContext(ctx, u.api, dnsdk.RecordListReq{DomainId: t.DomainId, Domain: t.Domain, Record: record, Type: typ}) {
+		if err = err0; err != nil {
+			return
+		}
+		if !strings.EqualFold(r.Type, typ) || !strings.EqualFold(recordOf(r.Record), record) || r.Line != line {
+			continue
+		}
+		if addr, err0 := netip.ParseAddr(r.Value); err0 == nil && addr.Unmap() == ip {
+			return Unchanged, value, r.Value, nil
+		}
+		if current == nil {
+			current = &r
+		}
+	}
+	if current != nil {
+		_, err = u.api.RecordUpdateContext(ctx, dnsdk.RecordUpdateReq{
+			RecordId: current.Id,
+			DomainId: t.DomainId,
+			Domain:   t.Domain,
+			Record:   record,
+			Type:     typ,
+			Value:    value,
+			Line:     current.Line,
+			TTL:      current.TTL,
+			Weight:   current.Weight,
+		})
+		return Updated, value, current.Value, err
+	}
+	ttl := t.TTL
+	if ttl == 0 {
+		if ttl = u.api.Capabilities().TTLMin; ttl == 0 {
+			ttl = defaultTTL
+		}
+	}
+	_, err = u.api.RecordAddContext(ctx, dnsdk.RecordAddReq{
+		DomainId: t.DomainId,
+		Domain:   t.Domain,
+		Record:   record,
+		Type:     typ,
+		Value:    value,
+		Line:     line,
+		TTL:      ttl,
+	})
+	return Added, value, "", err
+}
+
+func recordOf(record string) string {
+	if record == "" {
+		return "@"
+	}
+	return record
+}
+
+// backoff 上限为 base 的 2^(failures-1) 倍且不超过 max, 按 max>>shift 比较避免左移溢出
+func backoff(base, max time.Duration, failures int) time.Duration {
+	shift := min(failures-1, 62)
+	ceil := max
+	if shift >= 0 && base > 0 && base <= max>>shift {
+		ceil = base << shift
+	}
+	return ceil/2 + rand.N(ceil/2+1)
+}
@@ -0,0 +1,184 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddns
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/go-the-way/dnsdk"
+)
+
+const testDomain = "example.com"
+
+func memoryApi(t *testing.T, fault func(op dnsdk.Operation) error) dnsdk.Api {
+	t.Helper()
+	opts := dnsdk.NewMemorySupportOpts()
+	if fault != nil {
+		opts.FaultFunc(fault)
+	}
+	api, err := dnsdk.GetSupportApi(opts, dnsdk.MemorySupporter(func(o *dnsdk.MemorySupportOpts) *dnsdk.MemorySupportOpts { return o }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = api.DomainAdd(dnsdk.DomainAddReq{Domain: testDomain}); err != nil {
+		t.Fatal(err)
+	}
+	return api
+}
+
+// fixed 返回依次检测到 addrs 的 Detector, 之后保持最后一个地址
+func fixed(addrs ...string) Detector {
+	i := 0
+	return func(context.Context) (netip.Addr, error) {
+		addr := addrs[min(i, len(addrs)-1)]
+		i++
+		return netip.ParseAddr(addr)
+	}
+}
+
+func TestUpdater(t *testing.T) {
+	api := memoryApi(t, nil)
+	if _, err := api.RecordAdd(dnsdk.RecordAddReq{Domain: testDomain, Record: "home", Type: "A", Value: "192.0.2.9", TTL: 300, Remark: "router", Line: "telecom"}); err != nil {
+		t.Fatal(err)
+	}
+	u := NewUpdater(api, Opts{}, Target{Domain: testDomain, Record: "home", Detector: fixed("192.0.2.1", "192.0.2.1", "192.0.2.2")})
+	tests := []struct {
+		action   Action
+		value    string
+		previous string
+	}{
+		{Added, "192.0.2.1", ""},
+		{Unchanged, "192.0.2.1", "192.0.2.1"},
+		{Updated, "192.0.2.2", "192.0.2.1"},
+	}
+	for i, tt := range tests {
+		s := u.Update(context.Background())[0]
+		if s.Err != nil || s.Action != tt.action || s.Value != tt.value || s.Previous != tt.previous || s.Next != defaultInterval {
+			t.Fatalf("check %d = %+v, want %s %s (previous %s)", i, s, tt.action, tt.value, tt.previous)
+		}
+	}
+	resp, err := api.RecordList(dnsdk.RecordListReq{Domain: testDomain, Record: "home"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]dnsdk.RecordListRespRecord{}
+	for _, r := range resp.List {
+		values[r.Line] = r
+	}
+	if r := values["default"]; len(resp.List) != 2 || r.Value != "192.0.2.2" || r.TTL != 1 {
+		t.Fatalf("records = %+v, want the default line record updated and the telecom record untouched", resp.List)
+	}
+	if r := values["telecom"]; r.Value != "192.0.2.9" || r.Remark != "router" {
+		t.Fatalf("telecom record = %+v, want untouched", r)
+	}
+}
+
+func TestUpdaterUpdateKeepsFields(t *testing.T) {
+	api := memoryApi(t, nil)
+	if _, err := api.RecordAdd(dnsdk.RecordAddReq{Domain: testDomain, Type: "AAAA", Value: "2001:db8::1", TTL: 300, Weight: 5, Remark: "router"}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewUpdater(api, Opts{}, Target{Domain: testDomain, Detector: fixed("2001:db8::2")}).Update(context.Background())[0]
+	if s.Err != nil || s.Action != Updated {
+		t.Fatalf("status = %+v", s)
+	}
+	resp, _ := api.RecordList(dnsdk.RecordListReq{Domain: testDomain})
+	if r := resp.List[0]; r.Record != "@" || r.Value != "2001:db8::2" || r.TTL != 300 || r.Weight != 5 || r.Remark != "router" {
+		t.Fatalf("record = %+v, want value updated and other fields kept", r)
+	}
+}
+
+func TestUpdaterFailures(t *testing.T) {
+	fault := errors.New("fault")
+	api := memoryApi(t, func(op dnsdk.Operation) error {
+		if op == dnsdk.OpRecordAdd {
+			return fault
+		}
+		return nil
+	})
+	tests := []struct {
+		name   string
+		target Target
+		want   string
+	}{
+		{"no detector", Target{Domain: testDomain}, "detector is required"},
+		{"detect", Target{Domain: testDomain, Detector: func(context.Context) (netip.Addr, error) { return netip.Addr{}, fault }}, "detect: fault"},
+		{"type mismatch", Target{Domain: testDomain, Type: "AAAA", Detector: fixed("192.0.2.1")}, "detected 192.0.2.1 does not match type AAAA"},
+		{"api", Target{Domain: testDomain, Detector: fixed("192.0.2.1")}, "fault"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var statuses []Status
+			u := NewUpdater(api, Opts{BaseDelay: time.Second, MaxDelay: time.Second * 3, OnStatus: func(s Status) { statuses = append(statuses, s) }}, tt.target)
+			for range 4 {
+				u.Update(context.Background())
+			}
+			ceil := []time.Duration{time.Second, time.Second * 2, time.Second * 3, time.Second * 3}
+			for i, s := range statuses {
+				if s.Action != Failed || s.Err == nil || s.Err.Error() != tt.want || s.Failures != i+1 {
+					t.Fatalf("status %d = %+v, want failure %d with %q", i, s, i+1, tt.want)
+				}
+				if s.Next < ceil[i]/2 || s.Next > ceil[i] {
+					t.Fatalf("status %d next = %v, want [%v, %v]", i, s.Next, ceil[i]/2, ceil[i])
+				}
+			}
+			if len(statuses) != 4 {
+				t.Fatalf("OnStatus called %d times, want 4", len(statuses))
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		base, max time.Duration
+		failures  int
+		ceil      time.Duration
+	}{
+		{time.Second, time.Hour, 1, time.Second},
+		{time.Second, time.Hour, 4, time.Second * 8},
+		{time.Second, time.Hour, 13, time.Hour},
+		{time.Second, time.Hour, 35, time.Hour}, // 1s<<34 溢出为较小的正数
+		{time.Second, time.Hour, 64, time.Hour},
+		{time.Second, time.Hour, 1 << 20, time.Hour},
+		{time.Second, time.Hour, 0, time.Hour},
+		{time.Duration(1) << 62, time.Duration(1<<63 - 1), 2, time.Duration(1<<63 - 1)},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			if d := backoff(tt.base, tt.max, tt.failures); d < tt.ceil/2 || d > tt.ceil {
+				t.Fatalf("backoff(%v, %v, %d) = %v, want [%v, %v]", tt.base, tt.max, tt.failures, d, tt.ceil/2, tt.ceil)
+			}
+		}
+	}
+}
+
+func TestUpdaterRun(t *testing.T) {
+	api := memoryApi(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	var checks int
+	u := NewUpdater(api, Opts{Interval: time.Millisecond * 5, OnStatus: func(s Status) {
+		if checks++; checks == 3 {
+			cancel()
+		}
+	}}, Target{Domain: testDomain, Record: "home", Detector: fixed("192.0.2.1")})
+	if err := u.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run = %v, want context.Canceled", err)
+	}
+	if checks != 3 {
+		t.Fatalf("checks = %d, want 3", checks)
+	}
+}
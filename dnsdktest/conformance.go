@@ -0,0 +1,630 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dnsdktest 提供 dnsdk.Api 的一致性测试套件
+package dnsdktest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/go-the-way/dnsdk"
+)
+
+const (
+	defaultDomain = "dnsdk-conformance.com"
+	recordName    = "dnsdk-conformance"
+	recordValue   = "192.0.2.1"
+	recordUpdated = "192.0.2.2"
+	recordRemark  = "created by dnsdk"
+	recordWeight  = 10
+	recordTTL     = 600
+	mxValue       = "mail.dnsdk-conformance.com"
+	mxPriority    = 10
+	mxUpdated     = 20
+	srvName       = "_sip._tcp." + recordName
+	proxiedName   = "dnsdk-proxied"
+	recordTag     = "env:conformance"
+)
+
+// conformanceData 各类型的结构化记录值, 经 RecordAdd 与 RecordList 后应保持不变
+var conformanceData = []dnsdk.RecordData{
+	dnsdk.SRV{Priority: 10, Weight: 5, Port: 5060, Target: "sip.dnsdk-conformance.com"},
+	dnsdk.CAA{Flags: 0, Tag: "issue", Value: "letsencrypt.org"},
+}
+
+type (
+	// Factory 返回待测的 Api
+	Factory func(t *testing.T) dnsdk.Api
+
+	Config struct {
+		Domain         string // 测试域名 => dnsdk-conformance.com
+		ExistingDomain bool   // 域名已存在, 跳过 DomainAdd 与 DomainDelete
+	}
+)
+
+// RunConformance 使用默认配置执行一致性测试
+func RunConformance(t *testing.T, factory Factory) { RunConformanceConfig(t, factory, Config{}) }
+
+// RunConformanceConfig 依次执行 添加/查询/暂停/启用域名 → 添加/查询/修改/启用/暂停/删除记录 → 删除域名,
+// 不支持的操作按 Capabilities 跳过, 任一步骤失败后其余步骤不再执行
+func RunConformanceConfig(t *testing.T, factory Factory, cfg Config) {
+	if cfg.Domain == "" {
+		cfg.Domain = defaultDomain
+	}
+	c := &conformance{api: factory(t), cfg: cfg}
+	c.caps = c.api.Capabilities()
+	steps := []struct {
+		name string
+		fn   func(t *testing.T)
+	}{
+		{"Capabilities", c.capabilities},
+		{"DomainAdd", c.domainAdd},
+		{"DomainList", c.domainList},
+		{"DomainGet", c.domainGet},
+		{"DomainDisable", c.domainDisable},
+		{"DomainEnable", c.domainEnable},
+		{"Line", c.line},
+		{"RecordAdd", c.recordAdd},
+		{"RecordList", c.recordList},
+		{"RecordUpdate", c.recordUpdate},
+		{"RecordDisable", c.recordDisable},
+		{"RecordEnable", c.recordEnable},
+		{"RecordDelete", c.recordDelete},
+		{"RecordMX", c.recordMX},
+		{"RecordData", c.recordData},
+		{"RecordProxied", c.recordProxied},
+		{"DomainDelete", c.domainDelete},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.fn) {
+			return
+		}
+	}
+}
+
+type conformance struct {
+	api      dnsdk.Api
+	cfg      Config
+	caps     dnsdk.Capabilities
+	domainId string
+	recordId string
+}
+
+func (c *conformance) skipUnless(t *testing.T, op dnsdk.Operation) {
+	t.Helper()
+	if !c.caps.Supports(op) {
+		t.Skipf("%s not supported", op)
+	}
+}
+
+func (c *conformance) ttl() uint {
+	ttl := uint(recordTTL)
+	if c.caps.TTLMin > ttl {
+		ttl = c.caps.TTLMin
+	}
+	if c.caps.TTLMax > 0 && c.caps.TTLMax < ttl {
+		ttl = c.caps.TTLMax
+	}
+	return ttl
+}
+
+func (c *conformance) capabilities(t *testing.T) {
+	if len(c.caps.Operations) == 0 {
+		t.Fatal("Capabilities().Operations is empty")
+	}
+	if !c.caps.SupportsRecordType("A") {
+		t.Fatal("Capabilities().RecordTypes does not include A")
+	}
+	if c.caps.TTLMin > 0 && c.caps.TTLMax > 0 && c.caps.TTLMin > c.caps.TTLMax {
+		t.Fatalf("TTLMin %d > TTLMax %d", c.caps.TTLMin, c.caps.TTLMax)
+	}
+}
+
+// line 校验域名的线路列表包含默认线路, 且上级线路均存在
+func (c *conformance) line(t *testing.T) {
+	c.skipUnless(t, dnsdk.OpLineList)
+	resp, err := c.api.LineList(dnsdk.LineListReq{DomainId: c.domainId, Domain: c.cfg.Domain})
+	if err != nil {
+		t.Fatalf("LineList: %v", err)
+	}
+	for _, line := range resp.List {
+		if _, ok := resp.Find(line.Parent); line.Parent != "" && !ok {
+			t.Errorf("line %q has unknown parent %q", line.Id, line.Parent)
+		}
+	}
+	def := c.api.LineDefault()
+	if _, ok := resp.Find(def.Id); !ok {
+		t.Fatalf("LineDefault %q not in LineList", def.Id)
+	}
+}
+
+// domainGet 按域名与域名Id获取域名详情, 新添加的域名应为启用状态
+func (c *conformance) domainGet(t *testing.T) {
+	c.skipUnless(t, dnsdk.OpDomainGet)
+	resp, err := c.api.DomainGet(dnsdk.DomainGetReq{DomainId: c.domainId, Domain: c.cfg.Domain})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Name != c.cfg.Domain {
+		t.Fatalf("Name = %q, want %q", resp.Name, c.cfg.Domain)
+	}
+	if c.domainId != "" && resp.Id != c.domainId {
+		t.Fatalf("Id = %q, want %q", resp.Id, c.domainId)
+	}
+	// 不支持启停的服务商可能不返回状态
+	if resp.Status != "enable" && (resp.Status != "" || c.caps.Supports(dnsdk.OpDomainDisable)) {
+		t.Fatalf("Status = %q, want enable", resp.Status)
+	}
+	c.domainId = resp.Id
+}
+
+func (c *conformance) domainDisable(t *testing.T) {
+	req := dnsdk.DomainDisableReq{DomainId: c.domainId, Domain: c.cfg.Domain}
+	c.domainStatus(t, dnsdk.OpDomainDisable, "disable", func() error { return c.api.DomainDisable(req) })
+}
+
+func (c *conformance) domainEnable(t *testing.T) {
+	req := dnsdk.DomainEnableReq{DomainId: c.domainId, Domain: c.cfg.Domain}
+	c.domainStatus(t, dnsdk.OpDomainEnable, "enable", func() error { return c.api.DomainEnable(req) })
+}
+
+// domainStatus 不支持的启停操作应返回 ErrUnsupported, 支持时 DomainGet 返回新的状态
+func (c *conformance) domainStatus(t *testing.T, op dnsdk.Operation, status string, fn func() error) {
+	if c.cfg.ExistingDomain {
+		t.Skip("existing domain")
+	}
+	if !c.caps.Supports(op) {
+		if err := fn(); !errors.Is(err, dnsdk.ErrUnsupported) {
+			t.Fatalf("unsupported %s returned %v, want ErrUnsupported", op, err)
+		}
+		t.Skipf("%s not supported", op)
+	}
+	if err := fn(); err != nil {
+		t.Fatalf("%s: %v", op, err)
+	}
+	if c.caps.Supports(dnsdk.OpDomainGet) {
+		resp, err := c.api.DomainGet(dnsdk.DomainGetReq{DomainId: c.domainId, Domain: c.cfg.Domain})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Status != status {
+			t.Fatalf("Status = %q, want %q", resp.Status, status)
+		}
+	}
+}
+
+func (c *conformance) domainAdd(t *testing.T) {
+	if c.cfg.ExistingDomain {
+		t.Skip("existing domain")
+	}
+	c.skipUnless(t, dnsdk.OpDomainAdd)
+	resp, err := c.api.DomainAdd(dnsdk.DomainAddReq{Domain: c.cfg.Domain})
+	if err != nil {
+		t.Fatalf("DomainAdd: %v", err)
+	}
+	if resp.Id == "" {
+		t.Fatal("DomainAdd returned empty id")
+	}
+	c.domainId = resp.Id
+}
+
+func (c *conformance) domainList(t *testing.T) {
+	c.skipUnless(t, dnsdk.OpDomainList)
+	domain, err := c.findDomain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if domain == nil {
+		t.Fatalf("DomainList does not contain %s", c.cfg.Domain)
+	}
+	if c.domainId != "" && domain.Id != c.domainId {
+		t.Fatalf("DomainList id = %q, DomainAdd id = %q", domain.Id, c.domainId)
+	}
+	c.domainId = domain.Id
+}
+
+func (c *conformance) recordAdd(t *testing.T) {
+	c.skipUnless(t, dnsdk.OpRecordAdd)
+	req := dnsdk.RecordAddReq{
+		DomainId: c.domainId,
+		Domain:   c.cfg.Domain,
+		Record:   recordName,
+		Type:     "A",
+		Value:    recordValue,
+		Line:     c.api.LineDefault().Id,
+		TTL:      c.ttl(),
+	}
+	if c.caps.Weight {
+		req.Weight = recordWeight
+	}
+	if c.caps.Remark {
+		req.Remark = recordRemark
+	}
+	resp, err := c.api.RecordAdd(req)
+	if err != nil {
+		t.Fatalf("RecordAdd: %v", err)
+	}
+	if resp.Id == "" {
+		t.Fatal("RecordAdd returned empty id")
+	}
+	c.recordId = resp.Id
+}
+
+func (c *conformance) recordList(t *testing.T) {
+	c.skipUnless(t, dnsdk.OpRecordList)
+	record := c.mustFindRecord(t)
+	c.checkRecord(t, record, recordValue)
+	if c.caps.Weight && record.Weight != recordWeight {
+		t.Errorf("Weight = %d, want %d", record.Weight, recordWeight)
+	}
+	if c.caps.Remark && record.Remark != recordRemark {
+		t.Errorf("Remark = %q, want %q", record.Remark, recordRemark)
+	}
+}
+
+func (c *conformance) recordUpdate(t *testing.T) {
+	c.skipUnless(t, dnsdk.OpRecordUpdate)
+	resp, err := c.api.RecordUpdate(dnsdk.RecordUpdateReq{
+		RecordId: c.recordId,
+		DomainId: c.domainId,
+		Domain:   c.cfg.Domain,
+		Record:   recordName,
+		Type:     "A",
+		Value:    recordUpdated,
+		Line:     string(dnsdk.LineDefault), // 通用线路由服务商转换
+		TTL:      c.ttl(),
+	})
+	if err != nil {
+		t.Fatalf("RecordUpdate: %v", err)
+	}
+	if resp.Id != "" {
+		c.recordId = resp.Id
+	}
+	if c.caps.Supports(dnsdk.OpRecordList) {
+		c.checkRecord(t, c.mustFindRecord(t), recordUpdated)
+	}
+}
+
+func (c *conformance) recordDisable(t *testing.T) {
+	req := dnsdk.RecordDisableReq{RecordId: c.recordId, DomainId: c.domainId, Domain: c.cfg.Domain}
+	c.recordStatus(t, dnsdk.OpRecordDisable, "disable", func() error { return c.api.RecordDisable(req) })
+}
+
+func (c *conformance) recordEnable(t *testing.T) {
+	req := dnsdk.RecordEnableReq{RecordId: c.recordId, DomainId: c.domainId, Domain: c.cfg.Domain}
+	c.recordStatus(t, dnsdk.OpRecordEnable, "enable", func() error { return c.api.RecordEnable(req) })
+}
+
+func (c *conformance) recordStatus(t *testing.T, op dnsdk.Operation, status string, fn func() error) {
+	if !c.caps.Supports(op) {
+		if err := fn(); !errors.Is(err, dnsdk.ErrUnsupported) {
+			t.Fatalf("unsupported %s returned %v, want ErrUnsupported", op, err)
+		}
+		t.Skipf("%s not supported", op)
+	}
+	if err := fn(); err != nil {
+		t.Fatalf("%s: %v", op, err)
+	}
+	if c.caps.Supports(dnsdk.OpRecordList) {
+		if record := c.mustFindRecord(t); record.Status != status {
+			t.Fatalf("Status = %q, want %q", record.Status, status)
+		}
+	}
+}
+
+func (c *conformance) recordDelete(t *testing.T) {
+	c.skipUnless(t, dnsdk.OpRecordDelete)
+	if err := c.api.RecordDelete(dnsdk.RecordDeleteReq{RecordId: c.recordId, DomainId: c.domainId}); err != nil {
+		t.Fatalf("RecordDelete: %v", err)
+	}
+	if c.caps.Supports(dnsdk.OpRecordList) {
+		record, err := c.findRecord()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record != nil {
+			t.Fatalf("record %s still listed after RecordDelete", c.recordId)
+		}
+	}
+}
+
+// recordMX 添加、修改并删除一条 MX 记录, 校验优先级
+func (c *conformance) recordMX(t *testing.T) {
+	if !c.caps.MXPriority || !c.caps.SupportsRecordType("MX") {
+		t.Skip("MX priority not supported")
+	}
+	c.skipUnless(t, dnsdk.OpRecordAdd)
+	c.skipUnless(t, dnsdk.OpRecordList)
+	req := dnsdk.RecordAddReq{
+		DomainId: c.domainId,
+		Domain:   c.cfg.Domain,
+		Record:   recordName,
+		Type:     "MX",
+		Value:    mxValue,
+		Line:     c.api.LineDefault().Id,
+		TTL:      c.ttl(),
+		MX:       mxPriority,
+	}
+	resp, err := c.api.RecordAdd(req)
+	if err != nil {
+		t.Fatalf("RecordAdd MX: %v", err)
+	}
+	id := resp.Id
+	defer func() {
+		if c.caps.Supports(dnsdk.OpRecordDelete) {
+			if err := c.api.RecordDelete(dnsdk.RecordDeleteReq{RecordId: id, DomainId: c.domainId}); err != nil {
+				t.Errorf("RecordDelete MX: %v", err)
+			}
+		}
+	}()
+	c.checkMX(t, id, mxPriority)
+	if !c.caps.Supports(dnsdk.OpRecordUpdate) {
+		return
+	}
+	update, err := c.api.RecordUpdate(dnsdk.RecordUpdateReq{
+		RecordId: id,
+		DomainId: c.domainId,
+		Domain:   c.cfg.Domain,
+		Record:   recordName,
+		Type:     "MX",
+		Value:    mxValue,
+		Line:     req.Line,
+		TTL:      req.TTL,
+		MX:       mxUpdated,
+	})
+	if err != nil {
+		t.Fatalf("RecordUpdate MX: %v", err)
+	}
+	if update.Id != "" {
+		id = update.Id
+	}
+	c.checkMX(t, id, mxUpdated)
+}
+
+func (c *conformance) checkMX(t *testing.T, id string, priority uint16) {
+	t.Helper()
+	req := dnsdk.RecordListReq{DomainId: c.domainId, Domain: c.cfg.Domain, Record: recordName, Type: "MX"}
+	for record, err := range dnsdk.AllRecords(c.api, req) {
+		if err != nil {
+			t.Fatalf("RecordList: %v", err)
+		}
+		if record.Id == id {
+			if record.MX != priority {
+				t.Errorf("MX = %d, want %d", record.MX, priority)
+			}
+			return
+		}
+	}
+	t.Fatalf("MX record %s not listed", id)
+}
+
+// recordData 以结构化记录值添加 SRV、CAA 记录, 校验 RecordList 返回的 Data 一致
+func (c *conformance) recordData(t *testing.T) {
+	c.skipUnless(t, dnsdk.OpRecordAdd)
+	c.skipUnless(t, dnsdk.OpRecordList)
+	for _, data := range conformanceData {
+		t.Run(data.Type(), func(t *testing.T) {
+			if !c.caps.SupportsRecordType(data.Type()) {
+				t.Skipf("%s not supported", data.Type())
+			}
+			name := recordName
+			if data.Type() == "SRV" {
+				name = srvName
+			}
+			resp, err := c.api.RecordAdd(dnsdk.RecordAddReq{
+				DomainId: c.domainId,
+				Domain:   c.cfg.Domain,
+				Record:   name,
+				Line:     c.api.LineDefault().Id,
+				TTL:      c.ttl(),
+				Data:     data,
+			})
+			if err != nil {
+				t.Fatalf("RecordAdd %s: %v", data.Type(), err)
+			}
+			defer func() {
+				if c.caps.Supports(dnsdk.OpRecordDelete) {
+					if err := c.api.RecordDelete(dnsdk.RecordDeleteReq{RecordId: resp.Id, DomainId: c.domainId}); err != nil {
+						t.Errorf("RecordDelete %s: %v", data.Type(), err)
+					}
+				}
+			}()
+			req := dnsdk.RecordListReq{DomainId: c.domainId, Domain: c.cfg.Domain, Record: name, Type: data.Type()}
+			for record, err := range dnsdk.AllRecords(c.api, req) {
+				if err != nil {
+					t.Fatalf("RecordList: %v", err)
+				}
+				if record.Id != resp.Id {
+					continue
+				}
+				got, err := record.Data()
+				if err != nil {
+					t.Fatalf("Data: %v", err)
+				}
+				if got.String() != data.String() {
+					t.Errorf("Data = %s, want %s", got, data)
+				}
+				return
+			}
+			t.Fatalf("%s record %s not listed", data.Type(), resp.Id)
+		})
+	}
+}
+
+func (c *conformance) domainDelete(t *testing.T) {
+	if c.cfg.ExistingDomain {
+		t.Skip("existing domain")
+	}
+	c.skipUnless(t, dnsdk.OpDomainDelete)
+	if err := c.api.DomainDelete(dnsdk.DomainDeleteReq{Domain: c.cfg.Domain, DomainId: c.domainId}); err != nil {
+		t.Fatalf("DomainDelete: %v", err)
+	}
+	if c.caps.Supports(dnsdk.OpDomainList) {
+		domain, err := c.findDomain()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if domain != nil {
+			t.Fatalf("domain %s still listed after DomainDelete", c.cfg.Domain)
+		}
+	}
+}
+
+func (c *conformance) checkRecord(t *testing.T, record *dnsdk.RecordListRespRecord, value string) {
+	t.Helper()
+	if record.Record != recordName {
+		t.Errorf("Record = %q, want %q", record.Record, recordName)
+	}
+	if name := fmt.Sprintf("%s.%s", recordName, c.cfg.Domain); record.Name != name {
+		t.Errorf("Name = %q, want %q", record.Name, name)
+	}
+	if record.Type != "A" {
+		t.Errorf("Type = %q, want A", record.Type)
+	}
+	if record.Value != value {
+		t.Errorf("Value = %q, want %q", record.Value, value)
+	}
+	if record.TTL != c.ttl() {
+		t.Errorf("TTL = %d, want %d", record.TTL, c.ttl())
+	}
+	if def := c.api.LineDefault().Id; record.Line != def || record.CanonicalLine != dnsdk.LineDefault {
+		t.Errorf("Line = %q (%q), want %q (%q)", record.Line, record.CanonicalLine, def, dnsdk.LineDefault)
+	}
+}
+
+func (c *conformance) findDomain() (*dnsdk.DomainListRespDomain, error) {
+	for domain, err := range dnsdk.AllDomains(c.api, dnsdk.DomainListReq{Domain: c.cfg.Domain}) {
+		if err != nil {
+			return nil, fmt.Errorf("DomainList: %w", err)
+		}
+		if domain.Name == c.cfg.Domain {
+			return &domain, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *conformance) findRecord() (*dnsdk.RecordListRespRecord, error) {
+	req := dnsdk.RecordListReq{DomainId: c.domainId, Domain: c.cfg.Domain, Record: recordName, Type: "A"}
+	for record, err := range dnsdk.AllRecords(c.api, req) {
+		if err != nil {
+			return nil, fmt.Errorf("RecordList: %w", err)
+		}
+		if record.Id == c.recordId {
+			return &record, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *conformance) mustFindRecord(t *testing.T) *dnsdk.RecordListRespRecord {
+	t.Helper()
+	record, err := c.findRecord()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil {
+		t.Fatalf("RecordList does not contain record %s", c.recordId)
+	}
+	return record
+}
+
+// recordProxied 未传 Proxied、Tags 与 Remark 的更新应保留原值, 空 Tags 与空 Remark 清空
+func (c *conformance) recordProxied(t *testing.T) {
+	if !c.caps.Proxied {
+		t.Skip("proxied not supported")
+	}
+	c.skipUnless(t, dnsdk.OpRecordAdd)
+	c.skipUnless(t, dnsdk.OpRecordList)
+	var (
+		tags          []string
+		remark        string
+		proxied, none = true, false
+	)
+	if c.caps.Tags {
+		tags = []string{recordTag}
+	}
+	if c.caps.Remark {
+		remark = recordRemark
+	}
+	resp, err := c.api.RecordAdd(dnsdk.RecordAddReq{
+		DomainId: c.domainId,
+		Domain:   c.cfg.Domain,
+		Record:   proxiedName,
+		Type:     "A",
+		Value:    recordValue,
+		Line:     c.api.LineDefault().Id,
+		TTL:      c.ttl(),
+		Remark:   remark,
+		Proxied:  &proxied,
+		Tags:     tags,
+	})
+	if err != nil {
+		t.Fatalf("RecordAdd: %v", err)
+	}
+	defer func() {
+		if c.caps.Supports(dnsdk.OpRecordDelete) {
+			if err := c.api.RecordDelete(dnsdk.RecordDeleteReq{RecordId: resp.Id, DomainId: c.domainId}); err != nil {
+				t.Errorf("RecordDelete: %v", err)
+			}
+		}
+	}()
+	check := func(step string, proxied bool, tags []string, remark string) {
+		req := dnsdk.RecordListReq{DomainId: c.domainId, Domain: c.cfg.Domain, Record: proxiedName, Type: "A"}
+		for record, err := range dnsdk.AllRecords(c.api, req) {
+			if err != nil {
+				t.Fatalf("%s RecordList: %v", step, err)
+			}
+			if record.Id != resp.Id {
+				continue
+			}
+			if record.Proxied != proxied {
+				t.Errorf("%s Proxied = %t, want %t", step, record.Proxied, proxied)
+			}
+			if c.caps.Tags && !slices.Equal(record.Tags, tags) {
+				t.Errorf("%s Tags = %v, want %v", step, record.Tags, tags)
+			}
+			if record.Remark != remark {
+				t.Errorf("%s Remark = %q, want %q", step, record.Remark, remark)
+			}
+			return
+		}
+		t.Fatalf("%s record %s not listed", step, resp.Id)
+	}
+	check("RecordAdd", true, tags, remark)
+	if !c.caps.Supports(dnsdk.OpRecordUpdate) {
+		return
+	}
+	update := func(step string, proxied *bool, tags *[]string, remark *string) {
+		if _, err := c.api.RecordUpdate(dnsdk.RecordUpdateReq{
+			RecordId: resp.Id,
+			DomainId: c.domainId,
+			Domain:   c.cfg.Domain,
+			Record:   proxiedName,
+			Type:     "A",
+			Value:    recordUpdated,
+			Line:     c.api.LineDefault().Id,
+			TTL:      c.ttl(),
+			Remark:   remark,
+			Proxied:  proxied,
+			Tags:     tags,
+		}); err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+	}
+	update("RecordUpdate", nil, nil, nil)
+	check("RecordUpdate", true, tags, remark)
+	update("RecordUpdate proxied=false", &none, &[]string{}, new(string))
+	check("RecordUpdate proxied=false", false, nil, "")
+}
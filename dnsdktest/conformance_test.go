@@ -0,0 +1,64 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdktest_test
+
+import (
+	"testing"
+
+	"github.com/go-the-way/dnsdk"
+	"github.com/go-the-way/dnsdk/dnsdktest"
+)
+
+func opts[T any](o T) T { return o }
+
+func TestConformanceMemory(t *testing.T) {
+	dnsdktest.RunConformance(t, func(t *testing.T) dnsdk.Api {
+		api, err := dnsdk.GetSupportApi(dnsdk.NewMemorySupportOpts(), dnsdk.MemorySupporter(opts[*dnsdk.MemorySupportOpts]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return api
+	})
+}
+
+func TestConformanceServers(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		server func(*dnsdk.MemorySupportOpts) *dnsdktest.Server
+		cfg    func(url string) (dnsdk.Api, error)
+	}{
+		{"alidns", dnsdktest.NewAlidnsServer, func(url string) (dnsdk.Api, error) {
+			return dnsdk.GetSupportApi(dnsdk.NewAlidnsSupportOpts("id", "secret").Endpoint(url), dnsdk.AlidnsSupporter(opts[*dnsdk.AlidnsSupportOpts]))
+		}},
+		{"dnspod", dnsdktest.NewDnspodServer, func(url string) (dnsdk.Api, error) {
+			return dnsdk.GetSupportApi(dnsdk.NewDnspodSupportOpt("id", "secret").Endpoint(url), dnsdk.DnspodSupporter(opts[*dnsdk.DnspodSupportOpts]))
+		}},
+		{"cloudflare", dnsdktest.NewCloudflareServer, func(url string) (dnsdk.Api, error) {
+			return dnsdk.GetSupportApi(dnsdk.NewCloudflareSupportOpts("dnsdk@example.com", "key").Endpoint(url), dnsdk.CloudflareSupporter(opts[*dnsdk.CloudflareSupportOpts]))
+		}},
+		{"pqdns", dnsdktest.NewPqdnsServer, func(url string) (dnsdk.Api, error) {
+			return dnsdk.GetSupportApi(dnsdk.NewPqdnsSupportOpts(url, "user", "secret"), dnsdk.PqdnsSupporter(opts[*dnsdk.PqdnsSupportOpts]))
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := tt.server(nil)
+			defer srv.Close()
+			dnsdktest.RunConformance(t, func(t *testing.T) dnsdk.Api {
+				api, err := tt.cfg(srv.URL)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return api
+			})
+		})
+	}
+}
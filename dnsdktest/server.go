@@ -0,0 +1,89 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdktest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/go-the-way/dnsdk"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+// Server 模拟服务商接口协议的本地 HTTP 服务, 数据保存在 Store 中
+type Server struct {
+	*httptest.Server
+	Store dnsdk.Api // 后端内存存储, 可用于准备数据与断言
+}
+
+// newServer opts 为 nil 时使用默认配置, opts 中注入的错误与延迟按服务商协议返回
+func newServer(opts *dnsdk.MemorySupportOpts, handler func(store dnsdk.Api) http.Handler) *Server {
+	if opts == nil {
+		opts = dnsdk.NewMemorySupportOpts()
+	}
+	store, err := dnsdk.GetSupportApi(opts, dnsdk.MemorySupporter(func(o *dnsdk.MemorySupportOpts) *dnsdk.MemorySupportOpts { return o }))
+	if err != nil {
+		panic(err)
+	}
+	return &Server{httptest.NewServer(handler(store)), store}
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func statusOf(err error) int {
+	switch dnsdk.KindOf(err) {
+	case dnsdk.ErrNotFound:
+		return http.StatusNotFound
+	case dnsdk.ErrAlreadyExists, dnsdk.ErrConflict:
+		return http.StatusConflict
+	case dnsdk.ErrUnauthorized:
+		return http.StatusForbidden
+	case dnsdk.ErrRateLimited:
+		return http.StatusTooManyRequests
+	case dnsdk.ErrInvalidInput, dnsdk.ErrUnsupported:
+		return http.StatusBadRequest
+	case dnsdk.ErrProviderUnavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func toUint(str string) uint {
+	i, _ := strconv.ParseUint(str, 10, 64)
+	return uint(i)
+}
+
+func toUint64(str string) uint64 {
+	i, _ := strconv.ParseUint(str, 10, 64)
+	return i
+}
+
+func parseTime(str string) time.Time {
+	t, _ := time.ParseInLocation(timeLayout, str, time.Local)
+	return t
+}
+
+// page 将 offset/limit 转换为页码
+func page(offset, limit uint) uint {
+	if limit == 0 {
+		return 1
+	}
+	return offset/limit + 1
+}
@@ -0,0 +1,281 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdktest
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-the-way/dnsdk"
+)
+
+var alidnsErrorCodes = map[dnsdk.ErrorKind]string{
+	dnsdk.ErrNotFound:            "InvalidDomainName.NoExist",
+	dnsdk.ErrAlreadyExists:       "DomainRecordDuplicate",
+	dnsdk.ErrConflict:            "DomainRecordConflict",
+	dnsdk.ErrUnauthorized:        "Forbidden.RAM",
+	dnsdk.ErrRateLimited:         "Throttling.User",
+	dnsdk.ErrInvalidInput:        "InvalidParameter",
+	dnsdk.ErrUnsupported:         "InvalidParameter",
+	dnsdk.ErrProviderUnavailable: "ServiceUnavailable",
+}
+
+// alidnsLines DescribeSupportLines 返回的线路, 省份线路的 FatherCode 为运营商
+var alidnsLines = []dnsdk.LineListRespLine{
+	{Id: "default", Name: "默认"},
+	{Id: "telecom", Name: "电信"},
+	{Id: "cn_telecom_beijing", Name: "电信_北京", Parent: "telecom"},
+	{Id: "cn_telecom_shanghai", Name: "电信_上海", Parent: "telecom"},
+	{Id: "unicom", Name: "联通"},
+	{Id: "cn_unicom_beijing", Name: "联通_北京", Parent: "unicom"},
+	{Id: "mobile", Name: "移动"},
+	{Id: "oversea", Name: "境外"},
+}
+
+// NewAlidnsServer 模拟 Alidns RPC 接口, 配合 AlidnsSupportOpts.Endpoint(srv.URL) 使用
+func NewAlidnsServer(opts *dnsdk.MemorySupportOpts) *Server {
+	return newServer(opts, func(store dnsdk.Api) http.Handler { return &alidnsHandler{store} })
+}
+
+type alidnsHandler struct{ store dnsdk.Api }
+
+func (h *alidnsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	action := r.Header.Get("x-acs-action")
+	if action == "" {
+		action = r.Form.Get("Action")
+	}
+	var (
+		body map[string]any
+		err  error
+	)
+	switch action {
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]any{"Code": "InvalidAction.NotFound", "Message": "unknown action " + action})
+		return
+	case "DescribeSupportLines":
+		body, err = h.describeSupportLines(r)
+	case "DescribeDomains":
+		body, err = h.describeDomains(r)
+	case "AddDomain":
+		body, err = h.addDomain(r)
+	case "DeleteDomain":
+		body, err = h.deleteDomain(r)
+	case "DescribeDomainDnssecInfo":
+		body, err = h.describeDomainDnssecInfo(r)
+	case "DescribeDomainRecords":
+		body, err = h.describeDomainRecords(r)
+	case "AddDomainRecord":
+		body, err = h.addDomainRecord(r)
+	case "UpdateDomainRecord":
+		body, err = h.updateDomainRecord(r)
+	case "DeleteDomainRecord":
+		body, err = h.deleteDomainRecord(r)
+	case "SetDomainRecordStatus":
+		body, err = h.setDomainRecordStatus(r)
+	}
+	if err != nil {
+		statusCode := statusOf(err)
+		if statusCode == http.StatusNotFound || statusCode == http.StatusConflict {
+			statusCode = http.StatusBadRequest
+		}
+		writeJSON(w, statusCode, map[string]any{
+			"Code":      alidnsErrorCodes[dnsdk.KindOf(err)],
+			"Message":   err.Error(),
+			"RequestId": "dnsdktest",
+		})
+		return
+	}
+	body["RequestId"] = "dnsdktest"
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (h *alidnsHandler) describeSupportLines(r *http.Request) (body map[string]any, err error) {
+	if name := r.Form.Get("DomainName"); name != "" {
+		if _, err = h.store.LineListContext(r.Context(), dnsdk.LineListReq{Domain: name}); err != nil {
+			return
+		}
+	}
+	lines := make([]map[string]any, 0, len(alidnsLines))
+	for _, l := range alidnsLines {
+		lines = append(lines, map[string]any{
+			"LineCode":        l.Id,
+			"LineName":        l.Name,
+			"LineDisplayName": l.Name,
+			"FatherCode":      l.Parent,
+		})
+	}
+	return map[string]any{"RecordLines": map[string]any{"RecordLine": lines}}, nil
+}
+
+func (h *alidnsHandler) describeDomains(r *http.Request) (body map[string]any, err error) {
+	q := r.Form
+	resp, err := h.store.DomainListContext(r.Context(), dnsdk.DomainListReq{
+		Page:   toUint(q.Get("PageNumber")),
+		Limit:  toUint(q.Get("PageSize")),
+		Domain: q.Get("KeyWord"),
+	})
+	if err != nil {
+		return
+	}
+	domains := make([]map[string]any, 0, len(resp.List))
+	for _, d := range resp.List {
+		domains = append(domains, map[string]any{
+			"DomainId":        d.Id,
+			"DomainName":      d.Name,
+			"DnsServers":      map[string]any{"DnsServer": d.DnsServer},
+			"RecordCount":     d.RecordCount,
+			"Remark":          d.Remark,
+			"CreateTimestamp": parseTime(d.CreateTime).UnixMilli(),
+			"VersionCode":     "mianfei",
+			"VersionName":     "免费版",
+		})
+	}
+	return map[string]any{
+		"TotalCount": resp.Total,
+		"PageNumber": toUint(q.Get("PageNumber")),
+		"PageSize":   toUint(q.Get("PageSize")),
+		"Domains":    map[string]any{"Domain": domains},
+	}, nil
+}
+
+func (h *alidnsHandler) addDomain(r *http.Request) (body map[string]any, err error) {
+	name := r.Form.Get("DomainName")
+	resp, err := h.store.DomainAddContext(r.Context(), dnsdk.DomainAddReq{Domain: name})
+	if err != nil {
+		return
+	}
+	return map[string]any{
+		"DomainId":   resp.Id,
+		"DomainName": name,
+		"DnsServers": map[string]any{"DnsServer": resp.DnsServer},
+	}, nil
+}
+
+func (h *alidnsHandler) deleteDomain(r *http.Request) (body map[string]any, err error) {
+	name := r.Form.Get("DomainName")
+	if err = h.store.DomainDeleteContext(r.Context(), dnsdk.DomainDeleteReq{Domain: name}); err != nil {
+		return
+	}
+	return map[string]any{"DomainName": name}, nil
+}
+
+func (h *alidnsHandler) describeDomainDnssecInfo(r *http.Request) (body map[string]any, err error) {
+	name := r.Form.Get("DomainName")
+	resp, err := h.store.DomainGetContext(r.Context(), dnsdk.DomainGetReq{Domain: name})
+	if err != nil {
+		return
+	}
+	status := "OFF"
+	if resp.DNSSEC == "enable" {
+		status = "ON"
+	}
+	return map[string]any{"DomainName": name, "Status": status}, nil
+}
+
+func (h *alidnsHandler) describeDomainRecords(r *http.Request) (body map[string]any, err error) {
+	q := r.Form
+	name := q.Get("DomainName")
+	resp, err := h.store.RecordListContext(r.Context(), dnsdk.RecordListReq{
+		Page:      toUint(q.Get("PageNumber")),
+		Limit:     toUint(q.Get("PageSize")),
+		Domain:    name,
+		Record:    q.Get("RRKeyWord"),
+		Type:      q.Get("Type"),
+		Line:      q.Get("Line"),
+		Value:     q.Get("ValueKeyWord"),
+		Order:     q.Get("OrderBy"),
+		Direction: q.Get("Direction"),
+	})
+	if err != nil {
+		return
+	}
+	records := make([]map[string]any, 0, len(resp.List))
+	for _, rc := range resp.List {
+		records = append(records, map[string]any{
+			"RecordId":        rc.Id,
+			"RR":              rc.Record,
+			"DomainName":      name,
+			"Type":            rc.Type,
+			"Value":           rc.Value,
+			"Line":            rc.Line,
+			"TTL":             rc.TTL,
+			"Priority":        rc.MX,
+			"Weight":          rc.Weight,
+			"Remark":          rc.Remark,
+			"Status":          strings.ToUpper(rc.Status),
+			"CreateTimestamp": parseTime(rc.CreateTime).UnixMilli(),
+			"UpdateTimestamp": parseTime(rc.UpdateTime).UnixMilli(),
+		})
+	}
+	return map[string]any{
+		"TotalCount":    resp.Total,
+		"PageNumber":    toUint(q.Get("PageNumber")),
+		"PageSize":      toUint(q.Get("PageSize")),
+		"DomainRecords": map[string]any{"Record": records},
+	}, nil
+}
+
+func (h *alidnsHandler) addDomainRecord(r *http.Request) (body map[string]any, err error) {
+	q := r.Form
+	resp, err := h.store.RecordAddContext(r.Context(), dnsdk.RecordAddReq{
+		Domain: q.Get("DomainName"),
+		Record: q.Get("RR"),
+		Type:   q.Get("Type"),
+		Value:  q.Get("Value"),
+		Line:   q.Get("Line"),
+		TTL:    toUint(q.Get("TTL")),
+		MX:     uint16(toUint(q.Get("Priority"))),
+	})
+	if err != nil {
+		return
+	}
+	return map[string]any{"RecordId": resp.Id}, nil
+}
+
+func (h *alidnsHandler) updateDomainRecord(r *http.Request) (body map[string]any, err error) {
+	q := r.Form
+	resp, err := h.store.RecordUpdateContext(r.Context(), dnsdk.RecordUpdateReq{
+		RecordId: q.Get("RecordId"),
+		Record:   q.Get("RR"),
+		Type:     q.Get("Type"),
+		Value:    q.Get("Value"),
+		Line:     q.Get("Line"),
+		TTL:      toUint(q.Get("TTL")),
+		MX:       uint16(toUint(q.Get("Priority"))),
+	})
+	if err != nil {
+		return
+	}
+	return map[string]any{"RecordId": resp.Id}, nil
+}
+
+func (h *alidnsHandler) deleteDomainRecord(r *http.Request) (body map[string]any, err error) {
+	id := r.Form.Get("RecordId")
+	if err = h.store.RecordDeleteContext(r.Context(), dnsdk.RecordDeleteReq{RecordId: id}); err != nil {
+		return
+	}
+	return map[string]any{"RecordId": id}, nil
+}
+
+func (h *alidnsHandler) setDomainRecordStatus(r *http.Request) (body map[string]any, err error) {
+	id, status := r.Form.Get("RecordId"), r.Form.Get("Status")
+	if strings.EqualFold(status, "Enable") {
+		err = h.store.RecordEnableContext(r.Context(), dnsdk.RecordEnableReq{RecordId: id})
+	} else {
+		err = h.store.RecordDisableContext(r.Context(), dnsdk.RecordDisableReq{RecordId: id})
+	}
+	if err != nil {
+		return
+	}
+	return map[string]any{"RecordId": id, "Status": status}, nil
+}
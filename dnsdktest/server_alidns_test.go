@@ -0,0 +1,57 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdktest_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-the-way/dnsdk"
+	"github.com/go-the-way/dnsdk/dnsdktest"
+)
+
+func TestAlidnsDomainGet(t *testing.T) {
+	var dnssecFails bool
+	srv := dnsdktest.NewAlidnsServer(dnsdk.NewMemorySupportOpts().FaultFunc(func(op dnsdk.Operation) error {
+		// 服务端 DescribeDomainDnssecInfo 通过 DomainGet 查询
+		if dnssecFails && op == dnsdk.OpDomainGet {
+			return errors.New("fault")
+		}
+		return nil
+	}))
+	defer srv.Close()
+	api, err := dnsdk.GetSupportApi(dnsdk.NewAlidnsSupportOpts("id", "secret").Endpoint(srv.URL), dnsdk.AlidnsSupporter(opts[*dnsdk.AlidnsSupportOpts]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last dnsdk.DomainAddResp
+	for i := range 150 {
+		if last, err = srv.Store.DomainAdd(dnsdk.DomainAddReq{Domain: fmt.Sprintf("d%03d.example.com", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 只有域名Id时逐页查找
+	resp, err := api.DomainGet(dnsdk.DomainGetReq{DomainId: last.Id})
+	if err != nil || resp.Name != "d149.example.com" || resp.DNSSEC != "disable" {
+		t.Fatalf("DomainGet by id = %+v %v, want d149.example.com on the second page", resp, err)
+	}
+	if _, err = api.DomainGet(dnsdk.DomainGetReq{DomainId: "unknown"}); !errors.Is(err, dnsdk.ErrNotFound) {
+		t.Fatalf("DomainGet unknown id = %v, want ErrNotFound", err)
+	}
+
+	dnssecFails = true
+	if resp, err = api.DomainGet(dnsdk.DomainGetReq{Domain: "d001.example.com"}); err != nil || resp.Name != "d001.example.com" || resp.DNSSEC != "" {
+		t.Fatalf("DomainGet with DNSSEC failure = %+v %v, want the domain with an empty DNSSEC status", resp, err)
+	}
+}
@@ -0,0 +1,503 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdktest
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-the-way/dnsdk"
+)
+
+var cloudflareErrorCodes = map[dnsdk.ErrorKind]int{
+	dnsdk.ErrNotFound:            81044,
+	dnsdk.ErrAlreadyExists:       81057,
+	dnsdk.ErrConflict:            81053,
+	dnsdk.ErrUnauthorized:        10000,
+	dnsdk.ErrRateLimited:         10013,
+	dnsdk.ErrInvalidInput:        1004,
+	dnsdk.ErrUnsupported:         1004,
+	dnsdk.ErrProviderUnavailable: 10001,
+}
+
+// NewCloudflareServer 模拟 Cloudflare v4 接口, 配合 CloudflareSupportOpts.Endpoint(srv.URL) 使用
+func NewCloudflareServer(opts *dnsdk.MemorySupportOpts) *Server {
+	return newServer(opts, func(store dnsdk.Api) http.Handler {
+		h := &cloudflareHandler{store: store, accounts: make(map[string]string)}
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /zones", h.handle(h.listZones))
+		mux.HandleFunc("POST /zones", h.handle(h.createZone))
+		mux.HandleFunc("GET /zones/{zone}", h.handle(h.getZone))
+		mux.HandleFunc("DELETE /zones/{zone}", h.handle(h.deleteZone))
+		mux.HandleFunc("GET /zones/{zone}/dnssec", h.handle(h.getDNSSEC))
+		mux.HandleFunc("GET /zones/{zone}/dns_records", h.handle(h.listRecords))
+		mux.HandleFunc("POST /zones/{zone}/dns_records", h.handle(h.createRecord))
+		mux.HandleFunc("PATCH /zones/{zone}/dns_records/{record}", h.handle(h.updateRecord))
+		mux.HandleFunc("DELETE /zones/{zone}/dns_records/{record}", h.handle(h.deleteRecord))
+		return mux
+	})
+}
+
+type (
+	cloudflareHandler struct {
+		store    dnsdk.Api
+		mu       sync.Mutex
+		accounts map[string]string // zone Id => 账号Id
+	}
+
+	cloudflareResultInfo struct {
+		Page       uint `json:"page"`
+		PerPage    uint `json:"per_page"`
+		Count      uint `json:"count"`
+		TotalCount uint `json:"total_count"`
+		TotalPages uint `json:"total_pages"`
+	}
+
+	cloudflareRecordReq struct {
+		Type     string                `json:"type"`
+		Name     string                `json:"name"`
+		Content  string                `json:"content"`
+		TTL      uint                  `json:"ttl"`
+		Priority *uint16               `json:"priority"`
+		Comment  *string               `json:"comment"`
+		Data     *cloudflareRecordData `json:"data"`
+		Proxied  *bool                 `json:"proxied"`
+		Tags     *[]string             `json:"tags"`
+	}
+
+	// cloudflareRecordData SRV、CAA、HTTPS、SVCB 记录的 data 字段
+	cloudflareRecordData struct {
+		Flags    uint8  `json:"flags"`
+		Tag      string `json:"tag"`
+		Priority uint16 `json:"priority"`
+		Weight   uint16 `json:"weight"`
+		Port     uint16 `json:"port"`
+		Target   string `json:"target"`
+		Value    string `json:"value"`
+	}
+)
+
+var (
+	errCloudflareZoneNotFound = &dnsdk.Error{Kind: dnsdk.ErrNotFound, Err: errors.New("zone not found")}
+	errCloudflareAuth         = &dnsdk.Error{Kind: dnsdk.ErrUnauthorized, Err: errors.New("authentication error")}
+	errCloudflareProxied      = &dnsdk.Error{Kind: dnsdk.ErrInvalidInput, Err: errors.New("this record type cannot be proxied")}
+)
+
+func (h *cloudflareHandler) handle(fn func(r *http.Request) (result any, info *cloudflareResultInfo, err error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			result any
+			info   *cloudflareResultInfo
+			err    error
+		)
+		// 接受 API Token 或 Global API Key, 不校验具体值
+		if r.Header.Get("Authorization") == "" && r.Header.Get("X-Auth-Key") == "" {
+			err = errCloudflareAuth
+		} else {
+			result, info, err = fn(r)
+		}
+		if err != nil {
+			code := cloudflareErrorCodes[dnsdk.KindOf(err)]
+			switch err {
+			case errCloudflareZoneNotFound:
+				code = 7003
+			case errCloudflareProxied:
+				code = 9004
+			}
+			writeJSON(w, statusOf(err), map[string]any{
+				"success":  false,
+				"errors":   []map[string]any{{"code": code, "message": err.Error()}},
+				"messages": []any{},
+				"result":   nil,
+			})
+			return
+		}
+		body := map[string]any{"success": true, "errors": []any{}, "messages": []any{}, "result": result}
+		if info != nil {
+			body["result_info"] = info
+		}
+		writeJSON(w, http.StatusOK, body)
+	}
+}
+
+func resultInfo(page, perPage, count, total uint) *cloudflareResultInfo {
+	if page == 0 {
+		page = 1
+	}
+	info := &cloudflareResultInfo{Page: page, PerPage: perPage, Count: count, TotalCount: total, TotalPages: 1}
+	if perPage > 0 {
+		info.TotalPages = (total + perPage - 1) / perPage
+	}
+	return info
+}
+
+// zone 按 Id 查找 zone
+func (h *cloudflareHandler) zone(r *http.Request) (zone dnsdk.DomainListRespDomain, err error) {
+	resp, err := h.store.DomainListContext(r.Context(), dnsdk.DomainListReq{})
+	if err != nil {
+		return
+	}
+	for _, d := range resp.List {
+		if d.Id == r.PathValue("zone") {
+			return d, nil
+		}
+	}
+	return zone, errCloudflareZoneNotFound
+}
+
+func (h *cloudflareHandler) zoneResult(d dnsdk.DomainListRespDomain) map[string]any {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return map[string]any{
+		"account":      map[string]any{"id": h.accounts[d.Id]},
+		"id":           d.Id,
+		"name":         d.Name,
+		"status":       "active",
+		"type":         "full",
+		"plan":         map[string]any{"id": "0feeeeeeeeeeeeeeeeeeeeeeeeeeeeee", "name": "Free Website", "legacy_id": "free"},
+		"name_servers": d.DnsServer,
+		"created_on":   parseTime(d.CreateTime),
+	}
+}
+
+func (h *cloudflareHandler) listZones(r *http.Request) (result any, info *cloudflareResultInfo, err error) {
+	q := r.URL.Query()
+	resp, err := h.store.DomainListContext(r.Context(), dnsdk.DomainListReq{Domain: q.Get("name")})
+	if err != nil {
+		return
+	}
+	var zones []dnsdk.DomainListRespDomain
+	for _, d := range resp.List {
+		if name := q.Get("name"); name != "" && d.Name != name {
+			continue
+		}
+		if account := q.Get("account.id"); account != "" && h.account(d.Id) != account {
+			continue
+		}
+		zones = append(zones, d)
+	}
+	pageNum, perPage := toUint(q.Get("page")), toUint(q.Get("per_page"))
+	list := make([]map[string]any, 0)
+	for i, d := range zones {
+		if perPage == 0 || (uint(i) >= (max(pageNum, 1)-1)*perPage && uint(i) < max(pageNum, 1)*perPage) {
+			list = append(list, h.zoneResult(d))
+		}
+	}
+	return list, resultInfo(pageNum, perPage, uint(len(list)), uint(len(zones))), nil
+}
+
+func (h *cloudflareHandler) createZone(r *http.Request) (result any, info *cloudflareResultInfo, err error) {
+	// cloudflare-go 以旧字段 organization 提交账号
+	type account struct {
+		ID string `json:"id"`
+	}
+	var req struct {
+		Name         string  `json:"name"`
+		Account      account `json:"account"`
+		Organization account `json:"organization"`
+	}
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, nil, &dnsdk.Error{Kind: dnsdk.ErrInvalidInput, Err: err}
+	}
+	resp, err := h.store.DomainAddContext(r.Context(), dnsdk.DomainAddReq{Domain: req.Name})
+	if err != nil {
+		if dnsdk.KindOf(err) == dnsdk.ErrAlreadyExists {
+			err = &dnsdk.Error{Kind: dnsdk.ErrAlreadyExists, Err: errors.New("zone already exists")}
+		}
+		return
+	}
+	h.mu.Lock()
+	h.accounts[resp.Id] = cmp.Or(req.Account.ID, req.Organization.ID)
+	h.mu.Unlock()
+	return h.zoneResult(dnsdk.DomainListRespDomain{Id: resp.Id, Name: req.Name, DnsServer: resp.DnsServer}), nil, nil
+}
+
+func (h *cloudflareHandler) account(zoneId string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.accounts[zoneId]
+}
+
+func (h *cloudflareHandler) getZone(r *http.Request) (result any, info *cloudflareResultInfo, err error) {
+	zone, err := h.zone(r)
+	if err != nil {
+		return
+	}
+	return h.zoneResult(zone), nil, nil
+}
+
+func (h *cloudflareHandler) getDNSSEC(r *http.Request) (result any, info *cloudflareResultInfo, err error) {
+	zone, err := h.zone(r)
+	if err != nil {
+		return
+	}
+	resp, err := h.store.DomainGetContext(r.Context(), dnsdk.DomainGetReq{DomainId: zone.Id})
+	if err != nil {
+		return
+	}
+	status := "disabled"
+	if resp.DNSSEC == "enable" {
+		status = "active"
+	}
+	return map[string]any{"status": status}, nil, nil
+}
+
+func (h *cloudflareHandler) deleteZone(r *http.Request) (result any, info *cloudflareResultInfo, err error) {
+	zone, err := h.zone(r)
+	if err != nil {
+		return
+	}
+	if err = h.store.DomainDeleteContext(r.Context(), dnsdk.DomainDeleteReq{DomainId: zone.Id}); err != nil {
+		return
+	}
+	h.mu.Lock()
+	delete(h.accounts, zone.Id)
+	h.mu.Unlock()
+	return map[string]any{"id": zone.Id}, nil, nil
+}
+
+// relative 将完整记录名转换为主机记录 => www.example.com => www
+func relative(name, zone string) string {
+	if name == zone || name == "" {
+		return "@"
+	}
+	return strings.TrimSuffix(name, "."+zone)
+}
+
+// value 将 data 转换为通用格式的记录值
+func (d *cloudflareRecordData) value(typ, content string) (value string, err error) {
+	if d == nil {
+		return content, nil
+	}
+	var data dnsdk.RecordData
+	switch strings.ToUpper(typ) {
+	case "SRV":
+		data = dnsdk.SRV{Priority: d.Priority, Weight: d.Weight, Port: d.Port, Target: d.Target}
+	case "CAA":
+		data = dnsdk.CAA{Flags: d.Flags, Tag: d.Tag, Value: d.Value}
+	case "HTTPS", "SVCB":
+		if data, err = dnsdk.ParseRecordData(typ, fmt.Sprintf("%d %s %s", d.Priority, d.Target, d.Value)); err != nil {
+			return
+		}
+	default:
+		return "", &dnsdk.Error{Kind: dnsdk.ErrInvalidInput, Err: fmt.Errorf("data is not supported for %s records", typ)}
+	}
+	return data.String(), nil
+}
+
+// recordData 与 Cloudflare 一致, 结构化记录返回 data, SRV 的 content 不含优先级
+func recordData(result map[string]any, rc dnsdk.RecordListRespRecord) {
+	data, err := rc.Data()
+	if err != nil {
+		return
+	}
+	switch d := data.(type) {
+	case dnsdk.SRV:
+		result["data"] = cloudflareRecordData{Priority: d.Priority, Weight: d.Weight, Port: d.Port, Target: d.Target}
+		result["content"] = fmt.Sprintf("%d %d %s", d.Weight, d.Port, d.Target)
+		result["priority"] = d.Priority
+	case dnsdk.CAA:
+		result["data"] = cloudflareRecordData{Flags: d.Flags, Tag: d.Tag, Value: d.Value}
+	case dnsdk.SVCB:
+		result["data"] = cloudflareRecordData{Priority: d.Priority, Target: d.Target, Value: d.ParamsString()}
+	case dnsdk.HTTPS:
+		result["data"] = cloudflareRecordData{Priority: d.Priority, Target: d.Target, Value: d.ParamsString()}
+	}
+}
+
+func recordResult(zone dnsdk.DomainListRespDomain, rc dnsdk.RecordListRespRecord) map[string]any {
+	result := map[string]any{
+		"id":          rc.Id,
+		"zone_id":     zone.Id,
+		"zone_name":   zone.Name,
+		"name":        rc.Name,
+		"type":        rc.Type,
+		"content":     rc.Value,
+		"ttl":         rc.TTL,
+		"comment":     rc.Remark,
+		"proxied":     rc.Proxied,
+		"proxiable":   proxiable(rc.Type),
+		"tags":        rc.Tags,
+		"created_on":  parseTime(rc.CreateTime),
+		"modified_on": parseTime(rc.UpdateTime),
+	}
+	if strings.EqualFold(rc.Type, "MX") {
+		result["priority"] = rc.MX
+	}
+	recordData(result, rc)
+	return result
+}
+
+func proxiable(typ string) bool {
+	return slices.Contains([]string{"A", "AAAA", "CNAME"}, strings.ToUpper(typ))
+}
+
+func (h *cloudflareHandler) listRecords(r *http.Request) (result any, info *cloudflareResultInfo, err error) {
+	zone, err := h.zone(r)
+	if err != nil {
+		return
+	}
+	q := r.URL.Query()
+	record := ""
+	if name := q.Get("name"); name != "" {
+		record = relative(name, zone.Name)
+	}
+	pageNum, perPage := toUint(q.Get("page")), toUint(q.Get("per_page"))
+	resp, err := h.store.RecordListContext(r.Context(), dnsdk.RecordListReq{
+		Page:      pageNum,
+		Limit:     perPage,
+		DomainId:  zone.Id,
+		Record:    record,
+		Type:      q.Get("type"),
+		Value:     q.Get("content"),
+		Remark:    q.Get("comment"),
+		Order:     q.Get("order"),
+		Direction: q.Get("direction"),
+	})
+	if err != nil {
+		return
+	}
+	list := make([]map[string]any, 0, len(resp.List))
+	for _, rc := range resp.List {
+		list = append(list, recordResult(zone, rc))
+	}
+	return list, resultInfo(pageNum, perPage, uint(len(list)), resp.Total), nil
+}
+
+// find 按 Id 查找记录
+func (h *cloudflareHandler) find(r *http.Request, zone dnsdk.DomainListRespDomain) (record dnsdk.RecordListRespRecord, err error) {
+	resp, err := h.store.RecordListContext(r.Context(), dnsdk.RecordListReq{DomainId: zone.Id})
+	if err != nil {
+		return
+	}
+	for _, rc := range resp.List {
+		if rc.Id == r.PathValue("record") {
+			return rc, nil
+		}
+	}
+	return record, &dnsdk.Error{Kind: dnsdk.ErrNotFound, Err: errors.New("record does not exist")}
+}
+
+func (h *cloudflareHandler) createRecord(r *http.Request) (result any, info *cloudflareResultInfo, err error) {
+	zone, err := h.zone(r)
+	if err != nil {
+		return
+	}
+	var req cloudflareRecordReq
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, nil, &dnsdk.Error{Kind: dnsdk.ErrInvalidInput, Err: err}
+	}
+	value, err := req.Data.value(req.Type, req.Content)
+	if err != nil {
+		return
+	}
+	add := dnsdk.RecordAddReq{
+		DomainId: zone.Id,
+		Record:   relative(req.Name, zone.Name),
+		Type:     req.Type,
+		Value:    value,
+		TTL:      req.TTL,
+	}
+	if req.Priority != nil && strings.EqualFold(req.Type, "MX") {
+		add.MX = *req.Priority
+	}
+	if req.Comment != nil {
+		add.Remark = *req.Comment
+	}
+	if req.Proxied != nil && *req.Proxied && !proxiable(req.Type) {
+		return nil, nil, errCloudflareProxied
+	}
+	add.Proxied = req.Proxied
+	if req.Tags != nil {
+		add.Tags = *req.Tags
+	}
+	resp, err := h.store.RecordAddContext(r.Context(), add)
+	if err != nil {
+		return
+	}
+	return recordResult(zone, resp.RecordListRespRecord), nil, nil
+}
+
+func (h *cloudflareHandler) updateRecord(r *http.Request) (result any, info *cloudflareResultInfo, err error) {
+	zone, err := h.zone(r)
+	if err != nil {
+		return
+	}
+	record, err := h.find(r, zone)
+	if err != nil {
+		return
+	}
+	var req cloudflareRecordReq
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, nil, &dnsdk.Error{Kind: dnsdk.ErrInvalidInput, Err: err}
+	}
+	// PATCH 仅更新传入的字段
+	update := dnsdk.RecordUpdateReq{
+		DomainId: zone.Id,
+		RecordId: record.Id,
+		Record:   record.Record,
+		Type:     record.Type,
+		Value:    record.Value,
+		Line:     record.Line,
+		TTL:      record.TTL,
+		MX:       record.MX,
+		Remark:   req.Comment,
+		Tags:     req.Tags,
+	}
+	if req.Name != "" {
+		update.Record = relative(req.Name, zone.Name)
+	}
+	if req.Type != "" {
+		update.Type = req.Type
+	}
+	if req.Content != "" || req.Data != nil {
+		if update.Value, err = req.Data.value(update.Type, req.Content); err != nil {
+			return
+		}
+	}
+	if req.TTL != 0 {
+		update.TTL = req.TTL
+	}
+	if req.Priority != nil && strings.EqualFold(update.Type, "MX") {
+		update.MX = *req.Priority
+	}
+	proxied := record.Proxied
+	if req.Proxied != nil {
+		proxied = *req.Proxied
+	}
+	if proxied && !proxiable(update.Type) {
+		return nil, nil, errCloudflareProxied
+	}
+	update.Proxied = req.Proxied
+	resp, err := h.store.RecordUpdateContext(r.Context(), update)
+	if err != nil {
+		return
+	}
+	return recordResult(zone, resp.RecordListRespRecord), nil, nil
+}
+
+func (h *cloudflareHandler) deleteRecord(r *http.Request) (result any, info *cloudflareResultInfo, err error) {
+	zone, err := h.zone(r)
+	if err != nil {
+		return
+	}
+	if err = h.store.RecordDeleteContext(r.Context(), dnsdk.RecordDeleteReq{DomainId: zone.Id, RecordId: r.PathValue("record")}); err != nil {
+		return
+	}
+	return map[string]any{"id": r.PathValue("record")}, nil, nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdktest_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-the-way/dnsdk"
+	"github.com/go-the-way/dnsdk/dnsdktest"
+)
+
+func TestCloudflareDomainList(t *testing.T) {
+	srv := dnsdktest.NewCloudflareServer(dnsdk.NewMemorySupportOpts())
+	defer srv.Close()
+	api, err := dnsdk.GetSupportApi(dnsdk.NewCloudflareSupportOpts("dnsdk@example.com", "key").Endpoint(srv.URL), dnsdk.CloudflareSupporter(opts[*dnsdk.CloudflareSupportOpts]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 120 {
+		if _, err = srv.Store.DomainAdd(dnsdk.DomainAddReq{Domain: fmt.Sprintf("d%03d.example.com", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 每页不超过 50 个 zone, 总数取自 result_info
+	resp, err := api.DomainList(dnsdk.DomainListReq{Page: 3, Limit: 100})
+	if err != nil || len(resp.List) != 20 || resp.Total != 120 || resp.List[0].Name != "d100.example.com" {
+		t.Fatalf("page 3 = %d domains, total %d, %v, want d100-d119 of 120", len(resp.List), resp.Total, err)
+	}
+	var names []string
+	for d, err := range dnsdk.AllDomains(api, dnsdk.DomainListReq{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, d.Name)
+	}
+	if len(names) != 120 || names[119] != "d119.example.com" {
+		t.Fatalf("AllDomains = %d domains, want 120", len(names))
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdktest
+
+import (
+	"bytes"
+	"net"
+	"strings"
+
+	"github.com/go-the-way/dnsdk"
+	"github.com/miekg/dns"
+)
+
+// DNSServer 根据 Store 中的记录应答查询的本地权威服务器(UDP 与 TCP), 仅应答默认线路上启用的记录,
+// 可作为 VerifyReq.Nameservers 验证记录是否生效
+type DNSServer struct {
+	Addr  string    // 监听地址 => 127.0.0.1:53535
+	Store dnsdk.Api // 记录来源, 通常为 Server.Store
+	udp   *dns.Server
+	tcp   *dns.Server
+}
+
+// NewDNSServer 在 127.0.0.1 的随机端口上启动服务, 使用完毕后调用 Close
+func NewDNSServer(store dnsdk.Api) *DNSServer {
+	s := &DNSServer{Store: store}
+	for i := 0; ; i++ {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			panic(err)
+		}
+		l, err := net.Listen("tcp", pc.LocalAddr().String())
+		if err != nil {
+			_ = pc.Close()
+			if i < 10 {
+				continue
+			}
+			panic(err)
+		}
+		s.Addr = pc.LocalAddr().String()
+		s.udp = s.start(&dns.Server{PacketConn: pc})
+		s.tcp = s.start(&dns.Server{Listener: l})
+		return s
+	}
+}
+
+func (s *DNSServer) start(srv *dns.Server) *dns.Server {
+	started := make(chan struct{})
+	srv.Handler, srv.NotifyStartedFunc = s, func() { close(started) }
+	go func() { _ = srv.ActivateAndServe() }()
+	<-started
+	return srv
+}
+
+func (s *DNSServer) Close() {
+	_ = s.udp.Shutdown()
+	_ = s.tcp.Shutdown()
+}
+
+func (s *DNSServer) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+	if len(r.Question) == 1 {
+		m.Rcode, m.Answer = s.answer(r.Question[0])
+	}
+	_ = w.WriteMsg(m)
+}
+
+// answer 按最长后缀匹配域名, 将域名导出为区域文件后查找与问题匹配的记录
+func (s *DNSServer) answer(q dns.Question) (rcode int, answer []dns.RR) {
+	var origin string
+	var zone dnsdk.DomainListRespDomain
+	for d, err := range dnsdk.AllDomains(s.Store, dnsdk.DomainListReq{}) {
+		if err != nil {
+			return dns.RcodeServerFailure, nil
+		}
+		if o := dns.Fqdn(strings.ToLower(d.Name)); dns.IsSubDomain(o, strings.ToLower(q.Name)) && len(o) > len(origin) {
+			origin, zone = o, d
+		}
+	}
+	if origin == "" {
+		return dns.RcodeRefused, nil
+	}
+	var buf bytes.Buffer
+	if _, err := dnsdk.ExportZone(s.Store, &buf, dnsdk.ZoneReq{DomainId: zone.Id, Domain: zone.Name}); err != nil {
+		return dns.RcodeServerFailure, nil
+	}
+	rcode = dns.RcodeNameError
+	if strings.EqualFold(q.Name, origin) {
+		rcode = dns.RcodeSuccess
+	}
+	zp := dns.NewZoneParser(&buf, "", "")
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		if hdr := rr.Header(); strings.EqualFold(hdr.Name, q.Name) {
+			rcode = dns.RcodeSuccess
+			if hdr.Rrtype == q.Qtype || hdr.Rrtype == dns.TypeCNAME {
+				answer = append(answer, rr)
+			}
+		}
+	}
+	if zp.Err() != nil {
+		return dns.RcodeServerFailure, nil
+	}
+	return
+}
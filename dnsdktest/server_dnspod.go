@@ -0,0 +1,329 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdktest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-the-way/dnsdk"
+)
+
+var dnspodErrorCodes = map[dnsdk.ErrorKind]string{
+	dnsdk.ErrNotFound:            "ResourceNotFound.NoDataOfRecord",
+	dnsdk.ErrAlreadyExists:       "InvalidParameter.DomainRecordExist",
+	dnsdk.ErrConflict:            "FailedOperation.DomainIsLocked",
+	dnsdk.ErrUnauthorized:        "AuthFailure.SignatureFailure",
+	dnsdk.ErrRateLimited:         "RequestLimitExceeded",
+	dnsdk.ErrInvalidInput:        "InvalidParameter",
+	dnsdk.ErrUnsupported:         "UnsupportedOperation",
+	dnsdk.ErrProviderUnavailable: "InternalError",
+}
+
+// dnspodLines DescribeRecordLineCategoryList 返回的线路分类, 搜索引擎线路在免费套餐中不可用
+var dnspodLines = []dnspodLine{
+	{Id: "0", Name: "默认"},
+	{Id: "7=0", Name: "境内", Sub: []dnspodLine{
+		{Id: "10=0", Name: "电信"},
+		{Id: "10=1", Name: "联通"},
+		{Id: "10=3", Name: "移动"},
+	}},
+	{Id: "3=0", Name: "境外"},
+	{Id: "80=0", Name: "搜索引擎", Unusable: true, Sub: []dnspodLine{
+		{Id: "80=1", Name: "百度", Unusable: true},
+	}},
+}
+
+type dnspodLine struct {
+	Id, Name string
+	Unusable bool
+	Sub      []dnspodLine
+}
+
+func (l dnspodLine) item() map[string]any {
+	sub := make([]map[string]any, 0, len(l.Sub))
+	for _, s := range l.Sub {
+		sub = append(sub, s.item())
+	}
+	return map[string]any{"LineId": l.Id, "LineName": l.Name, "Useful": !l.Unusable, "Grade": "DP_FREE", "SubGroup": sub}
+}
+
+// NewDnspodServer 模拟 DNSPod API 3.0 接口, 配合 DnspodSupportOpts.Endpoint(srv.URL) 使用
+func NewDnspodServer(opts *dnsdk.MemorySupportOpts) *Server {
+	return newServer(opts, func(store dnsdk.Api) http.Handler { return &dnspodHandler{store} })
+}
+
+type (
+	dnspodHandler struct{ store dnsdk.Api }
+
+	dnspodReq struct {
+		Domain       string
+		DomainId     uint64
+		Keyword      string
+		Offset       uint
+		Limit        uint
+		RecordId     uint64
+		Subdomain    string
+		SubDomain    string
+		RecordType   string
+		RecordLineId string
+		Value        string
+		TTL          uint
+		MX           uint16
+		Weight       uint
+		Remark       *string
+		Status       string
+		SortField    string
+		SortType     string
+	}
+)
+
+func (h *dnspodHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req dnspodReq
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&req)
+	}
+	var (
+		body map[string]any
+		err  error
+	)
+	switch action := r.Header.Get("X-TC-Action"); action {
+	default:
+		err = &dnsdk.Error{Kind: dnsdk.ErrUnsupported, Err: fmt.Errorf("unknown action %s", action)}
+	case "DescribeRecordLineCategoryList":
+		body, err = h.describeRecordLineCategoryList(r, req)
+	case "DescribeDomainList":
+		body, err = h.describeDomainList(r, req)
+	case "CreateDomain":
+		body, err = h.createDomain(r, req)
+	case "DeleteDomain":
+		err = h.store.DomainDeleteContext(r.Context(), dnsdk.DomainDeleteReq{Domain: req.Domain})
+	case "DescribeDomain":
+		body, err = h.describeDomain(r, req)
+	case "ModifyDomainStatus":
+		body, err = h.modifyDomainStatus(r, req)
+	case "DescribeRecordList":
+		body, err = h.describeRecordList(r, req)
+	case "CreateRecord":
+		body, err = h.createRecord(r, req)
+	case "ModifyRecord":
+		body, err = h.modifyRecord(r, req)
+	case "DeleteRecord":
+		err = h.store.RecordDeleteContext(r.Context(), dnsdk.RecordDeleteReq{DomainId: idOf(req.DomainId), RecordId: idOf(req.RecordId)})
+	case "ModifyRecordStatus":
+		body, err = h.modifyRecordStatus(r, req)
+	}
+	if err != nil {
+		body = map[string]any{"Error": map[string]any{"Code": dnspodErrorCodes[dnsdk.KindOf(err)], "Message": err.Error()}}
+	}
+	if body == nil {
+		body = map[string]any{}
+	}
+	body["RequestId"] = "dnsdktest"
+	writeJSON(w, http.StatusOK, map[string]any{"Response": body})
+}
+
+func idOf(i uint64) string {
+	if i == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d", i)
+}
+
+func (h *dnspodHandler) describeRecordLineCategoryList(r *http.Request, req dnspodReq) (body map[string]any, err error) {
+	if req.Domain == "" {
+		return nil, &dnsdk.Error{Kind: dnsdk.ErrInvalidInput, Err: errors.New("missing parameter Domain")}
+	}
+	if _, err = h.store.LineListContext(r.Context(), dnsdk.LineListReq{DomainId: idOf(req.DomainId), Domain: req.Domain}); err != nil {
+		return
+	}
+	lines := make([]map[string]any, 0, len(dnspodLines))
+	for _, l := range dnspodLines {
+		lines = append(lines, l.item())
+	}
+	return map[string]any{"LineList": lines}, nil
+}
+
+func (h *dnspodHandler) describeDomainList(r *http.Request, req dnspodReq) (body map[string]any, err error) {
+	resp, err := h.store.DomainListContext(r.Context(), dnsdk.DomainListReq{
+		Page:   page(req.Offset, req.Limit),
+		Limit:  req.Limit,
+		Domain: req.Keyword,
+	})
+	if err != nil {
+		return
+	}
+	domains := make([]map[string]any, 0, len(resp.List))
+	for _, d := range resp.List {
+		domains = append(domains, map[string]any{
+			"DomainId":     toUint64(d.Id),
+			"Name":         d.Name,
+			"EffectiveDNS": d.DnsServer,
+			"RecordCount":  d.RecordCount,
+			"Remark":       d.Remark,
+			"CreatedOn":    d.CreateTime,
+		})
+	}
+	return map[string]any{
+		"DomainCountInfo": map[string]any{"AllTotal": resp.Total, "DomainTotal": resp.Total},
+		"DomainList":      domains,
+	}, nil
+}
+
+func (h *dnspodHandler) describeDomain(r *http.Request, req dnspodReq) (body map[string]any, err error) {
+	resp, err := h.store.DomainGetContext(r.Context(), dnsdk.DomainGetReq{Domain: req.Domain, DomainId: idOf(req.DomainId)})
+	if err != nil {
+		return
+	}
+	status := "ENABLE"
+	if resp.Status == "disable" {
+		status = "PAUSE"
+	}
+	return map[string]any{"DomainInfo": map[string]any{
+		"DomainId":     toUint64(resp.Id),
+		"Domain":       resp.Name,
+		"Status":       status,
+		"Grade":        "DP_Free",
+		"DnspodNsList": resp.DnsServer,
+		"RecordCount":  resp.RecordCount,
+		"Remark":       resp.Remark,
+		"CreatedOn":    resp.CreateTime,
+	}}, nil
+}
+
+// modifyDomainStatus DomainId 优先于 Domain
+func (h *dnspodHandler) modifyDomainStatus(r *http.Request, req dnspodReq) (body map[string]any, err error) {
+	domain := req.Domain
+	if req.DomainId != 0 {
+		domain = ""
+	}
+	switch strings.ToLower(req.Status) {
+	case "enable":
+		err = h.store.DomainEnableContext(r.Context(), dnsdk.DomainEnableReq{Domain: domain, DomainId: idOf(req.DomainId)})
+	case "disable":
+		err = h.store.DomainDisableContext(r.Context(), dnsdk.DomainDisableReq{Domain: domain, DomainId: idOf(req.DomainId)})
+	default:
+		err = &dnsdk.Error{Kind: dnsdk.ErrInvalidInput, Err: fmt.Errorf("invalid status %q", req.Status)}
+	}
+	return
+}
+
+func (h *dnspodHandler) createDomain(r *http.Request, req dnspodReq) (body map[string]any, err error) {
+	resp, err := h.store.DomainAddContext(r.Context(), dnsdk.DomainAddReq{Domain: req.Domain})
+	if err != nil {
+		return
+	}
+	return map[string]any{"DomainInfo": map[string]any{
+		"Id":          toUint64(resp.Id),
+		"Domain":      req.Domain,
+		"GradeNsList": resp.DnsServer,
+	}}, nil
+}
+
+func (h *dnspodHandler) describeRecordList(r *http.Request, req dnspodReq) (body map[string]any, err error) {
+	resp, err := h.store.RecordListContext(r.Context(), dnsdk.RecordListReq{
+		Page:      page(req.Offset, req.Limit),
+		Limit:     req.Limit,
+		Domain:    req.Domain,
+		DomainId:  idOf(req.DomainId),
+		Record:    req.Subdomain,
+		Type:      req.RecordType,
+		Line:      req.RecordLineId,
+		Value:     req.Keyword,
+		Order:     req.SortField,
+		Direction: req.SortType,
+	})
+	if err != nil {
+		return
+	}
+	if len(resp.List) == 0 {
+		return nil, &dnsdk.Error{Kind: dnsdk.ErrNotFound, Err: fmt.Errorf("记录列表为空")}
+	}
+	records := make([]map[string]any, 0, len(resp.List))
+	for _, rc := range resp.List {
+		records = append(records, map[string]any{
+			"RecordId":  toUint64(rc.Id),
+			"Name":      rc.Record,
+			"Type":      rc.Type,
+			"Value":     rc.Value,
+			"LineId":    rc.Line,
+			"TTL":       rc.TTL,
+			"MX":        rc.MX,
+			"Weight":    rc.Weight,
+			"Remark":    rc.Remark,
+			"Status":    strings.ToUpper(rc.Status),
+			"UpdatedOn": rc.UpdateTime,
+		})
+	}
+	return map[string]any{
+		"RecordCountInfo": map[string]any{"TotalCount": resp.Total, "ListCount": len(records)},
+		"RecordList":      records,
+	}, nil
+}
+
+func (h *dnspodHandler) createRecord(r *http.Request, req dnspodReq) (body map[string]any, err error) {
+	var remark string
+	if req.Remark != nil {
+		remark = *req.Remark
+	}
+	resp, err := h.store.RecordAddContext(r.Context(), dnsdk.RecordAddReq{
+		Domain:   req.Domain,
+		DomainId: idOf(req.DomainId),
+		Record:   req.SubDomain,
+		Type:     req.RecordType,
+		Value:    req.Value,
+		Line:     req.RecordLineId,
+		TTL:      req.TTL,
+		MX:       req.MX,
+		Weight:   req.Weight,
+		Remark:   remark,
+	})
+	if err != nil {
+		return
+	}
+	return map[string]any{"RecordId": toUint64(resp.Id)}, nil
+}
+
+func (h *dnspodHandler) modifyRecord(r *http.Request, req dnspodReq) (body map[string]any, err error) {
+	resp, err := h.store.RecordUpdateContext(r.Context(), dnsdk.RecordUpdateReq{
+		Domain:   req.Domain,
+		DomainId: idOf(req.DomainId),
+		RecordId: idOf(req.RecordId),
+		Record:   req.SubDomain,
+		Type:     req.RecordType,
+		Value:    req.Value,
+		Line:     req.RecordLineId,
+		TTL:      req.TTL,
+		MX:       req.MX,
+		Weight:   req.Weight,
+		Remark:   req.Remark,
+	})
+	if err != nil {
+		return
+	}
+	return map[string]any{"RecordId": toUint64(resp.Id)}, nil
+}
+
+func (h *dnspodHandler) modifyRecordStatus(r *http.Request, req dnspodReq) (body map[string]any, err error) {
+	if strings.EqualFold(req.Status, "ENABLE") {
+		err = h.store.RecordEnableContext(r.Context(), dnsdk.RecordEnableReq{DomainId: idOf(req.DomainId), RecordId: idOf(req.RecordId)})
+	} else {
+		err = h.store.RecordDisableContext(r.Context(), dnsdk.RecordDisableReq{DomainId: idOf(req.DomainId), RecordId: idOf(req.RecordId)})
+	}
+	if err != nil {
+		return
+	}
+	return map[string]any{"RecordId": req.RecordId}, nil
+}
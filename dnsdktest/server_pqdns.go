@@ -0,0 +1,244 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-the-way/dnsdk"
+)
+
+// NewPqdnsServer 模拟 PQDNS 接口, 配合 NewPqdnsSupportOpts(srv.URL, ...) 使用
+func NewPqdnsServer(opts *dnsdk.MemorySupportOpts) *Server {
+	return newServer(opts, func(store dnsdk.Api) http.Handler {
+		h := &pqdnsHandler{store}
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /api/ext/dns/domain", h.handle(h.listDomains))
+		mux.HandleFunc("POST /api/ext/dns/domain", h.handle(h.addDomain))
+		mux.HandleFunc("DELETE /api/ext/dns/domain", h.handle(h.deleteDomain))
+		mux.HandleFunc("GET /api/ext/dns/record", h.handle(h.listRecords))
+		mux.HandleFunc("POST /api/ext/dns/record", h.handle(h.addRecord))
+		mux.HandleFunc("PUT /api/ext/dns/record", h.handle(h.updateRecord))
+		mux.HandleFunc("DELETE /api/ext/dns/record", h.handle(h.deleteRecord))
+		return mux
+	})
+}
+
+type (
+	pqdnsHandler struct{ store dnsdk.Api }
+
+	pqdnsReq struct {
+		Domain    string `json:"domain"`
+		Ids       []uint `json:"ids"`
+		DomainId  uint   `json:"domain_id"`
+		RecordId  uint   `json:"record_id"`
+		Host      string `json:"host"`
+		RecType   string `json:"rec_type"`
+		RecValue  string `json:"rec_value"`
+		LineId    uint   `json:"line_id"`
+		MX        uint16 `json:"mx"`
+		Weight    uint   `json:"weight"`
+		TTL       uint   `json:"ttl"`
+		RecordIds []uint `json:"-"`
+	}
+)
+
+func (h *pqdnsHandler) handle(fn func(r *http.Request, req pqdnsReq) (body any, err error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req pqdnsReq
+		if r.Method != http.MethodGet {
+			var raw map[string]json.RawMessage
+			buf, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(buf, &raw); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			// 删除记录时 record_id 为数组
+			if ids, ok := raw["record_id"]; ok && strings.HasPrefix(string(ids), "[") {
+				delete(raw, "record_id")
+				_ = json.Unmarshal(ids, &req.RecordIds)
+			}
+			buf, _ = json.Marshal(raw)
+			_ = json.Unmarshal(buf, &req)
+		}
+		body, err := fn(r, req)
+		if err != nil {
+			http.Error(w, err.Error(), statusOf(err))
+			return
+		}
+		writeJSON(w, http.StatusOK, body)
+	}
+}
+
+func lineOf(lineId uint) string {
+	if lineId == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d", lineId)
+}
+
+func (h *pqdnsHandler) listDomains(r *http.Request, _ pqdnsReq) (body any, err error) {
+	q := r.URL.Query()
+	resp, err := h.store.DomainListContext(r.Context(), dnsdk.DomainListReq{
+		Page:   toUint(q.Get("page")),
+		Limit:  toUint(q.Get("limit")),
+		Domain: q.Get("domain"),
+	})
+	if err != nil {
+		return
+	}
+	list := make([]map[string]any, 0, len(resp.List))
+	for _, d := range resp.List {
+		list = append(list, map[string]any{
+			"id":           d.Id,
+			"name":         d.Name,
+			"tip_ns_value": d.DnsServer,
+			"record_count": d.RecordCount,
+			"remark":       d.Remark,
+			"create_time":  d.CreateTime,
+		})
+	}
+	return map[string]any{"total": resp.Total, "list": list}, nil
+}
+
+func (h *pqdnsHandler) addDomain(r *http.Request, req pqdnsReq) (body any, err error) {
+	resp, err := h.store.DomainAddContext(r.Context(), dnsdk.DomainAddReq{Domain: req.Domain})
+	if err != nil {
+		return
+	}
+	return map[string]any{"id": resp.Id}, nil
+}
+
+func (h *pqdnsHandler) deleteDomain(r *http.Request, req pqdnsReq) (body any, err error) {
+	for _, id := range req.Ids {
+		if err = h.store.DomainDeleteContext(r.Context(), dnsdk.DomainDeleteReq{DomainId: fmt.Sprintf("%d", id)}); err != nil {
+			return
+		}
+	}
+	return map[string]any{}, nil
+}
+
+func (h *pqdnsHandler) records(domainId, domain string, resp dnsdk.RecordListResp) map[string]any {
+	list := make([]map[string]any, 0, len(resp.List))
+	for _, rc := range resp.List {
+		var status byte
+		if rc.Status == "disable" {
+			status = 1
+		}
+		list = append(list, map[string]any{
+			"id":           toUint64(rc.Id),
+			"domain_id":    toUint64(domainId),
+			"host_record":  rc.Record,
+			"record_type":  rc.Type,
+			"record_value": rc.Value,
+			"line_id":      toUint64(rc.Line),
+			"weight":       rc.Weight,
+			"mx":           rc.MX,
+			"ttl":          rc.TTL,
+			"status":       status,
+			"create_time":  parseTime(rc.CreateTime),
+			"update_time":  parseTime(rc.UpdateTime),
+			"domain_name":  domain,
+		})
+	}
+	return map[string]any{"total": resp.Total, "list": list}
+}
+
+// domain 按 Id 查找域名
+func (h *pqdnsHandler) domain(r *http.Request, domainId string) (domain string, err error) {
+	resp, err := h.store.DomainListContext(r.Context(), dnsdk.DomainListReq{})
+	if err != nil {
+		return
+	}
+	for _, d := range resp.List {
+		if d.Id == domainId {
+			return d.Name, nil
+		}
+	}
+	return "", &dnsdk.Error{Kind: dnsdk.ErrNotFound, Err: fmt.Errorf("domain %s not found", domainId)}
+}
+
+func (h *pqdnsHandler) listRecords(r *http.Request, _ pqdnsReq) (body any, err error) {
+	q := r.URL.Query()
+	domain, err := h.domain(r, q.Get("domain_id"))
+	if err != nil {
+		return
+	}
+	resp, err := h.store.RecordListContext(r.Context(), dnsdk.RecordListReq{
+		Page:     toUint(q.Get("page")),
+		Limit:    toUint(q.Get("limit")),
+		DomainId: q.Get("domain_id"),
+		Record:   q.Get("host_record"),
+		Value:    q.Get("record_value"),
+		Line:     lineOf(toUint(q.Get("line_id"))),
+	})
+	if err != nil {
+		return
+	}
+	return h.records(q.Get("domain_id"), domain, resp), nil
+}
+
+func (h *pqdnsHandler) addRecord(r *http.Request, req pqdnsReq) (body any, err error) {
+	domainId := fmt.Sprintf("%d", req.DomainId)
+	domain, err := h.domain(r, domainId)
+	if err != nil {
+		return
+	}
+	resp, err := h.store.RecordAddContext(r.Context(), dnsdk.RecordAddReq{
+		DomainId: domainId,
+		Record:   req.Host,
+		Type:     req.RecType,
+		Value:    req.RecValue,
+		Line:     lineOf(req.LineId),
+		TTL:      req.TTL,
+		MX:       req.MX,
+		Weight:   req.Weight,
+	})
+	if err != nil {
+		return
+	}
+	return h.records(domainId, domain, dnsdk.RecordListResp{Total: 1, List: []dnsdk.RecordListRespRecord{resp.RecordListRespRecord}}), nil
+}
+
+func (h *pqdnsHandler) updateRecord(r *http.Request, req pqdnsReq) (body any, err error) {
+	resp, err := h.store.RecordUpdateContext(r.Context(), dnsdk.RecordUpdateReq{
+		RecordId: fmt.Sprintf("%d", req.RecordId),
+		Record:   req.Host,
+		Type:     req.RecType,
+		Value:    req.RecValue,
+		Line:     lineOf(req.LineId),
+		TTL:      req.TTL,
+		MX:       req.MX,
+		Weight:   req.Weight,
+	})
+	if err != nil {
+		return
+	}
+	domain := resp.Name
+	if resp.Record != "@" {
+		domain = strings.TrimPrefix(domain, resp.Record+".")
+	}
+	return h.records("", domain, dnsdk.RecordListResp{Total: 1, List: []dnsdk.RecordListRespRecord{resp.RecordListRespRecord}}), nil
+}
+
+func (h *pqdnsHandler) deleteRecord(r *http.Request, req pqdnsReq) (body any, err error) {
+	for _, id := range req.RecordIds {
+		if err = h.store.RecordDeleteContext(r.Context(), dnsdk.RecordDeleteReq{RecordId: fmt.Sprintf("%d", id)}); err != nil {
+			return
+		}
+	}
+	return map[string]any{"total": 0, "list": []any{}}, nil
+}
@@ -1,13 +1,17 @@
 module github.com/go-the-way/dnsdk
 
-go 1.18
+go 1.23
 
 require (
 	github.com/alibabacloud-go/alidns-20150109/v4 v4.5.0
 	github.com/alibabacloud-go/darabonba-openapi/v2 v2.0.7
 	github.com/alibabacloud-go/tea v1.2.2
+	github.com/alibabacloud-go/tea-utils/v2 v2.0.5
 	github.com/cloudflare/cloudflare-go v0.96.0
+	github.com/miekg/dns v1.1.59
+	github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common v1.0.936
 	github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/dnspod v1.0.936
+	golang.org/x/time v0.5.0
 )
 
 require (
@@ -16,7 +20,6 @@ require (
 	github.com/alibabacloud-go/endpoint-util v1.1.0 // indirect
 	github.com/alibabacloud-go/openapi-util v0.1.0 // indirect
 	github.com/alibabacloud-go/tea-utils v1.4.5 // indirect
-	github.com/alibabacloud-go/tea-utils/v2 v2.0.5 // indirect
 	github.com/alibabacloud-go/tea-xml v1.1.3 // indirect
 	github.com/aliyun/credentials-go v1.3.4 // indirect
 	github.com/clbanning/mxj/v2 v2.5.5 // indirect
@@ -27,10 +30,11 @@ require (
 	github.com/json-iterator/go v1.1.12 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
-	github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common v1.0.936 // indirect
 	github.com/tjfoc/gmsm v1.3.2 // indirect
+	golang.org/x/mod v0.16.0 // indirect
 	golang.org/x/net v0.25.0 // indirect
+	golang.org/x/sys v0.20.0 // indirect
 	golang.org/x/text v0.15.0 // indirect
-	golang.org/x/time v0.5.0 // indirect
+	golang.org/x/tools v0.19.0 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
 )
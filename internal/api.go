@@ -11,13 +11,21 @@
 
 package internal
 
+import "context"
+
+// Api 内嵌 ApiContext, 自定义实现需要同时提供 Context 方法 (不兼容变更),
+// 只实现 ApiContext 时可通过 BackgroundApi 补全非 Context 方法
 type Api interface {
+	ApiContext
 	Ping() (ok bool)                                                     // Ping
-	LineList() (resp LineListResp)                                       // 线路列表
+	LineList(req LineListReq) (resp LineListResp, err error)             // 线路列表
 	LineDefault() (resp LineListRespLine)                                // 线路默认
 	DomainList(req DomainListReq) (resp DomainListResp, err error)       // 域名列表
 	DomainAdd(req DomainAddReq) (resp DomainAddResp, err error)          // 域名添加
 	DomainDelete(req DomainDeleteReq) (err error)                        // 域名删除
+	DomainGet(req DomainGetReq) (resp DomainGetResp, err error)          // 域名详情
+	DomainEnable(req DomainEnableReq) (err error)                        // 域名启用
+	DomainDisable(req DomainDisableReq) (err error)                      // 域名暂停
 	RecordList(req RecordListReq) (resp RecordListResp, err error)       // 记录列表
 	RecordAdd(req RecordAddReq) (resp RecordAddResp, err error)          // 记录新增
 	RecordUpdate(req RecordUpdateReq) (resp RecordUpdateResp, err error) // 记录修改
@@ -25,3 +33,92 @@ type Api interface {
 	RecordEnable(req RecordEnableReq) (err error)                        // 记录启用
 	RecordDisable(req RecordDisableReq) (err error)                      // 记录暂停
 }
+
+// ApiContext ctx 结束时方法立即返回 ctx.Err(); Alidns SDK 无法中止已发出的请求,
+// 添加/修改/删除操作返回 ctx.Err() 后仍可能生效, 需要时重新查询确认
+type ApiContext interface {
+	Capabilities() (resp Capabilities)                                                               // 能力描述
+	PingContext(ctx context.Context) (ok bool)                                                       // Ping
+	LineListContext(ctx context.Context, req LineListReq) (resp LineListResp, err error)             // 线路列表
+	LineDefaultContext(ctx context.Context) (resp LineListRespLine)                                  // 线路默认
+	DomainListContext(ctx context.Context, req DomainListReq) (resp DomainListResp, err error)       // 域名列表
+	DomainAddContext(ctx context.Context, req DomainAddReq) (resp DomainAddResp, err error)          // 域名添加
+	DomainDeleteContext(ctx context.Context, req DomainDeleteReq) (err error)                        // 域名删除
+	DomainGetContext(ctx context.Context, req DomainGetReq) (resp DomainGetResp, err error)          // 域名详情
+	DomainEnableContext(ctx context.Context, req DomainEnableReq) (err error)                        // 域名启用
+	DomainDisableContext(ctx context.Context, req DomainDisableReq) (err error)                      // 域名暂停
+	RecordListContext(ctx context.Context, req RecordListReq) (resp RecordListResp, err error)       // 记录列表
+	RecordAddContext(ctx context.Context, req RecordAddReq) (resp RecordAddResp, err error)          // 记录新增
+	RecordUpdateContext(ctx context.Context, req RecordUpdateReq) (resp RecordUpdateResp, err error) // 记录修改
+	RecordDeleteContext(ctx context.Context, req RecordDeleteReq) (err error)                        // 记录删除
+	RecordEnableContext(ctx context.Context, req RecordEnableReq) (err error)                        // 记录启用
+	RecordDisableContext(ctx context.Context, req RecordDisableReq) (err error)                      // 记录暂停
+}
+
+// BackgroundApi 以 context.Background() 补全 ApiContext 的非 Context 方法
+func BackgroundApi(a ApiContext) Api {
+	if api, ok := a.(Api); ok {
+		return api
+	}
+	return &backgroundApi{a}
+}
+
+type backgroundApi struct{ ApiContext }
+
+func (a *backgroundApi) Ping() (ok bool) { return a.PingContext(context.Background()) }
+
+func (a *backgroundApi) LineList(req LineListReq) (resp LineListResp, err error) {
+	return a.LineListContext(context.Background(), req)
+}
+
+func (a *backgroundApi) LineDefault() (resp LineListRespLine) {
+	return a.LineDefaultContext(context.Background())
+}
+
+func (a *backgroundApi) DomainList(req DomainListReq) (resp DomainListResp, err error) {
+	return a.DomainListContext(context.Background(), req)
+}
+
+func (a *backgroundApi) DomainAdd(req DomainAddReq) (resp DomainAddResp, err error) {
+	return a.DomainAddContext(context.Background(), req)
+}
+
+func (a *backgroundApi) DomainDelete(req DomainDeleteReq) (err error) {
+	return a.DomainDeleteContext(context.Background(), req)
+}
+
+func (a *backgroundApi) DomainGet(req DomainGetReq) (resp DomainGetResp, err error) {
+	return a.DomainGetContext(context.Background(), req)
+}
+
+func (a *backgroundApi) DomainEnable(req DomainEnableReq) (err error) {
+	return a.DomainEnableContext(context.Background(), req)
+}
+
+func (a *backgroundApi) DomainDisable(req DomainDisableReq) (err error) {
+	return a.DomainDisableContext(context.Background(), req)
+}
+
+func (a *backgroundApi) RecordList(req RecordListReq) (resp RecordListResp, err error) {
+	return a.RecordListContext(context.Background(), req)
+}
+
+func (a *backgroundApi) RecordAdd(req RecordAddReq) (resp RecordAddResp, err error) {
+	return a.RecordAddContext(context.Background(), req)
+}
+
+func (a *backgroundApi) RecordUpdate(req RecordUpdateReq) (resp RecordUpdateResp, err error) {
+	return a.RecordUpdateContext(context.Background(), req)
+}
+
+func (a *backgroundApi) RecordDelete(req RecordDeleteReq) (err error) {
+	return a.RecordDeleteContext(context.Background(), req)
+}
+
+func (a *backgroundApi) RecordEnable(req RecordEnableReq) (err error) {
+	return a.RecordEnableContext(context.Background(), req)
+}
+
+func (a *backgroundApi) RecordDisable(req RecordDisableReq) (err error) {
+	return a.RecordDisableContext(context.Background(), req)
+}
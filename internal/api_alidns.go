@@ -12,116 +12,306 @@
 package internal
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
 	"strings"
 	"time"
 
-	alidns "github.com/alibabacloud-go/alidns-20150109/v4/client"
 	"github.com/alibabacloud-go/tea/tea"
+
+	alidns "github.com/alibabacloud-go/alidns-20150109/v4/client"
+	util "github.com/alibabacloud-go/tea-utils/v2/service"
 )
 
-var alidnsLineDef = LineListRespLine{"default", "默认"}
+const alidnsProvider = "alidns"
 
-func AlidnsApi(client *alidns.Client) Api { return &alidnsApi{client} }
+var alidnsLineDef = LineListRespLine{Id: "default", Name: "默认"}
 
-type alidnsApi struct{ *alidns.Client }
+// alidnsDomainPageLimit DescribeDomains 每页最多100条
+const alidnsDomainPageLimit = 100
 
-func (a *alidnsApi) endpoint() string {
-	return fmt.Sprintf("https://%s", tea.StringValue(a.Endpoint))
+var alidnsLineMap = lineMap{
+	{LineDefault, "default"},
+	{LineTelecom, "telecom"},
+	{LineUnicom, "unicom"},
+	{LineMobile, "mobile"},
+	{LineEducation, "edu"},
+	{LineOverseas, "oversea"},
 }
 
-func (a *alidnsApi) Ping() (ok bool) {
-	req, _ := http.NewRequest(http.MethodGet, a.endpoint(), nil)
-	resp, _ := (&http.Client{Timeout: time.Second * 5}).Do(req)
-	return resp.StatusCode == http.StatusOK
+// alidnsCapabilities 不支持 Weight 与 Remark: AddDomainRecord/UpdateDomainRecord 没有这两个参数
+// (需单独调用 UpdateDNSSLBWeight、UpdateDomainRecordRemark), RecordAdd/RecordUpdate 会忽略它们,
+// RecordList 仍返回控制台设置的权重与备注
+var alidnsCapabilities = Capabilities{
+	Operations:      operationsExcept(OpDomainEnable, OpDomainDisable),
+	RecordTypes:     []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS", "SRV", "CAA", "REDIRECT_URL", "FORWARD_URL"},
+	TTLMin:          1,
+	TTLMax:          86400,
+	Line:            true,
+	MXPriority:      true,
+	DomainPageLimit: alidnsDomainPageLimit,
+	RecordPageLimit: 500,
+	QPS:             20,
 }
 
-func (a *alidnsApi) LineList() (resp LineListResp) {
-	lines := []LineListRespLine{
-		alidnsLineDef,
-		{"telecom", "电信"},
-		{"unicom", "联通"},
-		{"mobile", "移动"},
-		{"oversea", "境外"},
+var (
+	alidnsErrorCodes = map[string]ErrorKind{
+		"InvalidDomainName.NoExist":   ErrNotFound,
+		"InvalidDomainName.Duplicate": ErrAlreadyExists,
+		"DomainRecordNotBelongToUser": ErrNotFound,
+		"IncorrectDomainUser":         ErrNotFound,
+		"DomainRecordDuplicate":       ErrAlreadyExists,
+		"DomainRecordConflict":        ErrConflict,
+		"DomainRecordLocked":          ErrConflict,
+		"DomainAddedByOther":          ErrConflict,
+		"SignatureDoesNotMatch":       ErrUnauthorized,
+		"ServiceUnavailable":          ErrProviderUnavailable,
+		"InternalError":               ErrProviderUnavailable,
+	}
+	alidnsErrorPrefixes = []codePrefix{
+		{"Throttling", ErrRateLimited},
+		{"InvalidAccessKeyId", ErrUnauthorized},
+		{"Forbidden", ErrUnauthorized},
+		{"Invalid", ErrInvalidInput},
+		{"Missing", ErrInvalidInput},
 	}
-	return LineListResp{lines}
+)
+
+func AlidnsApi(client *alidns.Client) Api { return BackgroundApi(&alidnsApi{Client: client}) }
+
+type alidnsApi struct {
+	*alidns.Client
+	lines lineCache
+}
+
+func (a *alidnsApi) endpoint() string {
+	protocol := "https"
+	if a.Protocol != nil {
+		protocol = strings.ToLower(tea.StringValue(a.Protocol))
+	}
+	return fmt.Sprintf("%s://%s", protocol, tea.StringValue(a.Endpoint))
+}
+
+func (a *alidnsApi) runtime(ctx context.Context) *util.RuntimeOptions {
+	runtime := &util.RuntimeOptions{}
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout := int(time.Until(deadline).Milliseconds())
+		if timeout < 1 {
+			timeout = 1
+		}
+		runtime.SetConnectTimeout(timeout).SetReadTimeout(timeout)
+	}
+	return runtime
+}
+
+func (a *alidnsApi) Capabilities() (resp Capabilities) { return alidnsCapabilities }
+
+func (a *alidnsApi) PingContext(ctx context.Context) (ok bool) { return ping(ctx, a.endpoint()) }
+
+func (a *alidnsApi) LineListContext(ctx context.Context, req LineListReq) (resp LineListResp, err error) {
+	return a.lines.get(ctx, req, func(ctx context.Context) (lines []LineListRespLine, err error) {
+		req0 := &alidns.DescribeSupportLinesRequest{}
+		if req.Domain != "" {
+			req0.DomainName = tea.String(req.Domain)
+		}
+		return transformLinesFromAlidns(withContext(ctx, func() (*alidns.DescribeSupportLinesResponse, error) {
+			return a.DescribeSupportLinesWithOptions(req0, a.runtime(ctx))
+		}))
+	})
 }
 
-func (a *alidnsApi) LineDefault() (resp LineListRespLine) { return alidnsLineDef }
+func (a *alidnsApi) LineDefaultContext(_ context.Context) (resp LineListRespLine) {
+	return alidnsLineDef
+}
 
-func (a *alidnsApi) DomainList(req DomainListReq) (resp DomainListResp, err error) {
-	return resp.transformFromAlidns(a.DescribeDomains(&alidns.DescribeDomainsRequest{
+func (a *alidnsApi) DomainListContext(ctx context.Context, req DomainListReq) (resp DomainListResp, err error) {
+	req0 := &alidns.DescribeDomainsRequest{
 		KeyWord:    tea.String(req.Domain),
 		PageNumber: tea.Int64(int64(req.Page)),
 		PageSize:   tea.Int64(int64(req.Limit)),
 		SearchMode: tea.String("EXACT"),
 		Starmark:   tea.Bool(false),
+	}
+	return resp.transformFromAlidns(withContext(ctx, func() (*alidns.DescribeDomainsResponse, error) {
+		return a.DescribeDomainsWithOptions(req0, a.runtime(ctx))
 	}))
 }
 
-func (a *alidnsApi) DomainAdd(req DomainAddReq) (resp DomainAddResp, err error) {
-	return resp.transformFromAlidns(a.AddDomain(&alidns.AddDomainRequest{DomainName: tea.String(req.Domain)}))
+func (a *alidnsApi) DomainAddContext(ctx context.Context, req DomainAddReq) (resp DomainAddResp, err error) {
+	req0 := &alidns.AddDomainRequest{DomainName: tea.String(req.Domain)}
+	return resp.transformFromAlidns(withContext(ctx, func() (*alidns.AddDomainResponse, error) {
+		return a.AddDomainWithOptions(req0, a.runtime(ctx))
+	}))
 }
 
-func (a *alidnsApi) DomainDelete(req DomainDeleteReq) (err error) {
-	_, err = a.DeleteDomain(&alidns.DeleteDomainRequest{DomainName: tea.String(req.Domain)})
+func (a *alidnsApi) DomainDeleteContext(ctx context.Context, req DomainDeleteReq) (err error) {
+	req0 := &alidns.DeleteDomainRequest{DomainName: tea.String(req.Domain)}
+	_, err = withContext(ctx, func() (*alidns.DeleteDomainResponse, error) {
+		return a.DeleteDomainWithOptions(req0, a.runtime(ctx))
+	})
+	if err = alidnsError(err); err == nil {
+		a.lines.forget(req.DomainId, req.Domain)
+	}
 	return
 }
 
-func (a *alidnsApi) RecordList(req RecordListReq) (resp RecordListResp, err error) {
-	return resp.transformFromAlidns(a.DescribeDomainRecords(&alidns.DescribeDomainRecordsRequest{
+// DomainGetContext 按域名精确查找, 只有域名Id时逐页查找; 域名总是启用状态, DNSSEC 状态获取失败时为空
+func (a *alidnsApi) DomainGetContext(ctx context.Context, req DomainGetReq) (resp DomainGetResp, err error) {
+	for page := int64(1); ; page++ {
+		req0 := &alidns.DescribeDomainsRequest{PageNumber: tea.Int64(page), PageSize: tea.Int64(alidnsDomainPageLimit)}
+		if req.Domain != "" {
+			req0.KeyWord, req0.SearchMode = tea.String(req.Domain), tea.String("EXACT")
+		}
+		domains, err0 := withContext(ctx, func() (*alidns.DescribeDomainsResponse, error) {
+			return a.DescribeDomainsWithOptions(req0, a.runtime(ctx))
+		})
+		if resp, err = resp.transformFromAlidns(req, domains, err0); err == nil {
+			break
+		}
+		if KindOf(err) != ErrNotFound || domains == nil || domains.Body == nil || tea.Int64Value(domains.Body.TotalCount) <= page*alidnsDomainPageLimit {
+			return
+		}
+	}
+	req1 := &alidns.DescribeDomainDnssecInfoRequest{DomainName: tea.String(resp.Name)}
+	dnssec, err0 := withContext(ctx, func() (*alidns.DescribeDomainDnssecInfoResponse, error) {
+		return a.DescribeDomainDnssecInfoWithOptions(req1, a.runtime(ctx))
+	})
+	if err0 == nil && dnssec.Body != nil {
+		resp.DNSSEC = "disable"
+		if strings.EqualFold(tea.StringValue(dnssec.Body.Status), "ON") {
+			resp.DNSSEC = "enable"
+		}
+	}
+	return
+}
+
+func (a *alidnsApi) DomainEnableContext(_ context.Context, _ DomainEnableReq) (err error) {
+	return ErrNotSupportedOperation
+}
+
+func (a *alidnsApi) DomainDisableContext(_ context.Context, _ DomainDisableReq) (err error) {
+	return ErrNotSupportedOperation
+}
+
+func (a *alidnsApi) RecordListContext(ctx context.Context, req RecordListReq) (resp RecordListResp, err error) {
+	if req.Line, err = alidnsLineMap.native(req.Line, lineCatalog(ctx, a, req.DomainId, req.Domain)); err != nil {
+		return
+	}
+	req0 := &alidns.DescribeDomainRecordsRequest{
 		Direction:    tea.String(strings.ToUpper(req.Direction)),
 		DomainName:   tea.String(req.Domain),
 		Line:         tea.String(req.Line),
 		OrderBy:      tea.String(req.Order),
 		PageNumber:   tea.Int64(int64(req.Page)),
 		PageSize:     tea.Int64(int64(req.Limit)),
+		RRKeyWord:    tea.String(req.Record),
 		Type:         tea.String(req.Type),
 		ValueKeyWord: tea.String(req.Value),
-	}))
+	}
+	if resp, err = resp.transformFromAlidns(withContext(ctx, func() (*alidns.DescribeDomainRecordsResponse, error) {
+		return a.DescribeDomainRecordsWithOptions(req0, a.runtime(ctx))
+	})); err != nil {
+		return
+	}
+	alidnsLineMap.canonicalize(resp.List, lineCatalog(ctx, a, req.DomainId, req.Domain))
+	return
 }
 
-func (a *alidnsApi) RecordAdd(req RecordAddReq) (resp RecordAddResp, err error) {
-	return resp.transformFromAlidns(a.AddDomainRecord(&alidns.AddDomainRecordRequest{
+func (a *alidnsApi) RecordAddContext(ctx context.Context, req RecordAddReq) (resp RecordAddResp, err error) {
+	if req.Line, err = alidnsLineMap.native(req.Line, lineCatalog(ctx, a, req.DomainId, req.Domain)); err != nil {
+		return
+	}
+	req.Type, req.Value = recordValue(req.Type, req.Value, req.Data)
+	req0 := &alidns.AddDomainRecordRequest{
 		DomainName: tea.String(req.Domain),
 		Line:       tea.String(req.Line),
-		Priority:   tea.Int64(1),
+		Priority:   tea.Int64(int64(mxPriority(req.MX))),
 		RR:         tea.String(req.Record),
 		TTL:        tea.Int64(int64(req.TTL)),
 		Type:       tea.String(req.Type),
 		Value:      tea.String(req.Value),
+	}
+	return resp.transformFromAlidns(withContext(ctx, func() (*alidns.AddDomainRecordResponse, error) {
+		return a.AddDomainRecordWithOptions(req0, a.runtime(ctx))
 	}))
 }
 
-func (a *alidnsApi) RecordUpdate(req RecordUpdateReq) (resp RecordUpdateResp, err error) {
-	return resp.transformFromAlidns(a.UpdateDomainRecord(&alidns.UpdateDomainRecordRequest{
+func (a *alidnsApi) RecordUpdateContext(ctx context.Context, req RecordUpdateReq) (resp RecordUpdateResp, err error) {
+	if req.Line, err = alidnsLineMap.native(req.Line, lineCatalog(ctx, a, req.DomainId, req.Domain)); err != nil {
+		return
+	}
+	req.Type, req.Value = recordValue(req.Type, req.Value, req.Data)
+	req0 := &alidns.UpdateDomainRecordRequest{
 		Line:     tea.String(req.Line),
-		Priority: tea.Int64(1),
+		Priority: tea.Int64(int64(mxPriority(req.MX))),
 		RR:       tea.String(req.Record),
 		RecordId: tea.String(req.RecordId),
 		TTL:      tea.Int64(int64(req.TTL)),
 		Type:     tea.String(req.Type),
 		Value:    tea.String(req.Value),
+	}
+	return resp.transformFromAlidns(withContext(ctx, func() (*alidns.UpdateDomainRecordResponse, error) {
+		return a.UpdateDomainRecordWithOptions(req0, a.runtime(ctx))
 	}))
 }
 
-func (a *alidnsApi) RecordDelete(req RecordDeleteReq) (err error) {
-	_, err = a.DeleteDomainRecord(&alidns.DeleteDomainRecordRequest{RecordId: tea.String(req.RecordId)})
-	return
+func (a *alidnsApi) RecordDeleteContext(ctx context.Context, req RecordDeleteReq) (err error) {
+	req0 := &alidns.DeleteDomainRecordRequest{RecordId: tea.String(req.RecordId)}
+	_, err = withContext(ctx, func() (*alidns.DeleteDomainRecordResponse, error) {
+		return a.DeleteDomainRecordWithOptions(req0, a.runtime(ctx))
+	})
+	return alidnsError(err)
 }
 
-func (a *alidnsApi) recordStatus(recordId string, status string) (err error) {
-	_, err = a.SetDomainRecordStatus(&alidns.SetDomainRecordStatusRequest{RecordId: tea.String(recordId), Status: tea.String(status)})
-	return
+func (a *alidnsApi) recordStatus(ctx context.Context, recordId string, status string) (err error) {
+	req0 := &alidns.SetDomainRecordStatusRequest{RecordId: tea.String(recordId), Status: tea.String(status)}
+	_, err = withContext(ctx, func() (*alidns.SetDomainRecordStatusResponse, error) {
+		return a.SetDomainRecordStatusWithOptions(req0, a.runtime(ctx))
+	})
+	return alidnsError(err)
 }
 
-func (a *alidnsApi) RecordEnable(req RecordEnableReq) (err error) {
-	return a.recordStatus(req.RecordId, "Enable")
+func (a *alidnsApi) RecordEnableContext(ctx context.Context, req RecordEnableReq) (err error) {
+	return a.recordStatus(ctx, req.RecordId, "Enable")
 }
 
-func (a *alidnsApi) RecordDisable(req RecordDisableReq) (err error) {
-	return a.recordStatus(req.RecordId, "Disable")
+func (a *alidnsApi) RecordDisableContext(ctx context.Context, req RecordDisableReq) (err error) {
+	return a.recordStatus(ctx, req.RecordId, "Disable")
+}
+
+func alidnsError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var sdkErr *tea.SDKError
+	if !errors.As(err, &sdkErr) {
+		return newError(alidnsProvider, "", transportKind(err), err)
+	}
+	code := tea.StringValue(sdkErr.Code)
+	kind := codeKind(code, alidnsErrorCodes, alidnsErrorPrefixes)
+	if kind == ErrUnknown {
+		kind = statusKind(tea.IntValue(sdkErr.StatusCode))
+	}
+	return newError(alidnsProvider, code, kind, err)
+}
+
+func transformLinesFromAlidns(a *alidns.DescribeSupportLinesResponse, err0 error) (lines []LineListRespLine, err error) {
+	if err = alidnsError(err0); err != nil {
+		return
+	}
+	if a.Body == nil || a.Body.RecordLines == nil {
+		return
+	}
+	for _, l := range a.Body.RecordLines.RecordLine {
+		name := tea.StringValue(l.LineDisplayName)
+		if name == "" {
+			name = tea.StringValue(l.LineName)
+		}
+		lines = append(lines, LineListRespLine{Id: tea.StringValue(l.LineCode), Name: name, Parent: tea.StringValue(l.FatherCode)})
+	}
+	return
 }
 
 func (_ *DomainListRespDomain) transformFromAlidns(a *alidns.DescribeDomainsResponseBodyDomainsDomain) (domain DomainListRespDomain) {
@@ -141,7 +331,7 @@ func (_ *DomainListRespDomain) transformFromAlidns(a *alidns.DescribeDomainsResp
 }
 
 func (_ *DomainAddResp) transformFromAlidns(a *alidns.AddDomainResponse, err0 error) (resp DomainAddResp, err error) {
-	if err = err0; err != nil {
+	if err = alidnsError(err0); err != nil {
 		return
 	}
 	aa := a.Body
@@ -164,7 +354,7 @@ func (_ *RecordListRespRecord) transformFromAlidns(a *alidns.DescribeDomainRecor
 	return RecordListRespRecord{
 		Id:         tea.StringValue(a.RecordId),
 		Record:     tea.StringValue(a.RR),
-		Name:       tea.StringValue(a.RecordId),
+		Name:       recordName(tea.StringValue(a.RR), tea.StringValue(a.DomainName)),
 		Type:       tea.StringValue(a.Type),
 		Value:      tea.StringValue(a.Value),
 		Line:       tea.StringValue(a.Line),
@@ -193,7 +383,7 @@ func alidnsRecordTransformUpdate(a *alidns.UpdateDomainRecordResponse) (record R
 }
 
 func (_ *DomainListResp) transformFromAlidns(a *alidns.DescribeDomainsResponse, err0 error) (resp DomainListResp, err error) {
-	if err = err0; err != nil {
+	if err = alidnsError(err0); err != nil {
 		return
 	}
 	aa := a.Body
@@ -211,8 +401,26 @@ func (_ *DomainListResp) transformFromAlidns(a *alidns.DescribeDomainsResponse,
 	return
 }
 
+func (_ *DomainGetResp) transformFromAlidns(req DomainGetReq, a *alidns.DescribeDomainsResponse, err0 error) (resp DomainGetResp, err error) {
+	if err = alidnsError(err0); err != nil {
+		return
+	}
+	if a.Body != nil && a.Body.Domains != nil {
+		for _, dd := range a.Body.Domains.Domain {
+			if tea.StringValue(dd.DomainId) == req.DomainId || strings.EqualFold(tea.StringValue(dd.DomainName), req.Domain) {
+				// DescribeDomains 与 DescribeDomainInfo 均不返回域名状态, Status 为空
+				resp.DomainListRespDomain = (&DomainListRespDomain{}).transformFromAlidns(dd)
+				resp.Grade = tea.StringValue(dd.VersionCode)
+				return
+			}
+		}
+	}
+	err = newError(alidnsProvider, "InvalidDomainName.NoExist", ErrNotFound, fmt.Errorf("domain %s not found", cmp.Or(req.Domain, req.DomainId)))
+	return
+}
+
 func (r *RecordListResp) transformFromAlidns(a *alidns.DescribeDomainRecordsResponse, err0 error) (resp RecordListResp, err error) {
-	if err = err0; err != nil {
+	if err = alidnsError(err0); err != nil {
 		return
 	}
 	aa := a.Body
@@ -231,7 +439,7 @@ func (r *RecordListResp) transformFromAlidns(a *alidns.DescribeDomainRecordsResp
 }
 
 func (_ *RecordAddResp) transformFromAlidns(a *alidns.AddDomainRecordResponse, err0 error) (resp RecordAddResp, err error) {
-	if err = err0; err != nil {
+	if err = alidnsError(err0); err != nil {
 		return
 	}
 	resp.RecordListRespRecord = resp.transformFromAlidnsAdd(a)
@@ -239,7 +447,7 @@ func (_ *RecordAddResp) transformFromAlidns(a *alidns.AddDomainRecordResponse, e
 }
 
 func (r *RecordUpdateResp) transformFromAlidns(a *alidns.UpdateDomainRecordResponse, err0 error) (resp RecordUpdateResp, err error) {
-	if err = err0; err != nil {
+	if err = alidnsError(err0); err != nil {
 		return
 	}
 	resp.RecordListRespRecord = alidnsRecordTransformUpdate(a)
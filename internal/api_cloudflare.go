@@ -13,8 +13,12 @@ package internal
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,59 +26,179 @@ import (
 	"github.com/cloudflare/cloudflare-go"
 )
 
-var cloudflareLineDef = LineListRespLine{"0", "默认"}
+const (
+	cloudflareProvider        = "cloudflare"
+	cloudflareDomainPageLimit = 50
+)
 
-func CloudflareApi(cApi *cloudflare.API) Api { return &cloudflareApi{cApi} }
+var cloudflareLineDef = LineListRespLine{Id: "0", Name: "默认"}
 
-type cloudflareApi struct{ *cloudflare.API }
+var cloudflareCapabilities = Capabilities{
+	Operations: operationsExcept(OpDomainEnable, OpDomainDisable, OpRecordEnable, OpRecordDisable),
+	RecordTypes: []string{"A", "AAAA", "CAA", "CERT", "CNAME", "DNSKEY", "DS", "HTTPS", "LOC", "MX",
+		"NAPTR", "NS", "PTR", "SMIMEA", "SRV", "SSHFP", "SVCB", "TLSA", "TXT", "URI"},
+	TTLMin:          60,
+	TTLMax:          86400,
+	Remark:          true,
+	MXPriority:      true,
+	Proxied:         true,
+	Tags:            true,
+	ApexCNAME:       true,
+	DomainPageLimit: cloudflareDomainPageLimit,
+	RecordPageLimit: 5000000,
+	QPS:             4, // 1200 requests / 5 minutes
+}
 
-func (a *cloudflareApi) ctx() context.Context { return context.Background() }
+var cloudflareErrorCodes = map[int]ErrorKind{
+	1049:  ErrInvalidInput,  // invalid domain
+	1061:  ErrAlreadyExists, // zone already exists
+	7003:  ErrNotFound,      // could not route, identifier is invalid
+	9004:  ErrInvalidInput,  // record type cannot be proxied
+	9109:  ErrUnauthorized,  // invalid access token
+	10000: ErrUnauthorized,  // authentication error
+	81044: ErrNotFound,      // record does not exist
+	81053: ErrConflict,      // an A, AAAA or CNAME record already exists with that host
+	81057: ErrAlreadyExists, // record already exists
+	81058: ErrAlreadyExists, // identical record already exists
+}
 
-func (a *cloudflareApi) rc(domainId string) *cloudflare.ResourceContainer {
-	return &cloudflare.ResourceContainer{Identifier: domainId, Type: cloudflare.AccountType}
+// CloudflareHTTPClient 将 429 响应转为携带 Retry-After 的错误,
+// cloudflare-go 重试耗尽后只返回文本错误, 响应头会丢失
+func CloudflareHTTPClient() *http.Client {
+	return &http.Client{Transport: cloudflareTransport{http.DefaultTransport}}
 }
 
-func (a *cloudflareApi) toParams(str string) []string {
-	if str == "" {
-		return []string{}
+type cloudflareTransport struct{ http.RoundTripper }
+
+func (t cloudflareTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	if resp, err = t.RoundTripper.RoundTrip(req); err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return
 	}
-	return []string{str}
+	_ = resp.Body.Close()
+	return nil, &cloudflareRateLimitError{status: resp.Status, retryAfter: retryAfter(resp.Header)}
 }
 
-func (a *cloudflareApi) Ping() (ok bool) {
-	req, _ := http.NewRequest(http.MethodGet, a.BaseURL, nil)
-	resp, _ := (&http.Client{Timeout: time.Second * 5}).Do(req)
-	return resp.StatusCode == http.StatusOK
+type cloudflareRateLimitError struct {
+	status     string
+	retryAfter time.Duration
 }
 
-func (a *cloudflareApi) LineList() (resp LineListResp) {
-	return LineListResp{[]LineListRespLine{cloudflareLineDef}}
+func (e *cloudflareRateLimitError) Error() string { return e.status }
+
+// CloudflareApi accountId 不为空时仅管理该账号下的域名
+func CloudflareApi(cApi *cloudflare.API, accountId string) Api {
+	return BackgroundApi(&cloudflareApi{API: cApi, accountId: accountId})
+}
+
+type cloudflareApi struct {
+	*cloudflare.API
+	accountId string
 }
 
-func (a *cloudflareApi) LineDefault() (resp LineListRespLine) {
+func (a *cloudflareApi) rc(domainId string) *cloudflare.ResourceContainer {
+	return cloudflare.ZoneIdentifier(domainId)
+}
+
+func (a *cloudflareApi) Capabilities() (resp Capabilities) { return cloudflareCapabilities }
+
+func (a *cloudflareApi) PingContext(ctx context.Context) (ok bool) { return ping(ctx, a.BaseURL) }
+
+func (a *cloudflareApi) LineListContext(_ context.Context, _ LineListReq) (resp LineListResp, err error) {
+	return LineListResp{[]LineListRespLine{cloudflareLineDef}}, nil
+}
+
+func (a *cloudflareApi) LineDefaultContext(_ context.Context) (resp LineListRespLine) {
 	return cloudflareLineDef
 }
 
-func (a *cloudflareApi) DomainList(req DomainListReq) (resp DomainListResp, err error) {
-	return resp.transformFromCloudflare(a.ListZones(context.Background(), a.toParams(req.Domain)...))
+// DomainListContext 仅请求 req.Page 一页, ListZonesContext 会获取全部页且不支持指定页码
+func (a *cloudflareApi) DomainListContext(ctx context.Context, req DomainListReq) (resp DomainListResp, err error) {
+	query := url.Values{}
+	if req.Page > 0 {
+		query.Set("page", strconv.FormatUint(uint64(req.Page), 10))
+	}
+	if req.Limit > 0 {
+		query.Set("per_page", strconv.FormatUint(uint64(min(req.Limit, cloudflareDomainPageLimit)), 10))
+	}
+	if req.Domain != "" {
+		query.Set("name", req.Domain)
+	}
+	if a.accountId != "" {
+		query.Set("account.id", a.accountId)
+	}
+	raw, err0 := a.Raw(ctx, http.MethodGet, "/zones?"+query.Encode(), nil, nil)
+	var zones []cloudflare.Zone
+	if err0 == nil {
+		err0 = json.Unmarshal(raw.Result, &zones)
+	}
+	if resp, err = resp.transformFromCloudflare(zones, err0); err != nil {
+		return
+	}
+	if raw.ResultInfo != nil {
+		resp.Total = uint(raw.ResultInfo.Total)
+	}
+	return
+}
+
+func (a *cloudflareApi) DomainAddContext(ctx context.Context, req DomainAddReq) (resp DomainAddResp, err error) {
+	return resp.transformFromCloudflare(a.CreateZone(ctx, req.Domain, true, cloudflare.Account{ID: a.accountId}, ""))
 }
 
-func (a *cloudflareApi) DomainAdd(req DomainAddReq) (resp DomainAddResp, err error) {
-	return resp.transformFromCloudflare(a.CreateZone(context.Background(), req.Domain, true, cloudflare.Account{}, ""))
+func (a *cloudflareApi) DomainDeleteContext(ctx context.Context, req DomainDeleteReq) (err error) {
+	_, err = a.DeleteZone(ctx, req.DomainId)
+	return cloudflareError(err)
 }
 
-func (a *cloudflareApi) DomainDelete(req DomainDeleteReq) (err error) {
-	_, err = a.DeleteZone(context.Background(), req.DomainId)
+// DomainGetContext 依次获取 zone、DNSSEC 设置与记录数
+func (a *cloudflareApi) DomainGetContext(ctx context.Context, req DomainGetReq) (resp DomainGetResp, err error) {
+	zoneId := req.DomainId
+	if zoneId == "" {
+		zones, err0 := a.ListZonesContext(ctx, cloudflare.WithZoneFilters(req.Domain, a.accountId, ""))
+		if err = cloudflareError(err0); err != nil {
+			return
+		}
+		if len(zones.Result) == 0 {
+			err = newError(cloudflareProvider, "", ErrNotFound, fmt.Errorf("zone %s not found", req.Domain))
+			return
+		}
+		zoneId = zones.Result[0].ID
+	}
+	zone, err0 := a.ZoneDetails(ctx, zoneId)
+	if err = cloudflareError(err0); err != nil {
+		return
+	}
+	// DNSSEC 需要单独的权限, 获取失败时状态为空
+	dnssec, _ := a.ZoneDNSSECSetting(ctx, zoneId)
+	_, info, err0 := a.ListDNSRecords(ctx, a.rc(zoneId), cloudflare.ListDNSRecordsParams{ResultInfo: cloudflare.ResultInfo{Page: 1, PerPage: 1}})
+	if err = cloudflareError(err0); err != nil {
+		return
+	}
+	resp = DomainGetResp{
+		DomainListRespDomain: (&DomainListRespDomain{}).transformFromCloudflare(zone),
+		Status:               cloudflareStatus(zone.Status),
+		Grade:                zone.Plan.Name,
+		DNSSEC:               cloudflareStatus(dnssec.Status),
+	}
+	resp.RecordCount = uint(info.Total)
 	return
 }
 
-func (a *cloudflareApi) RecordList(req RecordListReq) (resp RecordListResp, err error) {
+// DomainEnableContext zone 的暂停(paused)仅关闭代理, 不影响解析, 不作为域名暂停
+func (a *cloudflareApi) DomainEnableContext(_ context.Context, _ DomainEnableReq) (err error) {
+	return ErrNotSupportedOperation
+}
+
+func (a *cloudflareApi) DomainDisableContext(_ context.Context, _ DomainDisableReq) (err error) {
+	return ErrNotSupportedOperation
+}
+
+func (a *cloudflareApi) RecordListContext(ctx context.Context, req RecordListReq) (resp RecordListResp, err error) {
 	name := ""
 	if req.Record != "" && req.Domain != "" {
-		name = fmt.Sprintf("%s.%s", req.Record, req.Domain)
+		name = recordName(req.Record, req.Domain)
 	}
 	return resp.transformFromCloudflare(a.ListDNSRecords(
-		context.Background(),
+		ctx,
 		a.rc(req.DomainId),
 		cloudflare.ListDNSRecordsParams{
 			Type:       req.Type,
@@ -88,57 +212,202 @@ func (a *cloudflareApi) RecordList(req RecordListReq) (resp RecordListResp, err
 	)
 }
 
-func (a *cloudflareApi) RecordAdd(req RecordAddReq) (resp RecordAddResp, err error) {
+func (a *cloudflareApi) RecordAddContext(ctx context.Context, req RecordAddReq) (resp RecordAddResp, err error) {
+	req.Type, req.Value = recordValue(req.Type, req.Value, req.Data)
+	content, data, priority := cloudflareData(req.Type, req.Value, req.MX)
 	return resp.transformFromCloudflare(a.CreateDNSRecord(
-		context.Background(),
+		ctx,
 		a.rc(req.DomainId),
 		cloudflare.CreateDNSRecordParams{
 			Type:     req.Type,
-			Name:     fmt.Sprintf("%s.%s", req.Record, req.Domain),
-			Content:  req.Value,
-			Priority: tea.Uint16(uint16(1)),
+			Name:     recordName(req.Record, req.Domain),
+			Content:  content,
+			Data:     data,
+			Priority: tea.Uint16(priority),
 			TTL:      int(req.TTL),
 			Comment:  req.Remark,
+			Proxied:  req.Proxied,
+			Tags:     req.Tags,
 		},
 	))
 }
 
-func (a *cloudflareApi) RecordUpdate(req RecordUpdateReq) (resp RecordUpdateResp, err error) {
-	return resp.transformFromCloudflare(a.UpdateDNSRecord(
-		context.Background(),
-		a.rc(req.DomainId),
-		cloudflare.UpdateDNSRecordParams{
-			Type:     req.Type,
-			Name:     fmt.Sprintf("%s.%s", req.Record, req.Domain),
-			Content:  req.Value,
-			ID:       req.RecordId,
-			Priority: tea.Uint16(uint16(1)),
-			TTL:      int(req.TTL),
-			Comment:  &req.Remark,
-		},
-	))
+func (a *cloudflareApi) RecordUpdateContext(ctx context.Context, req RecordUpdateReq) (resp RecordUpdateResp, err error) {
+	req.Type, req.Value = recordValue(req.Type, req.Value, req.Data)
+	content, data, priority := cloudflareData(req.Type, req.Value, req.MX)
+	params := cloudflareRecordPatch{UpdateDNSRecordParams: cloudflare.UpdateDNSRecordParams{
+		Type:     req.Type,
+		Name:     recordName(req.Record, req.Domain),
+		Content:  content,
+		Data:     data,
+		Priority: tea.Uint16(priority),
+		TTL:      int(req.TTL),
+		Proxied:  req.Proxied,
+		Comment:  req.Remark,
+	}}
+	if req.Tags != nil {
+		// 提交空数组而不是 null 以清空标签
+		tags := append([]string{}, *req.Tags...)
+		params.Tags = &tags
+	}
+	if req.DomainId == "" || req.RecordId == "" {
+		return resp, &Error{Kind: ErrInvalidInput, Provider: cloudflareProvider, Err: errors.New("domain id and record id are required")}
+	}
+	// UpdateDNSRecord 总是发送 tags, nil 会清空原有标签, 因此直接发送 PATCH 请求
+	raw, err := a.Raw(ctx, http.MethodPatch, fmt.Sprintf("/zones/%s/dns_records/%s", a.rc(req.DomainId).Identifier, req.RecordId), params, nil)
+	if err != nil {
+		return resp.transformFromCloudflare(cloudflare.DNSRecord{}, err)
+	}
+	var record cloudflare.DNSRecord
+	if err = json.Unmarshal(raw.Result, &record); err != nil {
+		return
+	}
+	return resp.transformFromCloudflare(record, nil)
+}
+
+// cloudflareRecordPatch Comment 与 Tags 为 nil 时不发送, 保留原值
+type cloudflareRecordPatch struct {
+	cloudflare.UpdateDNSRecordParams
+	Tags *[]string `json:"tags,omitempty"`
 }
 
-func (a *cloudflareApi) RecordDelete(req RecordDeleteReq) (err error) {
-	return a.DeleteDNSRecord(context.Background(), a.rc(req.DomainId), req.RecordId)
+func (a *cloudflareApi) RecordDeleteContext(ctx context.Context, req RecordDeleteReq) (err error) {
+	return cloudflareError(a.DeleteDNSRecord(ctx, a.rc(req.DomainId), req.RecordId))
 }
 
-func (a *cloudflareApi) RecordEnable(_ RecordEnableReq) (err error) {
+func (a *cloudflareApi) RecordEnableContext(_ context.Context, _ RecordEnableReq) (err error) {
 	return ErrNotSupportedOperation
 }
 
-func (a *cloudflareApi) RecordDisable(_ RecordDisableReq) (err error) {
+func (a *cloudflareApi) RecordDisableContext(_ context.Context, _ RecordDisableReq) (err error) {
 	return ErrNotSupportedOperation
 }
 
+func cloudflareError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var cfErr interface {
+		ErrorCodes() []int
+		Type() cloudflare.ErrorType
+	}
+	var rateLimitErr *cloudflareRateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return &Error{Kind: ErrRateLimited, Provider: cloudflareProvider, RetryAfter: rateLimitErr.retryAfter, Err: err}
+	}
+	if !errors.As(err, &cfErr) {
+		switch {
+		case strings.HasPrefix(err.Error(), "exceeded available rate limit retries"):
+			return newError(cloudflareProvider, "", ErrRateLimited, err)
+		case strings.HasSuffix(err.Error(), "please try again later"):
+			return newError(cloudflareProvider, "", ErrProviderUnavailable, err)
+		}
+		return newError(cloudflareProvider, "", transportKind(err), err)
+	}
+	var code string
+	for _, c := range cfErr.ErrorCodes() {
+		code = strconv.Itoa(c)
+		if kind, ok := cloudflareErrorCodes[c]; ok {
+			return newError(cloudflareProvider, code, kind, err)
+		}
+	}
+	kind := ErrUnknown
+	switch cfErr.Type() {
+	case cloudflare.ErrorTypeNotFound:
+		kind = ErrNotFound
+	case cloudflare.ErrorTypeAuthentication, cloudflare.ErrorTypeAuthorization:
+		kind = ErrUnauthorized
+	case cloudflare.ErrorTypeRateLimit:
+		kind = ErrRateLimited
+	case cloudflare.ErrorTypeService:
+		kind = ErrProviderUnavailable
+	case cloudflare.ErrorTypeRequest:
+		kind = ErrInvalidInput
+	}
+	return newError(cloudflareProvider, code, kind, err)
+}
+
+// cloudflareData SRV、CAA、HTTPS、SVCB 记录通过 data 提交, 其他类型使用 content
+func cloudflareData(typ, value string, mx uint16) (content string, data any, priority uint16) {
+	d, err := ParseRecordData(typ, value)
+	if err != nil {
+		return value, nil, mxPriority(mx)
+	}
+	switch v := d.(type) {
+	case SRV:
+		return "", map[string]any{"priority": v.Priority, "weight": v.Weight, "port": v.Port, "target": v.Target}, v.Priority
+	case CAA:
+		return "", map[string]any{"flags": v.Flags, "tag": v.Tag, "value": v.Value}, mxPriority(mx)
+	case SVCB:
+		return "", map[string]any{"priority": v.Priority, "target": v.Target, "value": v.ParamsString()}, v.Priority
+	case HTTPS:
+		return "", map[string]any{"priority": v.Priority, "target": v.Target, "value": v.ParamsString()}, v.Priority
+	}
+	return value, nil, mxPriority(mx)
+}
+
+// cloudflareValue 将 data 转换为通用格式, SRV 的 content 不含优先级, TXT 的 content 可能带引号
+func cloudflareValue(a cloudflare.DNSRecord) string {
+	m, _ := a.Data.(map[string]any)
+	num := func(key string) uint16 { f, _ := m[key].(float64); return uint16(f) }
+	str := func(key string) string { s, _ := m[key].(string); return s }
+	switch strings.ToUpper(a.Type) {
+	case "SRV":
+		if m != nil {
+			return SRV{num("priority"), num("weight"), num("port"), str("target")}.String()
+		}
+		if len(strings.Fields(a.Content)) == 3 {
+			return fmt.Sprintf("%d %s", tea.Uint16Value(a.Priority), a.Content)
+		}
+	case "CAA":
+		if m != nil {
+			return CAA{uint8(num("flags")), str("tag"), str("value")}.String()
+		}
+	case "HTTPS", "SVCB":
+		if m != nil {
+			if d, err := ParseRecordData(a.Type, fmt.Sprintf("%d %s %s", num("priority"), str("target"), str("value"))); err == nil {
+				return d.String()
+			}
+		}
+	case "TXT", "SPF":
+		if strings.HasPrefix(a.Content, `"`) {
+			var sb strings.Builder
+			for _, f := range dataFields(a.Content) {
+				sb.WriteString(dataUnquote(f))
+			}
+			return sb.String()
+		}
+	}
+	return a.Content
+}
+
+// cloudflareStatus active 转换为 enable, disabled 转换为 disable, 其他状态原样返回 => pending
+func cloudflareStatus(status string) string {
+	switch status {
+	case "active":
+		return "enable"
+	case "disabled":
+		return "disable"
+	}
+	return status
+}
+
+func cloudflareMX(a cloudflare.DNSRecord) uint16 {
+	if strings.EqualFold(a.Type, "MX") {
+		return tea.Uint16Value(a.Priority)
+	}
+	return 0
+}
+
 func (_ *DomainListResp) transformFromCloudflare(zones []cloudflare.Zone, err0 error) (resp DomainListResp, err error) {
-	if err = err0; err != nil {
+	if err = cloudflareError(err0); err != nil {
 		return
 	}
 	var list []DomainListRespDomain
 	for _, zone := range zones {
 		list = append(list, (&DomainListRespDomain{}).transformFromCloudflare(zone))
 	}
+	resp.Total = uint(len(list))
 	resp.List = list
 	return
 }
@@ -153,7 +422,7 @@ func (_ *DomainListRespDomain) transformFromCloudflare(a cloudflare.Zone) (domai
 }
 
 func (_ *DomainAddResp) transformFromCloudflare(a cloudflare.Zone, err0 error) (resp DomainAddResp, err error) {
-	if err = err0; err != nil {
+	if err = cloudflareError(err0); err != nil {
 		return
 	}
 	resp = DomainAddResp{a.ID, a.NameServers}
@@ -162,22 +431,25 @@ func (_ *DomainAddResp) transformFromCloudflare(a cloudflare.Zone, err0 error) (
 
 func (_ *RecordListRespRecord) transformFromCloudflare(a cloudflare.DNSRecord) (record RecordListRespRecord) {
 	return RecordListRespRecord{
-		Id:         a.ID,
-		Record:     strings.TrimSuffix(strings.ReplaceAll(a.Name, a.ZoneName, ""), "."),
-		Name:       a.Name,
-		Type:       a.Type,
-		Value:      a.Content,
-		Line:       cloudflareLineDef.Id,
-		TTL:        uint(a.TTL),
-		MX:         tea.Uint16Value(a.Priority),
-		Remark:     a.Comment,
-		CreateTime: formatTime(a.CreatedOn),
-		UpdateTime: formatTime(a.ModifiedOn),
+		Id:            a.ID,
+		Record:        strings.TrimSuffix(strings.ReplaceAll(a.Name, a.ZoneName, ""), "."),
+		Name:          a.Name,
+		Type:          a.Type,
+		Value:         cloudflareValue(a),
+		Line:          cloudflareLineDef.Id,
+		CanonicalLine: LineDefault,
+		TTL:           uint(a.TTL),
+		MX:            cloudflareMX(a),
+		Remark:        a.Comment,
+		Proxied:       tea.BoolValue(a.Proxied),
+		Tags:          a.Tags,
+		CreateTime:    formatTime(a.CreatedOn),
+		UpdateTime:    formatTime(a.ModifiedOn),
 	}
 }
 
 func (_ *RecordListResp) transformFromCloudflare(records []cloudflare.DNSRecord, resultInfo *cloudflare.ResultInfo, err0 error) (resp RecordListResp, err error) {
-	if err = err0; err != nil {
+	if err = cloudflareError(err0); err != nil {
 		return
 	}
 	if resultInfo != nil {
@@ -194,7 +466,7 @@ func (_ *RecordListResp) transformFromCloudflare(records []cloudflare.DNSRecord,
 }
 
 func (r *RecordAddResp) transformFromCloudflare(a cloudflare.DNSRecord, err0 error) (resp RecordAddResp, err error) {
-	if err = err0; err != nil {
+	if err = cloudflareError(err0); err != nil {
 		return
 	}
 	resp.RecordListRespRecord = (&RecordListRespRecord{}).transformFromCloudflare(a)
@@ -202,7 +474,7 @@ func (r *RecordAddResp) transformFromCloudflare(a cloudflare.DNSRecord, err0 err
 }
 
 func (r *RecordUpdateResp) transformFromCloudflare(a cloudflare.DNSRecord, err0 error) (resp RecordUpdateResp, err error) {
-	if err = err0; err != nil {
+	if err = cloudflareError(err0); err != nil {
 		return
 	}
 	resp.RecordListRespRecord = (&RecordListRespRecord{}).transformFromCloudflare(a)
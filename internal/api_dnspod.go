@@ -12,70 +12,164 @@
 package internal
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	common "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/http"
-	"net/http"
 	"strings"
-	"time"
 
 	"github.com/alibabacloud-go/tea/tea"
 
 	dnspodErrors "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+	common "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/http"
 	dnspod "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/dnspod/v20210323"
 )
 
-var dnspodLineDef = LineListRespLine{"0", "默认"}
+const dnspodProvider = "dnspod"
 
-func DnspodApi(client *dnspod.Client) Api { return &dnspodApi{client} }
+var dnspodLineDef = LineListRespLine{Id: "0", Name: "默认"}
 
-type dnspodApi struct{ *dnspod.Client }
+var dnspodLineMap = lineMap{
+	{LineDefault, "0"},
+	{LineChina, "7=0"},
+	{LineTelecom, "10=0"},
+	{LineUnicom, "10=1"},
+	{LineEducation, "10=2"},
+	{LineMobile, "10=3"},
+	{LineOverseas, "3=0"},
+}
 
-func (a *dnspodApi) endpoint() string {
-	return fmt.Sprintf("https://%s", "dnspod"+"."+common.RootDomain)
+var dnspodCapabilities = Capabilities{
+	Operations:      operationsExcept(),
+	RecordTypes:     []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS", "SRV", "CAA", "SPF", "HTTPS", "SVCB"},
+	TTLMin:          1,
+	TTLMax:          604800,
+	Weight:          true,
+	Remark:          true,
+	Line:            true,
+	MXPriority:      true,
+	DomainPageLimit: 3000,
+	RecordPageLimit: 3000,
+	QPS:             20,
 }
 
-func (a *dnspodApi) Ping() (ok bool) {
-	req, _ := http.NewRequest(http.MethodGet, a.endpoint(), nil)
-	resp, _ := (&http.Client{Timeout: time.Second * 5}).Do(req)
-	return resp.StatusCode == http.StatusOK
+var (
+	dnspodErrorCodes = map[string]ErrorKind{
+		"FailedOperation.DomainExists":          ErrAlreadyExists,
+		"InvalidParameter.DomainRecordExist":    ErrAlreadyExists,
+		"InvalidParameterValue.DomainNotExists": ErrNotFound,
+		"FailedOperation.DomainIsLocked":        ErrConflict,
+		"FailedOperation.FrequencyLimit":        ErrRateLimited,
+		"InvalidParameter.PermissionDenied":     ErrUnauthorized,
+		"ResourceInUse":                         ErrConflict,
+		"ResourceUnavailable":                   ErrProviderUnavailable,
+		"InternalError":                         ErrProviderUnavailable,
+	}
+	dnspodErrorPrefixes = []codePrefix{
+		{"ResourceNotFound", ErrNotFound},
+		{"RequestLimitExceeded", ErrRateLimited},
+		{"AuthFailure", ErrUnauthorized},
+		{"UnauthorizedOperation", ErrUnauthorized},
+		{"OperationDenied", ErrUnauthorized},
+		{"InvalidParameter", ErrInvalidInput},
+		{"MissingParameter", ErrInvalidInput},
+		{"UnknownParameter", ErrInvalidInput},
+		{"InternalError", ErrProviderUnavailable},
+		{"ClientError.NetworkError", ErrProviderUnavailable},
+	}
+)
+
+func DnspodApi(client *dnspod.Client, scheme, endpoint string) Api {
+	return BackgroundApi(&dnspodApi{Client: client, scheme: scheme, host: endpoint})
 }
 
-func (a *dnspodApi) LineList() (resp LineListResp) {
-	lines := []LineListRespLine{
-		dnspodLineDef,
-		{"10=1", "电信"},
-		{"10=0", "联通"},
-		{"10=3", "移动"},
-		{"3=0", "境外"},
+type dnspodApi struct {
+	*dnspod.Client
+	scheme, host string
+	lines        lineCache
+}
+
+func (a *dnspodApi) endpoint() string {
+	scheme, host := "https", "dnspod"+"."+common.RootDomain
+	if a.scheme != "" {
+		scheme = strings.ToLower(a.scheme)
 	}
-	return LineListResp{lines}
+	if a.host != "" {
+		host = a.host
+	}
+	return fmt.Sprintf("%s://%s", scheme, host)
+}
+
+func (a *dnspodApi) Capabilities() (resp Capabilities) { return dnspodCapabilities }
+
+func (a *dnspodApi) PingContext(ctx context.Context) (ok bool) { return ping(ctx, a.endpoint()) }
+
+func (a *dnspodApi) LineListContext(ctx context.Context, req LineListReq) (resp LineListResp, err error) {
+	return a.lines.get(ctx, req, func(ctx context.Context) (lines []LineListRespLine, err error) {
+		req0 := dnspod.NewDescribeRecordLineCategoryListRequest()
+		req0.Domain = tea.String(req.Domain)
+		if req.DomainId != "" {
+			req0.DomainId = toUint64Ptr(req.DomainId)
+		}
+		return transformLinesFromDnspod(a.DescribeRecordLineCategoryListWithContext(ctx, req0))
+	})
 }
 
-func (a *dnspodApi) LineDefault() (resp LineListRespLine) { return dnspodLineDef }
+func (a *dnspodApi) LineDefaultContext(_ context.Context) (resp LineListRespLine) {
+	return dnspodLineDef
+}
 
-func (a *dnspodApi) DomainList(req DomainListReq) (resp DomainListResp, err error) {
+func (a *dnspodApi) DomainListContext(ctx context.Context, req DomainListReq) (resp DomainListResp, err error) {
 	req0 := dnspod.NewDescribeDomainListRequest()
 	req0.Keyword = tea.String(req.Domain)
-	req0.Offset = tea.Int64(int64((req.Page - 1) * req.Limit))
+	req0.Offset = tea.Int64(int64(pageOffset(req.Page, req.Limit)))
 	req0.Limit = tea.Int64(int64(req.Limit))
-	return resp.transformFromDnspod(a.DescribeDomainList(req0))
+	return resp.transformFromDnspod(a.DescribeDomainListWithContext(ctx, req0))
 }
 
-func (a *dnspodApi) DomainAdd(req DomainAddReq) (resp DomainAddResp, err error) {
+func (a *dnspodApi) DomainAddContext(ctx context.Context, req DomainAddReq) (resp DomainAddResp, err error) {
 	req0 := dnspod.NewCreateDomainRequest()
 	req0.Domain = tea.String(req.Domain)
-	return resp.transformFromDnspod(a.CreateDomain(req0))
+	return resp.transformFromDnspod(a.CreateDomainWithContext(ctx, req0))
 }
 
-func (a *dnspodApi) DomainDelete(req DomainDeleteReq) (err error) {
+func (a *dnspodApi) DomainDeleteContext(ctx context.Context, req DomainDeleteReq) (err error) {
 	req0 := dnspod.NewDeleteDomainRequest()
 	req0.Domain = tea.String(req.Domain)
-	_, err = a.DeleteDomain(req0)
+	_, err = a.DeleteDomainWithContext(ctx, req0)
+	if err = dnspodError(err); err == nil {
+		a.lines.forget(req.DomainId, req.Domain)
+	}
 	return
 }
 
-func (a *dnspodApi) RecordList(req RecordListReq) (resp RecordListResp, err error) {
+func (a *dnspodApi) DomainGetContext(ctx context.Context, req DomainGetReq) (resp DomainGetResp, err error) {
+	req0 := dnspod.NewDescribeDomainRequest()
+	req0.Domain = tea.String(req.Domain)
+	req0.DomainId = toUint64Ptr(req.DomainId)
+	return resp.transformFromDnspod(a.DescribeDomainWithContext(ctx, req0))
+}
+
+func (a *dnspodApi) DomainEnableContext(ctx context.Context, req DomainEnableReq) (err error) {
+	return a.domainStatus(ctx, req.DomainId, req.Domain, "enable")
+}
+
+func (a *dnspodApi) DomainDisableContext(ctx context.Context, req DomainDisableReq) (err error) {
+	return a.domainStatus(ctx, req.DomainId, req.Domain, "disable")
+}
+
+func (a *dnspodApi) domainStatus(ctx context.Context, domainId, domain, status string) (err error) {
+	req0 := dnspod.NewModifyDomainStatusRequest()
+	req0.Domain = tea.String(domain)
+	req0.DomainId = toUint64Ptr(domainId)
+	req0.Status = tea.String(status)
+	_, err = a.ModifyDomainStatusWithContext(ctx, req0)
+	return dnspodError(err)
+}
+
+func (a *dnspodApi) RecordListContext(ctx context.Context, req RecordListReq) (resp RecordListResp, err error) {
+	if req.Line, err = dnspodLineMap.native(req.Line, lineCatalog(ctx, a, req.DomainId, req.Domain)); err != nil {
+		return
+	}
 	req0 := dnspod.NewDescribeRecordListRequest()
 	req0.Domain = tea.String(req.Domain)
 	req0.DomainId = toUint64Ptr(req.DomainId)
@@ -85,12 +179,23 @@ func (a *dnspodApi) RecordList(req RecordListReq) (resp RecordListResp, err erro
 	req0.RecordLineId = tea.String(req.Line)
 	req0.SortField = tea.String(req.Order)
 	req0.SortType = tea.String(strings.ToUpper(req.Direction))
-	req0.Offset = tea.Uint64(uint64((req.Page - 1) * req.Limit))
+	req0.Offset = tea.Uint64(uint64(pageOffset(req.Page, req.Limit)))
 	req0.Limit = tea.Uint64(uint64(req.Limit))
-	return resp.transformFromDnspod(a.DescribeRecordList(req0))
+	if resp, err = resp.transformFromDnspod(a.DescribeRecordListWithContext(ctx, req0)); err != nil {
+		return
+	}
+	for i := range resp.List {
+		resp.List[i].Name = recordName(resp.List[i].Record, req.Domain)
+	}
+	dnspodLineMap.canonicalize(resp.List, lineCatalog(ctx, a, req.DomainId, req.Domain))
+	return
 }
 
-func (a *dnspodApi) RecordAdd(req RecordAddReq) (resp RecordAddResp, err error) {
+func (a *dnspodApi) RecordAddContext(ctx context.Context, req RecordAddReq) (resp RecordAddResp, err error) {
+	if req.Line, err = dnspodLineMap.native(req.Line, lineCatalog(ctx, a, req.DomainId, req.Domain)); err != nil {
+		return
+	}
+	req.Type, req.Value = recordValue(req.Type, req.Value, req.Data)
 	req0 := dnspod.NewCreateRecordRequest()
 	req0.Domain = tea.String(req.Domain)
 	req0.DomainId = toUint64Ptr(req.DomainId)
@@ -100,13 +205,17 @@ func (a *dnspodApi) RecordAdd(req RecordAddReq) (resp RecordAddResp, err error)
 	req0.RecordLineId = tea.String(req.Line)
 	req0.RecordLine = tea.String("")
 	req0.TTL = tea.Uint64(uint64(req.TTL))
-	req0.MX = tea.Uint64(1)
+	req0.MX = tea.Uint64(uint64(mxPriority(req.MX)))
 	req0.Weight = tea.Uint64(uint64(req.Weight))
 	req0.Remark = tea.String(req.Remark)
-	return resp.transformFromDnspod(a.CreateRecord(req0))
+	return resp.transformFromDnspod(a.CreateRecordWithContext(ctx, req0))
 }
 
-func (a *dnspodApi) RecordUpdate(req RecordUpdateReq) (resp RecordUpdateResp, err error) {
+func (a *dnspodApi) RecordUpdateContext(ctx context.Context, req RecordUpdateReq) (resp RecordUpdateResp, err error) {
+	if req.Line, err = dnspodLineMap.native(req.Line, lineCatalog(ctx, a, req.DomainId, req.Domain)); err != nil {
+		return
+	}
+	req.Type, req.Value = recordValue(req.Type, req.Value, req.Data)
 	req0 := dnspod.NewModifyRecordRequest()
 	req0.RecordId = toUint64Ptr(req.RecordId)
 	req0.Domain = tea.String(req.Domain)
@@ -117,41 +226,75 @@ func (a *dnspodApi) RecordUpdate(req RecordUpdateReq) (resp RecordUpdateResp, er
 	req0.RecordLineId = tea.String(req.Line)
 	req0.RecordLine = tea.String("")
 	req0.TTL = tea.Uint64(uint64(req.TTL))
-	req0.MX = tea.Uint64(1)
+	req0.MX = tea.Uint64(uint64(mxPriority(req.MX)))
 	req0.Weight = tea.Uint64(uint64(req.Weight))
-	req0.Remark = tea.String(req.Remark)
-	return resp.transformFromDnspod(a.ModifyRecord(req0))
+	req0.Remark = req.Remark
+	return resp.transformFromDnspod(a.ModifyRecordWithContext(ctx, req0))
 }
 
-func (a *dnspodApi) RecordDelete(req RecordDeleteReq) (err error) {
+func (a *dnspodApi) RecordDeleteContext(ctx context.Context, req RecordDeleteReq) (err error) {
 	req0 := dnspod.NewDeleteRecordRequest()
 	req0.RecordId = toUint64Ptr(req.RecordId)
 	req0.DomainId = toUint64Ptr(req.DomainId)
 	req0.Domain = tea.String("")
-	_, err = a.DeleteRecord(req0)
-	return
+	_, err = a.DeleteRecordWithContext(ctx, req0)
+	return dnspodError(err)
 }
 
-func (a *dnspodApi) recordStatus(domainId, recordId string, status string) (err error) {
+func (a *dnspodApi) recordStatus(ctx context.Context, domainId, recordId string, status string) (err error) {
 	req0 := dnspod.NewModifyRecordStatusRequest()
 	req0.RecordId = toUint64Ptr(recordId)
 	req0.DomainId = toUint64Ptr(domainId)
 	req0.Domain = tea.String("")
 	req0.Status = tea.String(status)
-	_, err = a.ModifyRecordStatus(req0)
-	return
+	_, err = a.ModifyRecordStatusWithContext(ctx, req0)
+	return dnspodError(err)
+}
+
+func (a *dnspodApi) RecordEnableContext(ctx context.Context, req RecordEnableReq) (err error) {
+	return a.recordStatus(ctx, req.DomainId, req.RecordId, "ENABLE")
+}
+
+func (a *dnspodApi) RecordDisableContext(ctx context.Context, req RecordDisableReq) (err error) {
+	return a.recordStatus(ctx, req.DomainId, req.RecordId, "DISABLE")
 }
 
-func (a *dnspodApi) RecordEnable(req RecordEnableReq) (err error) {
-	return a.recordStatus(req.DomainId, req.RecordId, "ENABLE")
+func dnspodError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var sdkErr *dnspodErrors.TencentCloudSDKError
+	if !errors.As(err, &sdkErr) {
+		return newError(dnspodProvider, "", transportKind(err), err)
+	}
+	return newError(dnspodProvider, sdkErr.Code, codeKind(sdkErr.Code, dnspodErrorCodes, dnspodErrorPrefixes), err)
 }
 
-func (a *dnspodApi) RecordDisable(req RecordDisableReq) (err error) {
-	return a.recordStatus(req.DomainId, req.RecordId, "DISABLE")
+// transformLinesFromDnspod 展开线路分类, 跳过当前套餐不可用的线路及其下级线路
+func transformLinesFromDnspod(a *dnspod.DescribeRecordLineCategoryListResponse, err0 error) (lines []LineListRespLine, err error) {
+	if err = dnspodError(err0); err != nil {
+		return
+	}
+	if a.Response == nil {
+		return
+	}
+	var walk func(items []*dnspod.LineItem, parent string)
+	walk = func(items []*dnspod.LineItem, parent string) {
+		for _, item := range items {
+			if item == nil || (item.Useful != nil && !*item.Useful) {
+				continue
+			}
+			id := tea.StringValue(item.LineId)
+			lines = append(lines, LineListRespLine{Id: id, Name: tea.StringValue(item.LineName), Parent: parent})
+			walk(item.SubGroup, id)
+		}
+	}
+	walk(a.Response.LineList, "")
+	return
 }
 
 func (*DomainListResp) transformFromDnspod(a *dnspod.DescribeDomainListResponse, err0 error) (resp DomainListResp, err error) {
-	if err = err0; err != nil {
+	if err = dnspodError(err0); err != nil {
 		return
 	}
 	aa := a.Response
@@ -174,11 +317,12 @@ func (*DomainListResp) transformFromDnspod(a *dnspod.DescribeDomainListResponse,
 			})
 		}
 	}
+	resp.List = list
 	return
 }
 
 func (*DomainAddResp) transformFromDnspod(a *dnspod.CreateDomainResponse, err0 error) (resp DomainAddResp, err error) {
-	if err = err0; err != nil {
+	if err = dnspodError(err0); err != nil {
 		return
 	}
 	aa := a.Response
@@ -190,11 +334,38 @@ func (*DomainAddResp) transformFromDnspod(a *dnspod.CreateDomainResponse, err0 e
 	return
 }
 
+// transformFromDnspod Status 为 ENABLE、PAUSE 以外的值(如 SPAM 封禁)时原样转为小写; SDK 未提供 DNSSEC 状态
+func (*DomainGetResp) transformFromDnspod(a *dnspod.DescribeDomainResponse, err0 error) (resp DomainGetResp, err error) {
+	if err = dnspodError(err0); err != nil {
+		return
+	}
+	if a.Response == nil || a.Response.DomainInfo == nil {
+		return
+	}
+	aa := a.Response.DomainInfo
+	resp = DomainGetResp{
+		DomainListRespDomain: DomainListRespDomain{
+			Id:          fmt.Sprintf("%d", tea.Uint64Value(aa.DomainId)),
+			Name:        tea.StringValue(aa.Domain),
+			DnsServer:   dnsServer(aa.DnspodNsList),
+			RecordCount: uint(tea.Uint64Value(aa.RecordCount)),
+			Remark:      tea.StringValue(aa.Remark),
+			CreateTime:  tea.StringValue(aa.CreatedOn),
+		},
+		Status: strings.ToLower(tea.StringValue(aa.Status)),
+		Grade:  tea.StringValue(aa.Grade),
+	}
+	if resp.Status == "pause" {
+		resp.Status = "disable"
+	}
+	return
+}
+
 func (*RecordListRespRecord) dnspodRecordTransform(a *dnspod.RecordListItem) (record RecordListRespRecord) {
 	return RecordListRespRecord{
 		Id:         fmt.Sprintf("%d", tea.Uint64Value(a.RecordId)),
 		Record:     tea.StringValue(a.Name),
-		Name:       "", // TODO:
+		Name:       "", // filled by RecordListContext
 		Type:       tea.StringValue(a.Type),
 		Value:      tea.StringValue(a.Value),
 		Line:       tea.StringValue(a.LineId),
@@ -234,11 +405,11 @@ func (r *RecordListResp) transformFromDnspod(a *dnspod.DescribeRecordListRespons
 			if _, ignored := ignoreCodesMap[sdkError.Code]; ignored {
 				// ignored
 			} else {
-				err = err0
+				err = dnspodError(err0)
 				return
 			}
 		} else {
-			err = err0
+			err = dnspodError(err0)
 			return
 		}
 	}
@@ -260,7 +431,7 @@ func (r *RecordListResp) transformFromDnspod(a *dnspod.DescribeRecordListRespons
 }
 
 func (r *RecordAddResp) transformFromDnspod(a *dnspod.CreateRecordResponse, err0 error) (resp RecordAddResp, err error) {
-	if err = err0; err != nil {
+	if err = dnspodError(err0); err != nil {
 		return
 	}
 	resp.RecordListRespRecord = (&RecordListRespRecord{}).dnspodRecordTransformAdd(a)
@@ -268,7 +439,7 @@ func (r *RecordAddResp) transformFromDnspod(a *dnspod.CreateRecordResponse, err0
 }
 
 func (r *RecordUpdateResp) transformFromDnspod(a *dnspod.ModifyRecordResponse, err0 error) (resp RecordUpdateResp, err error) {
-	if err = err0; err != nil {
+	if err = dnspodError(err0); err != nil {
 		return
 	}
 	resp.RecordListRespRecord = (&RecordListRespRecord{}).dnspodRecordTransformUpdate(a)
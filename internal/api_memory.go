@@ -0,0 +1,488 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const memoryProvider = "memory"
+
+var memoryLineDef = LineListRespLine{Id: "default", Name: "默认"}
+
+var memoryLines = []LineListRespLine{
+	memoryLineDef,
+	{Id: "cn", Name: "境内"},
+	{Id: "telecom", Name: "电信", Parent: "cn"},
+	{Id: "cn_telecom_beijing", Name: "电信_北京", Parent: "telecom"},
+	{Id: "cn_telecom_shanghai", Name: "电信_上海", Parent: "telecom"},
+	{Id: "unicom", Name: "联通", Parent: "cn"},
+	{Id: "cn_unicom_beijing", Name: "联通_北京", Parent: "unicom"},
+	{Id: "mobile", Name: "移动", Parent: "cn"},
+	{Id: "oversea", Name: "境外"},
+}
+
+var memoryLineMap = lineMap{
+	{LineDefault, "default"},
+	{LineChina, "cn"},
+	{LineTelecom, "telecom"},
+	{LineUnicom, "unicom"},
+	{LineMobile, "mobile"},
+	{LineOverseas, "oversea"},
+}
+
+// memoryCatalog 线路与域名无关, 不加锁
+func memoryCatalog() (LineListResp, error) { return LineListResp{memoryLines}, nil }
+
+var memoryCapabilities = Capabilities{
+	Operations:      operationsExcept(),
+	RecordTypes:     []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS", "SRV", "CAA", "PTR", "HTTPS", "SVCB"},
+	TTLMin:          1,
+	TTLMax:          604800,
+	Weight:          true,
+	Remark:          true,
+	Line:            true,
+	MXPriority:      true,
+	Proxied:         true,
+	Tags:            true,
+	DomainPageLimit: 100,
+	RecordPageLimit: 500,
+}
+
+var (
+	errMemoryDomainNotFound = errors.New("domain not found")
+	errMemoryDomainExists   = errors.New("domain already exists")
+	errMemoryRecordNotFound = errors.New("record not found")
+	errMemoryRecordExists   = errors.New("record already exists")
+)
+
+// MemoryOpts 内存实现的配置
+type MemoryOpts struct {
+	Latency time.Duration            // 每次调用的延迟
+	Fault   func(op Operation) error // 返回非nil时该次调用直接返回此错误
+}
+
+func MemoryApi(opts MemoryOpts) Api {
+	return BackgroundApi(&memoryApi{opts: opts, domains: make(map[string]*memoryDomain)})
+}
+
+type (
+	memoryApi struct {
+		opts     MemoryOpts
+		mu       sync.Mutex
+		seq      uint64
+		domains  map[string]*memoryDomain
+		domainId []string
+	}
+	memoryDomain struct {
+		DomainListRespDomain
+		status   string
+		records  map[string]*RecordListRespRecord
+		recordId []string
+	}
+)
+
+func (a *memoryApi) nextId() string {
+	a.seq++
+	return strconv.FormatUint(a.seq, 10)
+}
+
+func (a *memoryApi) call(ctx context.Context, op Operation) (err error) {
+	if a.opts.Latency > 0 {
+		timer := time.NewTimer(a.opts.Latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	if a.opts.Fault != nil {
+		return a.opts.Fault(op)
+	}
+	return
+}
+
+func (a *memoryApi) domain(domainId, domain string) (d *memoryDomain, err error) {
+	if d, ok := a.domains[domainId]; ok {
+		return d, nil
+	}
+	for _, id := range a.domainId {
+		if d = a.domains[id]; domain != "" && d.Name == domain {
+			return d, nil
+		}
+	}
+	return nil, &Error{Kind: ErrNotFound, Provider: memoryProvider, Err: errMemoryDomainNotFound}
+}
+
+func (a *memoryApi) record(domainId, domain, recordId string) (d *memoryDomain, r *RecordListRespRecord, err error) {
+	if domainId == "" && domain == "" {
+		for _, id := range a.domainId {
+			if r, ok := a.domains[id].records[recordId]; ok {
+				return a.domains[id], r, nil
+			}
+		}
+	} else if d, err = a.domain(domainId, domain); err != nil {
+		return
+	} else if r = d.records[recordId]; r != nil {
+		return
+	}
+	return nil, nil, &Error{Kind: ErrNotFound, Provider: memoryProvider, Err: errMemoryRecordNotFound}
+}
+
+func (a *memoryApi) Capabilities() (resp Capabilities) { return memoryCapabilities }
+
+func (a *memoryApi) PingContext(ctx context.Context) (ok bool) { return a.call(ctx, OpPing) == nil }
+
+func (a *memoryApi) LineListContext(ctx context.Context, req LineListReq) (resp LineListResp, err error) {
+	if err = a.call(ctx, OpLineList); err != nil {
+		return
+	}
+	if req.DomainId != "" || req.Domain != "" {
+		a.mu.Lock()
+		_, err = a.domain(req.DomainId, req.Domain)
+		a.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+	return LineListResp{slices.Clone(memoryLines)}, nil
+}
+
+func (a *memoryApi) LineDefaultContext(_ context.Context) (resp LineListRespLine) {
+	return memoryLineDef
+}
+
+func (a *memoryApi) DomainListContext(ctx context.Context, req DomainListReq) (resp DomainListResp, err error) {
+	if err = a.call(ctx, OpDomainList); err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var list []DomainListRespDomain
+	for _, id := range a.domainId {
+		d := a.domains[id]
+		if req.Domain != "" && !strings.Contains(d.Name, req.Domain) {
+			continue
+		}
+		domain := d.DomainListRespDomain
+		domain.DnsServer = append([]string(nil), d.DnsServer...)
+		domain.RecordCount = uint(len(d.records))
+		list = append(list, domain)
+	}
+	resp.Total = uint(len(list))
+	resp.List = pageOf(list, req.Page, req.Limit)
+	return
+}
+
+func (a *memoryApi) DomainAddContext(ctx context.Context, req DomainAddReq) (resp DomainAddResp, err error) {
+	if err = a.call(ctx, OpDomainAdd); err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if req.Domain == "" {
+		err = &Error{Kind: ErrInvalidInput, Provider: memoryProvider, Err: errors.New("domain is required")}
+		return
+	}
+	if _, err0 := a.domain("", req.Domain); err0 == nil {
+		err = &Error{Kind: ErrAlreadyExists, Provider: memoryProvider, Err: errMemoryDomainExists}
+		return
+	}
+	d := &memoryDomain{
+		DomainListRespDomain: DomainListRespDomain{
+			Id:         a.nextId(),
+			Name:       req.Domain,
+			DnsServer:  []string{"ns1.dnsdk.memory", "ns2.dnsdk.memory"},
+			CreateTime: formatTime(time.Now()),
+		},
+		status:  "enable",
+		records: make(map[string]*RecordListRespRecord),
+	}
+	a.domains[d.Id] = d
+	a.domainId = append(a.domainId, d.Id)
+	resp = DomainAddResp{Id: d.Id, DnsServer: append([]string(nil), d.DnsServer...)}
+	return
+}
+
+func (a *memoryApi) DomainDeleteContext(ctx context.Context, req DomainDeleteReq) (err error) {
+	if err = a.call(ctx, OpDomainDelete); err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	d, err := a.domain(req.DomainId, req.Domain)
+	if err != nil {
+		return
+	}
+	delete(a.domains, d.Id)
+	a.domainId = removeId(a.domainId, d.Id)
+	return
+}
+
+func (a *memoryApi) DomainGetContext(ctx context.Context, req DomainGetReq) (resp DomainGetResp, err error) {
+	if err = a.call(ctx, OpDomainGet); err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	d, err := a.domain(req.DomainId, req.Domain)
+	if err != nil {
+		return
+	}
+	resp = DomainGetResp{DomainListRespDomain: d.DomainListRespDomain, Status: d.status, Grade: "free", DNSSEC: "disable"}
+	resp.DnsServer = append([]string(nil), d.DnsServer...)
+	resp.RecordCount = uint(len(d.records))
+	return
+}
+
+func (a *memoryApi) DomainEnableContext(ctx context.Context, req DomainEnableReq) (err error) {
+	return a.domainStatus(ctx, OpDomainEnable, req.DomainId, req.Domain, "enable")
+}
+
+func (a *memoryApi) DomainDisableContext(ctx context.Context, req DomainDisableReq) (err error) {
+	return a.domainStatus(ctx, OpDomainDisable, req.DomainId, req.Domain, "disable")
+}
+
+func (a *memoryApi) domainStatus(ctx context.Context, op Operation, domainId, domain, status string) (err error) {
+	if err = a.call(ctx, op); err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	d, err := a.domain(domainId, domain)
+	if err != nil {
+		return
+	}
+	d.status = status
+	return
+}
+
+func (a *memoryApi) RecordListContext(ctx context.Context, req RecordListReq) (resp RecordListResp, err error) {
+	if req.Line, err = memoryLineMap.native(req.Line, memoryCatalog); err != nil {
+		return
+	}
+	if err = a.call(ctx, OpRecordList); err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	d, err := a.domain(req.DomainId, req.Domain)
+	if err != nil {
+		return
+	}
+	list := make([]RecordListRespRecord, 0)
+	for _, id := range d.recordId {
+		r := d.records[id]
+		if (req.Record != "" && r.Record != req.Record) ||
+			(req.Type != "" && !strings.EqualFold(r.Type, req.Type)) ||
+			(req.Line != "" && r.Line != req.Line) ||
+			(req.Value != "" && !strings.Contains(r.Value, req.Value)) ||
+			(req.Remark != "" && !strings.Contains(r.Remark, req.Remark)) {
+			continue
+		}
+		rc := *r
+		rc.Tags = slices.Clone(r.Tags)
+		list = append(list, rc)
+	}
+	sortRecords(list, req.Order, req.Direction)
+	resp.Total = uint(len(list))
+	resp.List = pageOf(list, req.Page, req.Limit)
+	return
+}
+
+func (a *memoryApi) RecordAddContext(ctx context.Context, req RecordAddReq) (resp RecordAddResp, err error) {
+	if req.Line, err = memoryLineMap.native(req.Line, memoryCatalog); err != nil {
+		return
+	}
+	req.Type, req.Value = recordValue(req.Type, req.Value, req.Data)
+	if err = a.call(ctx, OpRecordAdd); err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	d, err := a.domain(req.DomainId, req.Domain)
+	if err != nil {
+		return
+	}
+	now := formatTime(time.Now())
+	r := &RecordListRespRecord{
+		Id:         a.nextId(),
+		Status:     "enable",
+		CreateTime: now,
+		UpdateTime: now,
+	}
+	memoryRecordSet(r, d.Name, req.Record, req.Type, req.Value, req.Line, req.TTL, req.MX, req.Weight, req.Remark)
+	r.Proxied, r.Tags = req.Proxied != nil && *req.Proxied, slices.Clone(req.Tags)
+	if d.duplicate(r) {
+		err = &Error{Kind: ErrAlreadyExists, Provider: memoryProvider, Err: errMemoryRecordExists}
+		return
+	}
+	d.records[r.Id] = r
+	d.recordId = append(d.recordId, r.Id)
+	resp.RecordListRespRecord = *r
+	resp.Tags = slices.Clone(r.Tags)
+	return
+}
+
+func (a *memoryApi) RecordUpdateContext(ctx context.Context, req RecordUpdateReq) (resp RecordUpdateResp, err error) {
+	if req.Line, err = memoryLineMap.native(req.Line, memoryCatalog); err != nil {
+		return
+	}
+	req.Type, req.Value = recordValue(req.Type, req.Value, req.Data)
+	if err = a.call(ctx, OpRecordUpdate); err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	d, r, err := a.record(req.DomainId, req.Domain, req.RecordId)
+	if err != nil {
+		return
+	}
+	updated := *r
+	remark := r.Remark
+	if req.Remark != nil {
+		remark = *req.Remark
+	}
+	memoryRecordSet(&updated, d.Name, req.Record, req.Type, req.Value, req.Line, req.TTL, req.MX, req.Weight, remark)
+	if req.Proxied != nil {
+		updated.Proxied = *req.Proxied
+	}
+	if req.Tags != nil {
+		updated.Tags = slices.Clone(*req.Tags)
+	}
+	updated.UpdateTime = formatTime(time.Now())
+	if d.duplicate(&updated) {
+		err = &Error{Kind: ErrAlreadyExists, Provider: memoryProvider, Err: errMemoryRecordExists}
+		return
+	}
+	*r = updated
+	resp.RecordListRespRecord = *r
+	resp.Tags = slices.Clone(r.Tags)
+	return
+}
+
+func (a *memoryApi) RecordDeleteContext(ctx context.Context, req RecordDeleteReq) (err error) {
+	if err = a.call(ctx, OpRecordDelete); err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	d, r, err := a.record(req.DomainId, "", req.RecordId)
+	if err != nil {
+		return
+	}
+	delete(d.records, r.Id)
+	d.recordId = removeId(d.recordId, r.Id)
+	return
+}
+
+func (a *memoryApi) recordStatus(ctx context.Context, op Operation, domainId, domain, recordId, status string) (err error) {
+	if err = a.call(ctx, op); err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, r, err := a.record(domainId, domain, recordId)
+	if err != nil {
+		return
+	}
+	r.Status = status
+	r.UpdateTime = formatTime(time.Now())
+	return
+}
+
+func (a *memoryApi) RecordEnableContext(ctx context.Context, req RecordEnableReq) (err error) {
+	return a.recordStatus(ctx, OpRecordEnable, req.DomainId, req.Domain, req.RecordId, "enable")
+}
+
+func (a *memoryApi) RecordDisableContext(ctx context.Context, req RecordDisableReq) (err error) {
+	return a.recordStatus(ctx, OpRecordDisable, req.DomainId, req.Domain, req.RecordId, "disable")
+}
+
+func (d *memoryDomain) duplicate(r *RecordListRespRecord) bool {
+	for _, id := range d.recordId {
+		if rr := d.records[id]; rr.Id != r.Id && rr.Record == r.Record && rr.Type == r.Type && rr.Line == r.Line && rr.Value == r.Value {
+			return true
+		}
+	}
+	return false
+}
+
+func memoryRecordSet(r *RecordListRespRecord, domain, record, typ, value, line string, ttl uint, mx uint16, weight uint, remark string) {
+	if record == "" {
+		record = "@"
+	}
+	if line == "" {
+		line = memoryLineDef.Id
+	}
+	if ttl == 0 {
+		ttl = 600
+	}
+	r.Record = record
+	r.Name = recordName(record, domain)
+	r.Type = strings.ToUpper(typ)
+	r.Value = value
+	r.MX = 0
+	if r.Type == "MX" {
+		r.MX = mxPriority(mx)
+	}
+	r.Line = line
+	r.CanonicalLine = memoryLineMap.canonical(line, memoryCatalog)
+	r.TTL = ttl
+	r.Weight = weight
+	r.Remark = remark
+}
+
+func sortRecords(list []RecordListRespRecord, order, direction string) {
+	less := func(a, b RecordListRespRecord) bool { return toUint(a.Id) < toUint(b.Id) }
+	switch strings.ToLower(order) {
+	case "record", "name":
+		less = func(a, b RecordListRespRecord) bool { return a.Record < b.Record }
+	case "type":
+		less = func(a, b RecordListRespRecord) bool { return a.Type < b.Type }
+	case "value":
+		less = func(a, b RecordListRespRecord) bool { return a.Value < b.Value }
+	case "line":
+		less = func(a, b RecordListRespRecord) bool { return a.Line < b.Line }
+	case "ttl":
+		less = func(a, b RecordListRespRecord) bool { return a.TTL < b.TTL }
+	case "updated_on", "update_time":
+		less = func(a, b RecordListRespRecord) bool { return a.UpdateTime < b.UpdateTime }
+	}
+	desc := strings.EqualFold(direction, "desc")
+	sort.SliceStable(list, func(i, j int) bool {
+		if desc {
+			return less(list[j], list[i])
+		}
+		return less(list[i], list[j])
+	})
+}
+
+func removeId(ids []string, id string) []string {
+	for i, v := range ids {
+		if v == id {
+			return append(ids[:i:i], ids[i+1:]...)
+		}
+	}
+	return ids
+}
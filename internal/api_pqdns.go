@@ -13,29 +13,67 @@ package internal
 
 import (
 	"bytes"
+	"cmp"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 )
 
-var pqdnsLineDef = LineListRespLine{"9065", "默认"}
+const (
+	pqdnsProvider        = "pqdns"
+	pqdnsTimeout         = time.Second * 10
+	pqdnsDomainPageLimit = 100
+	pqdnsRecordPageLimit = 100
+)
+
+var pqdnsLineDef = LineListRespLine{Id: "9065", Name: "默认"}
+
+// pqdnsLineMap PQDNS 未公开线路列表接口, 通用线路只能转换为其中列出的线路
+var pqdnsLineMap = lineMap{
+	{LineDefault, "9065"},
+	{LineTelecom, "4"},
+	{LineUnicom, "2971"},
+	{LineMobile, "5643"},
+	{LineOverseas, "8542"},
+}
+
+// pqdnsCapabilities ext 接口未公开 TTL 范围, 取国内免费套餐常见的 600 至 86400 秒
+var pqdnsCapabilities = Capabilities{
+	Operations:      operationsExcept(OpLineList, OpDomainEnable, OpDomainDisable, OpRecordEnable, OpRecordDisable),
+	RecordTypes:     []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS", "SRV", "CAA"},
+	TTLMin:          600,
+	TTLMax:          86400,
+	Weight:          true,
+	Line:            true,
+	MXPriority:      true,
+	DomainPageLimit: pqdnsDomainPageLimit,
+	RecordPageLimit: pqdnsRecordPageLimit,
+}
 
 func PqdnsApi(baseUrl, username, secretKey string) Api {
-	return &pqdnsApi{baseUrl, username, secretKey}
+	return BackgroundApi(&pqdnsApi{baseUrl: baseUrl, username: username, secretKey: secretKey})
 }
 
 type pqdnsApi struct{ baseUrl, username, secretKey string }
 
 func (a *pqdnsApi) getAuthUrl() string {
-	return fmt.Sprintf("user_name=%s&secret_key=%s", a.username, a.secretKey)
+	return url.Values{"user_name": {a.username}, "secret_key": {a.secretKey}}.Encode()
 }
 
-func (a *pqdnsApi) req(apiUrl, apiMethod string, reqT, respT any) (err error) {
+func (a *pqdnsApi) req(ctx context.Context, apiUrl, apiMethod string, reqT, respT any) (err error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, pqdnsTimeout)
+		defer cancel()
+	}
 	var prefix string
 	if strings.Contains(apiUrl, "?") {
 		prefix = "&"
@@ -44,7 +82,6 @@ func (a *pqdnsApi) req(apiUrl, apiMethod string, reqT, respT any) (err error) {
 	}
 	apiUrl += prefix + a.getAuthUrl()
 	reqUrl := fmt.Sprintf("%s%s", a.baseUrl, apiUrl)
-	client := &http.Client{Timeout: time.Second * 10}
 	var reader io.Reader
 	if reflect.ValueOf(reqT).IsValid() {
 		buf, err0 := json.Marshal(reqT)
@@ -53,17 +90,28 @@ func (a *pqdnsApi) req(apiUrl, apiMethod string, reqT, respT any) (err error) {
 		}
 		reader = bytes.NewBuffer(buf)
 	}
-	req, _ := http.NewRequest(apiMethod, reqUrl, reader)
+	req, err0 := http.NewRequestWithContext(ctx, apiMethod, reqUrl, reader)
+	if err0 != nil {
+		err = err0
+		return
+	}
 	req.Header = make(http.Header)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "dnsdk (https://github.com/go-the-way/dnsdk)")
-	resp, err0 := client.Do(req)
+	resp, err0 := http.DefaultClient.Do(req)
 	if err0 != nil {
-		err = err0
+		err = newError(pqdnsProvider, "", transportKind(err0), err0)
 		return
 	}
+	defer func() { _ = resp.Body.Close() }()
 	if resp.StatusCode != http.StatusOK {
-		err = errors.New(resp.Status)
+		err = &Error{
+			Kind:       statusKind(resp.StatusCode),
+			Provider:   pqdnsProvider,
+			Code:       strconv.Itoa(resp.StatusCode),
+			RetryAfter: retryAfter(resp.Header),
+			Err:        errors.New(resp.Status),
+		}
 		return
 	}
 	buf, err0 := io.ReadAll(resp.Body)
@@ -77,39 +125,36 @@ func (a *pqdnsApi) req(apiUrl, apiMethod string, reqT, respT any) (err error) {
 	return
 }
 
-func (a *pqdnsApi) Ping() (ok bool) {
-	req, _ := http.NewRequest(http.MethodGet, a.baseUrl, nil)
-	resp, _ := (&http.Client{Timeout: time.Second * 5}).Do(req)
-	return resp.StatusCode == http.StatusOK
-}
+func (a *pqdnsApi) Capabilities() (resp Capabilities) { return pqdnsCapabilities }
 
-func (a *pqdnsApi) LineList() (resp LineListResp) {
-	lines := []LineListRespLine{
-		pqdnsLineDef,
-		{"4", "电信"},
-		{"2971", "联通"},
-		{"5643", "移动"},
-		{"8542", "境外"},
-	}
-	return LineListResp{lines}
+func (a *pqdnsApi) PingContext(ctx context.Context) (ok bool) { return ping(ctx, a.baseUrl) }
+
+func (a *pqdnsApi) LineListContext(_ context.Context, _ LineListReq) (resp LineListResp, err error) {
+	return resp, ErrNotSupportedOperation
 }
 
-func (a *pqdnsApi) LineDefault() (resp LineListRespLine) { return pqdnsLineDef }
+func (a *pqdnsApi) LineDefaultContext(_ context.Context) (resp LineListRespLine) {
+	return pqdnsLineDef
+}
 
-func (a *pqdnsApi) DomainList(req DomainListReq) (resp DomainListResp, err error) {
+func (a *pqdnsApi) DomainListContext(ctx context.Context, req DomainListReq) (resp DomainListResp, err error) {
 	var rsp pqdnsDomainListResp
-	apiUrl := fmt.Sprintf("/api/ext/dns/domain?domain=%s&page=%d&limit=%d", req.Domain, req.Page, req.Limit)
-	err = a.req(apiUrl, http.MethodGet, nil, &rsp)
+	query := url.Values{"domain": {req.Domain}, "page": {strconv.FormatUint(uint64(req.Page), 10)}, "limit": {strconv.FormatUint(uint64(req.Limit), 10)}}
+	apiUrl := "/api/ext/dns/domain?" + query.Encode()
+	if err = a.req(ctx, apiUrl, http.MethodGet, nil, &rsp); err != nil {
+		return
+	}
+	resp = rsp.transform()
 	return
 }
 
-func (a *pqdnsApi) DomainAdd(req DomainAddReq) (resp DomainAddResp, err error) {
+func (a *pqdnsApi) DomainAddContext(ctx context.Context, req DomainAddReq) (resp DomainAddResp, err error) {
 	apiUrl := "/api/ext/dns/domain"
-	err = a.req(apiUrl, http.MethodPost, (&pqdnsDomainAddReq{}).transform(a.username, a.secretKey, req), &resp)
+	err = a.req(ctx, apiUrl, http.MethodPost, (&pqdnsDomainAddReq{}).transform(a.username, a.secretKey, req), &resp)
 	if err != nil {
 		return
 	}
-	domainListResp, err0 := a.DomainList(DomainListReq{Page: 1, Limit: 1, Domain: req.Domain})
+	domainListResp, err0 := a.DomainListContext(ctx, DomainListReq{Page: 1, Limit: 1, Domain: req.Domain})
 	if err0 != nil {
 		err = err0
 		return
@@ -122,44 +167,111 @@ func (a *pqdnsApi) DomainAdd(req DomainAddReq) (resp DomainAddResp, err error) {
 	return
 }
 
-func (a *pqdnsApi) DomainDelete(req DomainDeleteReq) (err error) {
+func (a *pqdnsApi) DomainDeleteContext(ctx context.Context, req DomainDeleteReq) (err error) {
 	apiUrl := "/api/ext/dns/domain"
-	err = a.req(apiUrl, http.MethodDelete, (&pqdnsDomainDeleteReq{}).transform(a.username, a.secretKey, req), nil)
+	return a.req(ctx, apiUrl, http.MethodDelete, (&pqdnsDomainDeleteReq{}).transform(a.username, a.secretKey, req), nil)
+}
+
+// DomainGetContext 接口未提供域名详情, 从域名列表中按 Id 或域名查找; 域名列表不返回状态, Status 为空
+func (a *pqdnsApi) DomainGetContext(ctx context.Context, req DomainGetReq) (resp DomainGetResp, err error) {
+	for page := uint(1); ; page++ {
+		var rsp pqdnsDomainListResp
+		query := url.Values{"domain": {req.Domain}, "page": {strconv.FormatUint(uint64(page), 10)}, "limit": {strconv.Itoa(pqdnsDomainPageLimit)}}
+		apiUrl := "/api/ext/dns/domain?" + query.Encode()
+		if err = a.req(ctx, apiUrl, http.MethodGet, nil, &rsp); err != nil {
+			return
+		}
+		for _, do0 := range rsp.List {
+			if do0.Id == req.DomainId || strings.EqualFold(do0.Name, req.Domain) {
+				return do0.transform(), nil
+			}
+		}
+		if len(rsp.List) < pqdnsDomainPageLimit || page*pqdnsDomainPageLimit >= rsp.Total {
+			break
+		}
+	}
+	err = &Error{Kind: ErrNotFound, Provider: pqdnsProvider, Err: fmt.Errorf("domain %s not found", cmp.Or(req.Domain, req.DomainId))}
 	return
 }
 
-func (a *pqdnsApi) RecordList(req RecordListReq) (resp RecordListResp, err error) {
+func (a *pqdnsApi) DomainEnableContext(_ context.Context, _ DomainEnableReq) (err error) {
+	return ErrNotSupportedOperation
+}
+
+func (a *pqdnsApi) DomainDisableContext(_ context.Context, _ DomainDisableReq) (err error) {
+	return ErrNotSupportedOperation
+}
+
+func (a *pqdnsApi) RecordListContext(ctx context.Context, req RecordListReq) (resp RecordListResp, err error) {
+	if req.Line, err = pqdnsLineMap.native(req.Line, lineCatalog(ctx, a, req.DomainId, req.Domain)); err != nil {
+		return
+	}
 	var rsp pqdnsRecordListResp
-	apiUrl := fmt.Sprintf("/api/ext/dns/record?host_record=%s&record_value=%s&line_id=%s&page=%d&limit=%d", req.Record, req.Value, req.Line, req.Page, req.Limit)
-	err = a.req(apiUrl, http.MethodGet, nil, &rsp)
+	query := url.Values{
+		"domain_id":    {req.DomainId},
+		"host_record":  {req.Record},
+		"record_value": {req.Value},
+		"line_id":      {req.Line},
+		"page":         {strconv.FormatUint(uint64(req.Page), 10)},
+		"limit":        {strconv.FormatUint(uint64(req.Limit), 10)},
+	}
+	apiUrl := "/api/ext/dns/record?" + query.Encode()
+	if err = a.req(ctx, apiUrl, http.MethodGet, nil, &rsp); err != nil {
+		return
+	}
 	resp = rsp.transform()
+	pqdnsLineMap.canonicalize(resp.List, lineCatalog(ctx, a, req.DomainId, req.Domain))
 	return
 }
 
-func (a *pqdnsApi) RecordAdd(req RecordAddReq) (resp RecordAddResp, err error) {
+func (a *pqdnsApi) RecordAddContext(ctx context.Context, req RecordAddReq) (resp RecordAddResp, err error) {
+	if req.Line, err = pqdnsLineMap.native(req.Line, lineCatalog(ctx, a, req.DomainId, req.Domain)); err != nil {
+		return
+	}
 	var rsp pqdnsRecordListResp
 	apiUrl := "/api/ext/dns/record"
-	err = a.req(apiUrl, http.MethodPost, (&pqdnsRecordAddReq{}).transform(a.username, a.secretKey, req), &rsp)
+	if err = a.req(ctx, apiUrl, http.MethodPost, (&pqdnsRecordAddReq{}).transform(a.username, a.secretKey, req), &rsp); err != nil {
+		return
+	}
+	for _, rc := range rsp.transform().List {
+		resp.RecordListRespRecord = rc
+		resp.CanonicalLine = pqdnsLineMap.canonical(rc.Line, lineCatalog(ctx, a, req.DomainId, req.Domain))
+		break
+	}
 	return
 }
 
-func (a *pqdnsApi) RecordUpdate(req RecordUpdateReq) (resp RecordUpdateResp, err error) {
+func (a *pqdnsApi) RecordUpdateContext(ctx context.Context, req RecordUpdateReq) (resp RecordUpdateResp, err error) {
+	if req.Line, err = pqdnsLineMap.native(req.Line, lineCatalog(ctx, a, req.DomainId, req.Domain)); err != nil {
+		return
+	}
 	var rsp pqdnsRecordListResp
 	apiUrl := "/api/ext/dns/record"
-	err = a.req(apiUrl, http.MethodPut, (&pqdnsRecordUpdateReq{}).transform(a.username, a.secretKey, req), &rsp)
+	if err = a.req(ctx, apiUrl, http.MethodPut, (&pqdnsRecordUpdateReq{}).transform(a.username, a.secretKey, req), &rsp); err != nil {
+		return
+	}
+	for _, rc := range rsp.transform().List {
+		resp.RecordListRespRecord = rc
+		resp.CanonicalLine = pqdnsLineMap.canonical(rc.Line, lineCatalog(ctx, a, req.DomainId, req.Domain))
+		break
+	}
 	return
 }
 
-func (a *pqdnsApi) RecordDelete(req RecordDeleteReq) (err error) {
+func (a *pqdnsApi) RecordDeleteContext(ctx context.Context, req RecordDeleteReq) (err error) {
 	var rsp pqdnsRecordListResp
 	apiUrl := "/api/ext/dns/record"
-	err = a.req(apiUrl, http.MethodDelete, (&pqdnsRecordDeleteReq{}).transform(a.username, a.secretKey, req), &rsp)
+	err = a.req(ctx, apiUrl, http.MethodDelete, (&pqdnsRecordDeleteReq{}).transform(a.username, a.secretKey, req), &rsp)
 	return
 }
 
-func (a *pqdnsApi) RecordEnable(_ RecordEnableReq) (err error) { return ErrNotSupportedOperation }
+func (a *pqdnsApi) RecordEnableContext(_ context.Context, _ RecordEnableReq) (err error) {
+	return ErrNotSupportedOperation
+}
 
-func (a *pqdnsApi) RecordDisable(_ RecordDisableReq) (err error) { return ErrNotSupportedOperation }
+func (a *pqdnsApi) RecordDisableContext(_ context.Context, _ RecordDisableReq) (err error) {
+	return ErrNotSupportedOperation
+}
 
 type (
 	pqdnsDomainListReq struct {
@@ -251,7 +363,35 @@ func (a *pqdnsDomainAddReq) transform(username, secretKey string, req DomainAddR
 }
 
 func (a *pqdnsDomainDeleteReq) transform(username, secretKey string, req DomainDeleteReq) (resp pqdnsDomainDeleteReq) {
-	return pqdnsDomainDeleteReq{username, secretKey, []uint{toUint(req.Domain)}}
+	return pqdnsDomainDeleteReq{username, secretKey, []uint{toUint(req.DomainId)}}
+}
+
+func (a *pqdnsDomainListResp) transform() (resp DomainListResp) {
+	var list []DomainListRespDomain
+	for _, do0 := range a.List {
+		list = append(list, DomainListRespDomain{
+			Id:          do0.Id,
+			Name:        do0.Name,
+			DnsServer:   do0.DnsServer,
+			RecordCount: do0.RecordCount,
+			Remark:      do0.Remark,
+			CreateTime:  do0.CreateTime,
+		})
+	}
+	return DomainListResp{Total: a.Total, List: list}
+}
+
+func (a pqdnsRecordListRespDomain) transform() (resp DomainGetResp) {
+	return DomainGetResp{
+		DomainListRespDomain: DomainListRespDomain{
+			Id:          a.Id,
+			Name:        a.Name,
+			DnsServer:   a.DnsServer,
+			RecordCount: a.RecordCount,
+			Remark:      a.Remark,
+			CreateTime:  a.CreateTime,
+		},
+	}
 }
 
 func (a *pqdnsRecordListResp) transform() (resp RecordListResp) {
@@ -260,7 +400,7 @@ func (a *pqdnsRecordListResp) transform() (resp RecordListResp) {
 		list = append(list, RecordListRespRecord{
 			Id:         fmt.Sprintf("%d", rc.Id),
 			Record:     rc.HostRecord,
-			Name:       fmt.Sprintf("%s.%s", rc.HostRecord, rc.DomainName),
+			Name:       recordName(rc.HostRecord, rc.DomainName),
 			Type:       rc.RecordType,
 			Value:      rc.RecordValue,
 			Line:       fmt.Sprintf("%d", rc.LineId),
@@ -268,13 +408,22 @@ func (a *pqdnsRecordListResp) transform() (resp RecordListResp) {
 			MX:         uint16(rc.MX),
 			Weight:     uint(rc.Weight),
 			Remark:     "", // ignored
+			Status:     pqdnsStatus(rc.Status),
 			CreateTime: formatTime(rc.CreateTime),
 			UpdateTime: formatTime(rc.UpdateTime),
 		})
 	}
 	return RecordListResp{Total: a.Total, List: list}
 }
+func pqdnsStatus(status byte) string {
+	if status == 0 {
+		return "enable"
+	}
+	return "disable"
+}
+
 func (a *pqdnsRecordAddReq) transform(username, secretKey string, req RecordAddReq) *pqdnsRecordAddReq {
+	req.Type, req.Value = recordValue(req.Type, req.Value, req.Data)
 	return &pqdnsRecordAddReq{
 		Username:  username,
 		SecretKey: secretKey,
@@ -283,13 +432,14 @@ func (a *pqdnsRecordAddReq) transform(username, secretKey string, req RecordAddR
 		RecType:   req.Type,
 		RecValue:  req.Value,
 		LineId:    toUint(req.Line),
-		MX:        1,
+		MX:        uint(mxPriority(req.MX)),
 		Weight:    req.Weight,
 		TTL:       req.TTL,
 	}
 }
 
 func (a *pqdnsRecordUpdateReq) transform(username, secretKey string, req RecordUpdateReq) *pqdnsRecordUpdateReq {
+	req.Type, req.Value = recordValue(req.Type, req.Value, req.Data)
 	return &pqdnsRecordUpdateReq{
 		Username:  username,
 		SecretKey: secretKey,
@@ -298,7 +448,7 @@ func (a *pqdnsRecordUpdateReq) transform(username, secretKey string, req RecordU
 		RecType:   req.Type,
 		RecValue:  req.Value,
 		LineId:    toUint(req.Line),
-		MX:        1,
+		MX:        uint(mxPriority(req.MX)),
 		Weight:    req.Weight,
 		TTL:       req.TTL,
 	}
@@ -12,6 +12,10 @@
 package internal
 
 type (
+	LineListReq struct {
+		DomainId string `json:"domain_id"` // 域名Id, 按域名的套餐返回可用线路 => xxxxxxxxxxxx
+		Domain   string `json:"domain"`    // 域名 => example.com
+	}
 	DomainListReq struct {
 		Page   uint   `form:"page"`   // 页码 => 1
 		Limit  uint   `form:"limit"`  // 每页数量 => 10
@@ -24,6 +28,10 @@ type (
 		Domain   string `json:"domain"`    // 域名 => example.com
 		DomainId string `json:"domain_id"` // 域名Id => xxxxxxxxxxxx
 	}
+	DomainGetReq struct {
+		Domain   string `json:"domain"`    // 域名 => example.com
+		DomainId string `json:"domain_id"` // 域名Id => xxxxxxxxxxxx
+	}
 	DomainEnableReq struct {
 		Domain   string `json:"domain"`    // 域名 => example.com
 		DomainId string `json:"domain_id"` // 域名Id => xxxxxxxxxxxx
@@ -46,29 +54,35 @@ type (
 		Direction string `form:"direction"` // 方向 => asc / desc
 	}
 	RecordAddReq struct {
-		DomainId string `json:"domain_id"` // 域名Id => xxxxxxxxxxxx
-		Domain   string `json:"domain"`    // 域名 => example.com
-		Record   string `json:"record"`    // 主机记录 => www
-		Type     string `json:"type"`      // 类型 => A
-		Value    string `json:"value"`     // 记录值 => 1.1.1.1
-		Line     string `json:"line"`      //  线路 => 0
-		TTL      uint   `json:"ttl"`       // TTL => 60
-		//	MX       uint   `json:"mx"`        // MX => only for mx type
-		Weight uint   `json:"weight"` // 权重 => 100
-		Remark string `json:"remark"` // 备注 => created by dnsdk
+		DomainId string     `json:"domain_id"`      // 域名Id => xxxxxxxxxxxx
+		Domain   string     `json:"domain"`         // 域名 => example.com
+		Record   string     `json:"record"`         // 主机记录 => www
+		Type     string     `json:"type"`           // 类型 => A
+		Value    string     `json:"value"`          // 记录值 => 1.1.1.1
+		Line     string     `json:"line"`           //  线路 => 0
+		TTL      uint       `json:"ttl"`            // TTL => 60
+		MX       uint16     `json:"mx"`             // MX优先级, 仅 MX 记录, 0 时为1 => 10
+		Weight   uint       `json:"weight"`         // 权重 => 100
+		Remark   string     `json:"remark"`         // 备注 => created by dnsdk
+		Data     RecordData `json:"data,omitempty"` // 结构化记录值, 不为空时覆盖 Type 与 Value => SRV{10, 5, 5060, "sip.example.com"}
+		Proxied  *bool      `json:"proxied"`        // 是否开启代理, 仅 Cloudflare 的 A、AAAA、CNAME 记录, nil 时不开启 => true
+		Tags     []string   `json:"tags"`           // 标签, 仅 Cloudflare => [env:prod]
 	}
 	RecordUpdateReq struct {
-		RecordId string `json:"record_id"` // 记录Id => xxxxxxxxxxxx
-		DomainId string `json:"domain_id"` // 域名Id => xxxxxxxxxxxx
-		Domain   string `json:"domain"`    // 域名 => example.com
-		Record   string `json:"record"`    // 主机记录 => www
-		Type     string `json:"type"`      // 类型 => A
-		Value    string `json:"value"`     // 记录值 => 1.1.1.1
-		Line     string `json:"line"`      //  线路 => 0
-		TTL      uint   `json:"ttl"`       // TTL => 60
-		//	MX       uint   `json:"mx"`        // MX => only for mx type
-		Weight uint   `json:"weight"` // 权重 => 100
-		Remark string `json:"remark"` // 备注 => created by dnsdk
+		RecordId string     `json:"record_id"`      // 记录Id => xxxxxxxxxxxx
+		DomainId string     `json:"domain_id"`      // 域名Id => xxxxxxxxxxxx
+		Domain   string     `json:"domain"`         // 域名 => example.com
+		Record   string     `json:"record"`         // 主机记录 => www
+		Type     string     `json:"type"`           // 类型 => A
+		Value    string     `json:"value"`          // 记录值 => 1.1.1.1
+		Line     string     `json:"line"`           //  线路 => 0
+		TTL      uint       `json:"ttl"`            // TTL => 60
+		MX       uint16     `json:"mx"`             // MX优先级, 仅 MX 记录, 0 时为1 => 10
+		Weight   uint       `json:"weight"`         // 权重 => 100
+		Remark   *string    `json:"remark"`         // 备注, nil 时不修改, 空字符串清空 => created by dnsdk
+		Data     RecordData `json:"data,omitempty"` // 结构化记录值, 不为空时覆盖 Type 与 Value => SRV{10, 5, 5060, "sip.example.com"}
+		Proxied  *bool      `json:"proxied"`        // 是否开启代理, 仅 Cloudflare 的 A、AAAA、CNAME 记录, nil 时不修改 => true
+		Tags     *[]string  `json:"tags"`           // 标签, 覆盖原有标签, nil 时不修改, 空切片清空, 仅 Cloudflare => [env:prod]
 	}
 	RecordDeleteReq struct {
 		RecordId string `json:"record_id"` // 记录Id => xxxxxxxxxxxx
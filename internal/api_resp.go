@@ -16,8 +16,9 @@ type (
 		List []LineListRespLine `json:"list"`
 	}
 	LineListRespLine struct {
-		Id   string `json:"id"`   // 线路id
-		Name string `json:"name"` // 线路名称
+		Id     string `json:"id"`     // 线路id
+		Name   string `json:"name"`   // 线路名称
+		Parent string `json:"parent"` // 上级线路id, 顶级线路为空 => telecom
 	}
 	DomainListResp struct {
 		Total uint                   `json:"total"`
@@ -31,6 +32,12 @@ type (
 		Remark      string   `json:"remark"`       // 备注
 		CreateTime  string   `json:"create_time"`  // 创建时间 => 2022-09-27 08:09:25
 	}
+	DomainGetResp struct {
+		DomainListRespDomain
+		Status string `json:"status"` // 状态, 为空表示服务商不返回 (Alidns、PQDNS) => enable / disable
+		Grade  string `json:"grade"`  // 套餐 => DP_Free
+		DNSSEC string `json:"dnssec"` // DNSSEC 状态, 为空表示未知 => enable / disable
+	}
 	DomainAddResp struct {
 		Id        string   `json:"id"`         // 域名id => xxxxxxxxxxxx
 		DnsServer []string `json:"dns_server"` // DNS服务器 => [ns1.com, ns2.com]
@@ -40,19 +47,22 @@ type (
 		List  []RecordListRespRecord `json:"list"`
 	}
 	RecordListRespRecord struct {
-		Id         string `json:"id"`          // id => xxxxxxxxxxxx
-		Record     string `json:"record"`      // 主机记录 => www
-		Name       string `json:"name"`        // 名称 => www.example.com
-		Type       string `json:"type"`        // 类型 => A
-		Value      string `json:"value"`       // 记录值 => 1.1.1.1
-		Line       string `json:"line"`        // 线路 => default
-		TTL        uint   `json:"ttl"`         // TTL => 60
-		MX         uint16 `json:"mx"`          // MX => 1
-		Weight     uint   `json:"weight"`      // 权重 => 5
-		Remark     string `json:"remark"`      // 备注
-		Status     string `json:"status"`      // 状态
-		CreateTime string `json:"create_time"` // 创建时间 => 2022-09-27 08:09:25
-		UpdateTime string `json:"update_time"` // 修改时间 => 2022-09-27 08:09:25
+		Id            string   `json:"id"`             // id => xxxxxxxxxxxx
+		Record        string   `json:"record"`         // 主机记录 => www
+		Name          string   `json:"name"`           // 名称 => www.example.com
+		Type          string   `json:"type"`           // 类型 => A
+		Value         string   `json:"value"`          // 记录值 => 1.1.1.1
+		Line          string   `json:"line"`           // 线路 => default
+		CanonicalLine Line     `json:"canonical_line"` // 通用线路, 无对应时为空 => telecom
+		TTL           uint     `json:"ttl"`            // TTL => 60
+		MX            uint16   `json:"mx"`             // MX => 1
+		Weight        uint     `json:"weight"`         // 权重 => 5
+		Remark        string   `json:"remark"`         // 备注
+		Proxied       bool     `json:"proxied"`        // 是否开启代理, 仅 Cloudflare
+		Tags          []string `json:"tags"`           // 标签, 仅 Cloudflare => [env:prod]
+		Status        string   `json:"status"`         // 状态
+		CreateTime    string   `json:"create_time"`    // 创建时间 => 2022-09-27 08:09:25
+		UpdateTime    string   `json:"update_time"`    // 修改时间 => 2022-09-27 08:09:25
 	}
 	RecordAddResp    struct{ RecordListRespRecord }
 	RecordUpdateResp RecordAddResp
@@ -0,0 +1,259 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultCacheTTL = time.Minute
+
+var cacheGen atomic.Uint64
+
+type (
+	// CacheStore 缓存存储, 可替换为 Redis 等共享存储; ttl 为0表示不过期
+	CacheStore interface {
+		Get(key string) (value []byte, ok bool)
+		Set(key string, value []byte, ttl time.Duration)
+	}
+
+	CacheOpts struct {
+		TTL        time.Duration               // 缓存时长, 默认1分钟
+		Operations map[Operation]time.Duration // 按操作覆盖缓存时长, 0表示不缓存 => {RecordList: 10s}
+		Store      CacheStore                  // 存储, 默认为内存
+		Prefix     string                      // 键前缀, 多个账号共用存储时区分 => dnspod:AKIDxxxx
+	}
+
+	cache struct {
+		CacheOpts
+		mu      sync.Mutex
+		aliases map[string]string // 域名Id <=> 域名
+	}
+
+	memoryCacheStore struct {
+		mu      sync.Mutex
+		entries map[string]memoryCacheEntry
+		sets    uint
+	}
+
+	memoryCacheEntry struct {
+		value   []byte
+		expires time.Time
+	}
+)
+
+// Cache 返回缓存拦截器, 缓存 LineList、LineDefault、DomainList 与 RecordList 的成功响应, 键为完整请求;
+// 域名添加删除与启停使域名列表失效, 记录增删改与启停使所属域名的记录列表失效, 无法确定域名时使全部记录列表失效
+func Cache(opts CacheOpts) Interceptor {
+	if opts.TTL <= 0 {
+		opts.TTL = defaultCacheTTL
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryCacheStore()
+	}
+	c := &cache{CacheOpts: opts, aliases: make(map[string]string)}
+	return c.intercept
+}
+
+func (c *cache) intercept(ctx context.Context, op Operation, req any, next Invoker) (resp any, err error) {
+	var key string
+	switch op {
+	case OpLineList, OpLineDefault:
+		key = string(op)
+	case OpDomainList:
+		key = string(op) + ":" + c.gen("domains")
+	case OpRecordList:
+		r := reqOf[RecordListReq](req)
+		c.alias(r.DomainId, r.Domain)
+		key = string(op) + ":" + c.gen("records") + ":" + c.gen(c.tags(r.DomainId, r.Domain)...)
+	default:
+		if resp, err = next(ctx, op, req); err == nil {
+			c.invalidate(op, req)
+		}
+		return
+	}
+	ttl, ok := c.Operations[op]
+	if !ok {
+		ttl = c.TTL
+	}
+	if ttl <= 0 {
+		return next(ctx, op, req)
+	}
+	buf, _ := json.Marshal(req)
+	key = c.Prefix + ":" + key + ":" + string(buf)
+	if value, ok := c.Store.Get(key); ok {
+		if resp, ok = cacheDecode(op, value); ok {
+			return resp, nil
+		}
+	}
+	if resp, err = next(ctx, op, req); err != nil {
+		return
+	}
+	if op == OpDomainList {
+		for _, d := range reqOf[DomainListResp](resp).List {
+			c.alias(d.Id, d.Name)
+		}
+	}
+	if value, err0 := json.Marshal(resp); err0 == nil {
+		c.Store.Set(key, value, ttl)
+	}
+	return
+}
+
+func (c *cache) invalidate(op Operation, req any) {
+	var domainId, domain string
+	switch r := req.(type) {
+	case DomainAddReq:
+		c.bump("domains")
+		return
+	case DomainDeleteReq:
+		c.bump("domains")
+		domainId, domain = r.DomainId, r.Domain
+	case DomainEnableReq, DomainDisableReq:
+		c.bump("domains")
+		return
+	case RecordAddReq:
+		domainId, domain = r.DomainId, r.Domain
+	case RecordUpdateReq:
+		domainId, domain = r.DomainId, r.Domain
+	case RecordDeleteReq:
+		domainId = r.DomainId
+	case RecordEnableReq:
+		domainId, domain = r.DomainId, r.Domain
+	case RecordDisableReq:
+		domainId, domain = r.DomainId, r.Domain
+	default:
+		return
+	}
+	if tags := c.tags(domainId, domain); len(tags) > 0 {
+		c.bump(tags...)
+	} else {
+		c.bump("records")
+	}
+}
+
+// tags 返回域名的缓存标签, 包含已知的域名Id与域名
+func (c *cache) tags(domainId, domain string) (tags []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	domain = strings.ToLower(domain)
+	if domainId == "" {
+		domainId = c.aliases["name:"+domain]
+	}
+	if domain == "" {
+		domain = c.aliases["id:"+domainId]
+	}
+	if domainId != "" {
+		tags = append(tags, "records:id:"+domainId)
+	}
+	if domain != "" {
+		tags = append(tags, "records:name:"+domain)
+	}
+	return
+}
+
+func (c *cache) alias(domainId, domain string) {
+	if domainId == "" || domain == "" {
+		return
+	}
+	domain = strings.ToLower(domain)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.aliases["id:"+domainId] = domain
+	c.aliases["name:"+domain] = domainId
+}
+
+// gen 返回标签的当前版本, 版本变化后旧的缓存键不再命中
+func (c *cache) gen(tags ...string) string {
+	var gens []string
+	for _, tag := range tags {
+		value, _ := c.Store.Get(c.Prefix + ":gen:" + tag)
+		gens = append(gens, string(value))
+	}
+	return strings.Join(gens, ",")
+}
+
+func (c *cache) bump(tags ...string) {
+	gen := strconv.FormatInt(time.Now().UnixNano(), 36) + "." + strconv.FormatUint(cacheGen.Add(1), 36)
+	for _, tag := range tags {
+		c.Store.Set(c.Prefix+":gen:"+tag, []byte(gen), 0)
+	}
+}
+
+func cacheDecode(op Operation, value []byte) (resp any, ok bool) {
+	switch op {
+	case OpLineList:
+		return cacheUnmarshal[LineListResp](value)
+	case OpLineDefault:
+		return cacheUnmarshal[LineListRespLine](value)
+	case OpDomainList:
+		return cacheUnmarshal[DomainListResp](value)
+	case OpRecordList:
+		return cacheUnmarshal[RecordListResp](value)
+	}
+	return nil, false
+}
+
+func cacheUnmarshal[T any](value []byte) (resp any, ok bool) {
+	var t T
+	if err := json.Unmarshal(value, &t); err != nil {
+		return nil, false
+	}
+	return t, true
+}
+
+// NewMemoryCacheStore 返回内存存储, 过期的条目在读取时或定期清理
+func NewMemoryCacheStore() CacheStore {
+	return &memoryCacheStore{entries: make(map[string]memoryCacheEntry)}
+}
+
+func (s *memoryCacheStore) Get(key string) (value []byte, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if e.expired(time.Now()) {
+		delete(s.entries, key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (s *memoryCacheStore) Set(key string, value []byte, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := memoryCacheEntry{value: value}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	s.entries[key] = e
+	if s.sets++; s.sets%1024 == 0 {
+		now := time.Now()
+		for k, e := range s.entries {
+			if e.expired(now) {
+				delete(s.entries, k)
+			}
+		}
+	}
+}
+
+func (e memoryCacheEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// countingApi 返回统计各操作调用次数的内存实现, fail 不为空时对应操作返回错误
+func countingApi(fail map[Operation]bool) (api Api, calls map[Operation]int) {
+	calls = make(map[Operation]int)
+	api = MemoryApi(MemoryOpts{Fault: func(op Operation) error {
+		calls[op]++
+		if fail[op] {
+			return errors.New("fault")
+		}
+		return nil
+	}})
+	return
+}
+
+func TestCacheRecordInvalidation(t *testing.T) {
+	base, calls := countingApi(nil)
+	api := Wrap(base, Cache(CacheOpts{}))
+	a, _ := api.DomainAdd(DomainAddReq{Domain: "a.com"})
+	b, _ := api.DomainAdd(DomainAddReq{Domain: "b.com"})
+	list := func(req RecordListReq) {
+		if _, err := api.RecordList(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	steps := []struct {
+		name string
+		do   func()
+		want int // RecordList 的累计调用次数
+	}{
+		{"miss a by id", func() { list(RecordListReq{DomainId: a.Id, Domain: "a.com"}) }, 1},
+		{"hit a by id", func() { list(RecordListReq{DomainId: a.Id, Domain: "a.com"}) }, 1},
+		{"miss b", func() { list(RecordListReq{DomainId: b.Id}) }, 2},
+		{"miss a by name", func() { list(RecordListReq{Domain: "a.com"}) }, 3},
+		{"add to a by name", func() {
+			_, _ = api.RecordAdd(RecordAddReq{Domain: "a.com", Record: "www", Type: "A", Value: "192.0.2.1", TTL: 600})
+		}, 3},
+		{"a by id invalidated", func() { list(RecordListReq{DomainId: a.Id, Domain: "a.com"}) }, 4},
+		{"a by name invalidated", func() { list(RecordListReq{Domain: "a.com"}) }, 5},
+		{"b still cached", func() { list(RecordListReq{DomainId: b.Id}) }, 5},
+		{"delete by id only", func() {
+			resp, _ := base.RecordList(RecordListReq{DomainId: a.Id})
+			calls[OpRecordList]--
+			_ = api.RecordDelete(RecordDeleteReq{DomainId: a.Id, RecordId: resp.List[0].Id})
+		}, 5},
+		{"a invalidated by delete", func() { list(RecordListReq{DomainId: a.Id, Domain: "a.com"}) }, 6},
+		{"b cached after delete", func() { list(RecordListReq{DomainId: b.Id}) }, 6},
+	}
+	for _, s := range steps {
+		s.do()
+		if calls[OpRecordList] != s.want {
+			t.Fatalf("%s: RecordList calls = %d, want %d", s.name, calls[OpRecordList], s.want)
+		}
+	}
+}
+
+func TestCacheDomainInvalidation(t *testing.T) {
+	base, calls := countingApi(nil)
+	api := Wrap(base, Cache(CacheOpts{}))
+	for i, step := range []struct {
+		do   func() error
+		want int
+	}{
+		{func() error { _, err := api.DomainList(DomainListReq{}); return err }, 1},
+		{func() error { _, err := api.DomainList(DomainListReq{}); return err }, 1},
+		{func() error { _, err := api.DomainAdd(DomainAddReq{Domain: testDomain}); return err }, 1},
+		{func() error { _, err := api.DomainList(DomainListReq{}); return err }, 2},
+		{func() error { return api.DomainDisable(DomainDisableReq{Domain: testDomain}) }, 2},
+		{func() error { _, err := api.DomainList(DomainListReq{}); return err }, 3},
+		{func() error { return api.DomainDelete(DomainDeleteReq{Domain: testDomain}) }, 3},
+		{func() error { _, err := api.DomainList(DomainListReq{}); return err }, 4},
+	} {
+		if err := step.do(); err != nil {
+			t.Fatal(err)
+		}
+		if calls[OpDomainList] != step.want {
+			t.Fatalf("step %d: DomainList calls = %d, want %d", i, calls[OpDomainList], step.want)
+		}
+	}
+}
+
+func TestCacheOpts(t *testing.T) {
+	base, calls := countingApi(map[Operation]bool{OpDomainList: true})
+	store := NewMemoryCacheStore()
+	api := Wrap(base, Cache(CacheOpts{TTL: time.Millisecond * 20, Operations: map[Operation]time.Duration{OpLineList: 0}, Store: store}))
+	for range 2 {
+		_, _ = api.LineList(LineListReq{})
+		_, _ = api.DomainList(DomainListReq{})
+		_ = api.LineDefault()
+	}
+	if calls[OpLineList] != 2 {
+		t.Fatalf("LineList calls = %d, want 2 (caching disabled)", calls[OpLineList])
+	}
+	if calls[OpDomainList] != 2 {
+		t.Fatalf("DomainList calls = %d, want 2 (errors are not cached)", calls[OpDomainList])
+	}
+
+	api = Wrap(base, Cache(CacheOpts{TTL: time.Millisecond * 20, Store: store, Prefix: "ttl"}))
+	resp, _ := api.LineList(LineListReq{})
+	_, _ = api.LineList(LineListReq{})
+	if calls[OpLineList] != 3 || len(resp.List) == 0 {
+		t.Fatalf("LineList calls = %d, want a cached response", calls[OpLineList])
+	}
+	time.Sleep(time.Millisecond * 30)
+	_, _ = api.LineList(LineListReq{})
+	if calls[OpLineList] != 4 {
+		t.Fatalf("LineList calls = %d, want a miss after TTL", calls[OpLineList])
+	}
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	s := NewMemoryCacheStore()
+	s.Set("forever", []byte("1"), 0)
+	s.Set("short", []byte("2"), time.Millisecond)
+	time.Sleep(time.Millisecond * 5)
+	if v, ok := s.Get("forever"); !ok || string(v) != "1" {
+		t.Fatalf("forever = %q %v", v, ok)
+	}
+	if _, ok := s.Get("short"); ok {
+		t.Fatal("short should have expired")
+	}
+}
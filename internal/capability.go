@@ -0,0 +1,99 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import "strings"
+
+const (
+	OpPing          Operation = "Ping"
+	OpLineList      Operation = "LineList"
+	OpLineDefault   Operation = "LineDefault"
+	OpDomainList    Operation = "DomainList"
+	OpDomainAdd     Operation = "DomainAdd"
+	OpDomainDelete  Operation = "DomainDelete"
+	OpDomainGet     Operation = "DomainGet"
+	OpDomainEnable  Operation = "DomainEnable"
+	OpDomainDisable Operation = "DomainDisable"
+	OpRecordList    Operation = "RecordList"
+	OpRecordAdd     Operation = "RecordAdd"
+	OpRecordUpdate  Operation = "RecordUpdate"
+	OpRecordDelete  Operation = "RecordDelete"
+	OpRecordEnable  Operation = "RecordEnable"
+	OpRecordDisable Operation = "RecordDisable"
+)
+
+var operations = []Operation{
+	OpPing,
+	OpLineList,
+	OpLineDefault,
+	OpDomainList,
+	OpDomainAdd,
+	OpDomainDelete,
+	OpDomainGet,
+	OpDomainEnable,
+	OpDomainDisable,
+	OpRecordList,
+	OpRecordAdd,
+	OpRecordUpdate,
+	OpRecordDelete,
+	OpRecordEnable,
+	OpRecordDisable,
+}
+
+type (
+	Operation string // 操作 => RecordAdd
+
+	Capabilities struct {
+		Operations      []Operation `json:"operations"`        // 支持的操作 => [DomainList, RecordAdd]
+		RecordTypes     []string    `json:"record_types"`      // 支持的记录类型 => [A, AAAA, CNAME]
+		TTLMin          uint        `json:"ttl_min"`           // TTL最小值, 0表示未知 => 600
+		TTLMax          uint        `json:"ttl_max"`           // TTL最大值, 0表示未知 => 86400
+		Weight          bool        `json:"weight"`            // 是否支持权重, 为 false 时 RecordAdd/RecordUpdate 忽略 Weight (如 Alidns)
+		Remark          bool        `json:"remark"`            // 是否支持备注, 为 false 时 RecordAdd/RecordUpdate 忽略 Remark (如 Alidns)
+		Line            bool        `json:"line"`              // 是否支持线路
+		MXPriority      bool        `json:"mx_priority"`       // 是否支持MX优先级
+		Proxied         bool        `json:"proxied"`           // 是否支持代理(Cloudflare 橙色云朵)
+		Tags            bool        `json:"tags"`              // 是否支持记录标签
+		ApexCNAME       bool        `json:"apex_cname"`        // 是否支持主机记录 @ 的 CNAME(CNAME 拉平)
+		DomainPageLimit uint        `json:"domain_page_limit"` // 域名列表每页最大数量, 0表示未知 => 100
+		RecordPageLimit uint        `json:"record_page_limit"` // 记录列表每页最大数量, 0表示未知 => 500
+		QPS             float64     `json:"qps"`               // 账号每秒请求数上限, 0表示未知 => 20
+	}
+)
+
+func (c Capabilities) Supports(op Operation) bool {
+	for _, o := range c.Operations {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Capabilities) SupportsRecordType(typ string) bool {
+	for _, t := range c.RecordTypes {
+		if strings.EqualFold(t, typ) {
+			return true
+		}
+	}
+	return false
+}
+
+func operationsExcept(excludes ...Operation) []Operation {
+	var ops []Operation
+	for _, op := range operations {
+		if !(Capabilities{Operations: excludes}).Supports(op) {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
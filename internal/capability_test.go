@@ -0,0 +1,52 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	tests := []struct {
+		name                             string
+		caps                             Capabilities
+		ttlMin, ttlMax                   uint
+		domainPageLimit, recordPageLimit uint
+		unsupported                      []Operation
+	}{
+		{"alidns", alidnsCapabilities, 1, 86400, 100, 500, []Operation{OpDomainEnable, OpDomainDisable}},
+		{"dnspod", dnspodCapabilities, 1, 604800, 3000, 3000, nil},
+		{"cloudflare", cloudflareCapabilities, 60, 86400, 50, 5000000, []Operation{OpDomainEnable, OpDomainDisable, OpRecordEnable, OpRecordDisable}},
+		{"pqdns", pqdnsCapabilities, 600, 86400, 100, 100, []Operation{OpLineList, OpDomainEnable, OpDomainDisable, OpRecordEnable, OpRecordDisable}},
+		{"memory", memoryCapabilities, 1, 604800, 100, 500, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.caps
+			if c.TTLMin != tt.ttlMin || c.TTLMax != tt.ttlMax {
+				t.Errorf("TTL = [%d, %d], want [%d, %d]", c.TTLMin, c.TTLMax, tt.ttlMin, tt.ttlMax)
+			}
+			if c.DomainPageLimit != tt.domainPageLimit || c.RecordPageLimit != tt.recordPageLimit {
+				t.Errorf("page limit = %d/%d, want %d/%d", c.DomainPageLimit, c.RecordPageLimit, tt.domainPageLimit, tt.recordPageLimit)
+			}
+			for _, op := range operations {
+				if got := c.Supports(op); got == slices.Contains(tt.unsupported, op) {
+					t.Errorf("Supports(%s) = %v", op, got)
+				}
+			}
+			if len(c.RecordTypes) == 0 || !c.SupportsRecordType("a") || c.SupportsRecordType("UNKNOWN") {
+				t.Errorf("record types = %v", c.RecordTypes)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ErrUnknown             ErrorKind = "unknown"              // 未分类
+	ErrNotFound            ErrorKind = "not found"            // 不存在
+	ErrAlreadyExists       ErrorKind = "already exists"       // 已存在
+	ErrUnauthorized        ErrorKind = "unauthorized"         // 未授权
+	ErrRateLimited         ErrorKind = "rate limited"         // 限流
+	ErrInvalidInput        ErrorKind = "invalid input"        // 参数错误
+	ErrConflict            ErrorKind = "conflict"             // 冲突
+	ErrUnsupported         ErrorKind = "unsupported"          // 不支持
+	ErrProviderUnavailable ErrorKind = "provider unavailable" // 服务不可用
+)
+
+var ErrNotSupportedOperation = &Error{Kind: ErrUnsupported, Err: errors.New("不支持的操作")}
+
+// ErrorKind 错误分类, 可用于 errors.Is(err, ErrNotFound)
+type ErrorKind string
+
+func (k ErrorKind) Error() string { return string(k) }
+
+// Error 服务商无关的错误, 包装服务商原始错误
+type Error struct {
+	Kind       ErrorKind     // 分类 => not found
+	Provider   string        // 服务商 => alidns
+	Code       string        // 服务商错误码 => DomainRecordDuplicate
+	RetryAfter time.Duration // 服务商建议的重试等待时长, 0表示未知 => 1s
+	Err        error         // 原始错误
+}
+
+func (e *Error) Error() string {
+	msg := string(e.Kind)
+	if e.Err != nil {
+		msg = e.Err.Error()
+	}
+	if e.Provider != "" {
+		msg = e.Provider + ": " + msg
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+func (e *Error) Is(target error) bool {
+	kind, ok := target.(ErrorKind)
+	return ok && kind == e.Kind
+}
+
+// KindOf 返回 err 的分类, 未分类时返回 ErrUnknown
+func KindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	var kind ErrorKind
+	if errors.As(err, &kind) {
+		return kind
+	}
+	return ErrUnknown
+}
+
+func newError(provider, code string, kind ErrorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return &Error{Kind: kind, Provider: provider, Code: code, Err: err}
+}
+
+// retryAfter 解析 Retry-After 响应头, 支持秒数与 HTTP 日期
+func retryAfter(h http.Header) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && time.Until(t) > 0 {
+		return time.Until(t)
+	}
+	return 0
+}
+
+func transportKind(err error) ErrorKind {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrProviderUnavailable
+	}
+	return ErrUnknown
+}
+
+func statusKind(statusCode int) ErrorKind {
+	switch {
+	case statusCode == http.StatusNotFound:
+		return ErrNotFound
+	case statusCode == http.StatusConflict:
+		return ErrConflict
+	case statusCode == http.StatusUnauthorized, statusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode >= http.StatusInternalServerError:
+		return ErrProviderUnavailable
+	case statusCode >= http.StatusBadRequest:
+		return ErrInvalidInput
+	}
+	return ErrUnknown
+}
+
+type codePrefix struct {
+	prefix string
+	kind   ErrorKind
+}
+
+// codeKind 按错误码映射分类, 先精确匹配 codes, 再按 prefixes 前缀匹配
+func codeKind(code string, codes map[string]ErrorKind, prefixes []codePrefix) ErrorKind {
+	if kind, ok := codes[code]; ok {
+		return kind
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(code, p.prefix) {
+			return p.kind
+		}
+	}
+	return ErrUnknown
+}
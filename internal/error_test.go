@@ -0,0 +1,233 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alibabacloud-go/tea/tea"
+	"github.com/cloudflare/cloudflare-go"
+	dnspodErrors "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+)
+
+var errorKinds = []ErrorKind{ErrUnknown, ErrNotFound, ErrAlreadyExists, ErrUnauthorized, ErrRateLimited,
+	ErrInvalidInput, ErrConflict, ErrUnsupported, ErrProviderUnavailable}
+
+func TestNewError(t *testing.T) {
+	if newError("alidns", "", ErrNotFound, nil) != nil {
+		t.Fatal("nil error should stay nil")
+	}
+	for _, err := range []error{context.Canceled, fmt.Errorf("list: %w", context.DeadlineExceeded)} {
+		if got := newError("alidns", "", ErrUnknown, err); got != err {
+			t.Fatalf("newError(%v) = %v, want it unchanged", err, got)
+		}
+	}
+	classified := &Error{Kind: ErrConflict, Provider: "dnspod"}
+	if got := newError("alidns", "", ErrNotFound, fmt.Errorf("wrap: %w", classified)); KindOf(got) != ErrConflict {
+		t.Fatalf("kind = %v, want the wrapped kind kept", KindOf(got))
+	}
+	raw := errors.New("record exists")
+	err := newError("alidns", "DomainRecordDuplicate", ErrAlreadyExists, raw)
+	var e *Error
+	if !errors.As(err, &e) || e.Provider != "alidns" || e.Code != "DomainRecordDuplicate" || !errors.Is(err, raw) {
+		t.Fatalf("err = %#v", err)
+	}
+	if err.Error() != "alidns: record exists" {
+		t.Fatalf("Error() = %q", err.Error())
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	for _, kind := range errorKinds {
+		err := fmt.Errorf("wrap: %w", &Error{Kind: kind, Err: errors.New("boom")})
+		for _, target := range errorKinds {
+			if got := errors.Is(err, target); got != (kind == target) {
+				t.Errorf("errors.Is(%s, %s) = %v", kind, target, got)
+			}
+		}
+		if got := KindOf(err); got != kind {
+			t.Errorf("KindOf(%s) = %s", kind, got)
+		}
+		if got := KindOf(kind); got != kind {
+			t.Errorf("KindOf(bare %s) = %s", kind, got)
+		}
+	}
+	if !errors.Is(ErrNotSupportedOperation, ErrUnsupported) {
+		t.Fatal("ErrNotSupportedOperation should be ErrUnsupported")
+	}
+	for _, err := range []error{nil, errors.New("boom")} {
+		if got := KindOf(err); got != ErrUnknown {
+			t.Fatalf("KindOf(%v) = %s, want unknown", err, got)
+		}
+	}
+}
+
+func TestStatusKind(t *testing.T) {
+	tests := []struct {
+		status int
+		want   ErrorKind
+	}{
+		{http.StatusOK, ErrUnknown},
+		{http.StatusBadRequest, ErrInvalidInput},
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrUnauthorized},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusConflict, ErrConflict},
+		{http.StatusUnprocessableEntity, ErrInvalidInput},
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusInternalServerError, ErrProviderUnavailable},
+		{http.StatusServiceUnavailable, ErrProviderUnavailable},
+	}
+	for _, tt := range tests {
+		if got := statusKind(tt.status); got != tt.want {
+			t.Errorf("statusKind(%d) = %s, want %s", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestCodeKind(t *testing.T) {
+	codes := map[string]ErrorKind{"InvalidDomainName.NoExist": ErrNotFound}
+	prefixes := []codePrefix{{"Throttling", ErrRateLimited}, {"Invalid", ErrInvalidInput}}
+	tests := []struct {
+		code string
+		want ErrorKind
+	}{
+		{"InvalidDomainName.NoExist", ErrNotFound}, // 精确匹配优先于前缀
+		{"InvalidParameter", ErrInvalidInput},
+		{"Throttling.User", ErrRateLimited},
+		{"Other", ErrUnknown},
+		{"", ErrUnknown},
+	}
+	for _, tt := range tests {
+		if got := codeKind(tt.code, codes, prefixes); got != tt.want {
+			t.Errorf("codeKind(%q) = %s, want %s", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	tests := []struct {
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"", 0, 0},
+		{"3", time.Second * 3, time.Second * 3},
+		{"0", 0, 0},
+		{"-1", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), time.Second * 58, time.Minute},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.value != "" {
+			h.Set("Retry-After", tt.value)
+		}
+		if got := retryAfter(h); got < tt.min || got > tt.max {
+			t.Errorf("retryAfter(%q) = %s, want [%s, %s]", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestProviderErrors(t *testing.T) {
+	alidnsErr := func(code string, status int) error {
+		return tea.NewSDKError(map[string]any{"code": code, "message": code, "data": map[string]any{"statusCode": status}})
+	}
+	// cloudflare-go 按状态码设置 Type 并返回指针
+	cfErr := func(code int, typ cloudflare.ErrorType) *cloudflare.Error {
+		return &cloudflare.Error{Type: typ, ErrorCodes: []int{code}, Errors: []cloudflare.ResponseInfo{{Code: code}}}
+	}
+	notFound := cloudflare.NewNotFoundError(cfErr(1, cloudflare.ErrorTypeNotFound))
+	unauthorized := cloudflare.NewAuthorizationError(cfErr(2, cloudflare.ErrorTypeAuthorization))
+	request := cloudflare.NewRequestError(cfErr(81057, cloudflare.ErrorTypeRequest))
+	tests := []struct {
+		name     string
+		mapper   func(error) error
+		err      error
+		provider string
+		code     string
+		want     ErrorKind
+	}{
+		{"alidns code", alidnsError, alidnsErr("DomainRecordDuplicate", 400), "alidns", "DomainRecordDuplicate", ErrAlreadyExists},
+		{"alidns prefix", alidnsError, alidnsErr("Throttling.User", 400), "alidns", "Throttling.User", ErrRateLimited},
+		{"alidns status", alidnsError, alidnsErr("Unmapped", 503), "alidns", "Unmapped", ErrProviderUnavailable},
+		{"alidns transport", alidnsError, &net.OpError{Op: "dial", Err: errors.New("refused")}, "alidns", "", ErrProviderUnavailable},
+		{"dnspod code", dnspodError, dnspodErrors.NewTencentCloudSDKError("FailedOperation.DomainExists", "exists", "r"), "dnspod", "FailedOperation.DomainExists", ErrAlreadyExists},
+		{"dnspod prefix", dnspodError, dnspodErrors.NewTencentCloudSDKError("ResourceNotFound.NoDataOfRecord", "missing", "r"), "dnspod", "ResourceNotFound.NoDataOfRecord", ErrNotFound},
+		{"dnspod unknown", dnspodError, dnspodErrors.NewTencentCloudSDKError("FailedOperation", "failed", "r"), "dnspod", "FailedOperation", ErrUnknown},
+		{"cloudflare code", cloudflareError, &request, "cloudflare", "81057", ErrAlreadyExists},
+		{"cloudflare type", cloudflareError, &notFound, "cloudflare", "1", ErrNotFound},
+		{"cloudflare auth", cloudflareError, &unauthorized, "cloudflare", "2", ErrUnauthorized},
+		{"cloudflare retries", cloudflareError, errors.New("exceeded available rate limit retries"), "cloudflare", "", ErrRateLimited},
+		{"cloudflare 5xx", cloudflareError, errors.New("received service unavailable response (HTTP 503), please try again later"), "cloudflare", "", ErrProviderUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.mapper(tt.err)
+			var e *Error
+			if !errors.As(err, &e) || e.Kind != tt.want || e.Provider != tt.provider || e.Code != tt.code {
+				t.Fatalf("err = %#v, want %s %s %s", err, tt.provider, tt.code, tt.want)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Fatal("original error should be wrapped")
+			}
+		})
+	}
+	for _, mapper := range []func(error) error{alidnsError, dnspodError, cloudflareError} {
+		if mapper(nil) != nil {
+			t.Fatal("nil error should stay nil")
+		}
+	}
+}
+
+func TestPqdnsError(t *testing.T) {
+	var status int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	api := PqdnsApi(srv.URL, "user", "secret")
+	for _, status = range []int{http.StatusNotFound, http.StatusTooManyRequests, http.StatusBadGateway} {
+		_, err := api.DomainList(DomainListReq{})
+		var e *Error
+		if !errors.As(err, &e) || e.Kind != statusKind(status) || e.Provider != "pqdns" || e.Code != fmt.Sprint(status) || e.RetryAfter != time.Second*5 {
+			t.Fatalf("status %d: err = %#v", status, err)
+		}
+	}
+}
+
+func TestCloudflareRetryAfter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	cApi, err := cloudflare.New("key", "dnsdk@example.com", cloudflare.BaseURL(srv.URL),
+		cloudflare.HTTPClient(CloudflareHTTPClient()), cloudflare.UsingRetryPolicy(0, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = CloudflareApi(cApi, "").DomainList(DomainListReq{})
+	var e *Error
+	if !errors.As(err, &e) || e.Kind != ErrRateLimited || e.RetryAfter != time.Second*7 {
+		t.Fatalf("err = %#v, want rate limited with 7s Retry-After", err)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"iter"
+)
+
+const defaultPageLimit = 100
+
+// AllDomains 从 req.Page(默认1) 开始遍历域名列表的每一页, 出错时产出错误并停止
+func AllDomains(ctx context.Context, api Api, req DomainListReq) iter.Seq2[DomainListRespDomain, error] {
+	limit := pageLimit(req.Limit, api.Capabilities().DomainPageLimit)
+	return paginate(req.Page, limit, func(page uint) (list []DomainListRespDomain, total uint, err error) {
+		req.Page, req.Limit = page, limit
+		resp, err := api.DomainListContext(ctx, req)
+		return resp.List, resp.Total, err
+	}, func(d DomainListRespDomain) string { return d.Id })
+}
+
+// AllRecords 从 req.Page(默认1) 开始遍历记录列表的每一页, 出错时产出错误并停止
+func AllRecords(ctx context.Context, api Api, req RecordListReq) iter.Seq2[RecordListRespRecord, error] {
+	limit := pageLimit(req.Limit, api.Capabilities().RecordPageLimit)
+	return paginate(req.Page, limit, func(page uint) (list []RecordListRespRecord, total uint, err error) {
+		req.Page, req.Limit = page, limit
+		resp, err := api.RecordListContext(ctx, req)
+		return resp.List, resp.Total, err
+	}, func(r RecordListRespRecord) string { return r.Id })
+}
+
+func pageLimit(limit, max uint) uint {
+	if limit == 0 {
+		limit = defaultPageLimit
+	}
+	if max > 0 && limit > max {
+		limit = max
+	}
+	return limit
+}
+
+// paginate 在出现短页、达到总数或整页均为已产出的 id 时停止
+func paginate[T any](page, limit uint, list func(page uint) ([]T, uint, error), id func(T) string) iter.Seq2[T, error] {
+	if page == 0 {
+		page = 1
+	}
+	return func(yield func(T, error) bool) {
+		var (
+			zero  T
+			count uint
+			seen  = make(map[string]struct{})
+		)
+		for ; ; page++ {
+			items, total, err := list(page)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			fresh := 0
+			for _, item := range items {
+				if k := id(item); k != "" {
+					if _, ok := seen[k]; ok {
+						continue
+					}
+					seen[k] = struct{}{}
+				}
+				fresh++
+				count++
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if fresh == 0 || uint(len(items)) < limit || (total > 0 && count >= total) {
+				return
+			}
+		}
+	}
+}
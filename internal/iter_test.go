@@ -0,0 +1,117 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestAllDomains(t *testing.T) {
+	// noTotal 模拟不返回总数的服务商, samePage 模拟忽略页码的服务商
+	noTotal := func(ctx context.Context, op Operation, req any, next Invoker) (resp any, err error) {
+		if resp, err = next(ctx, op, req); err == nil && op == OpDomainList {
+			r := resp.(DomainListResp)
+			r.Total = 0
+			resp = r
+		}
+		return
+	}
+	samePage := func(ctx context.Context, op Operation, req any, next Invoker) (resp any, err error) {
+		if r, ok := req.(DomainListReq); ok {
+			r.Page = 1
+			req = r
+		}
+		return next(ctx, op, req)
+	}
+	tests := []struct {
+		name        string
+		domains     int
+		req         DomainListReq
+		interceptor Interceptor
+		wantCount   int
+		wantCalls   int
+	}{
+		{"short page", 5, DomainListReq{Limit: 2}, nil, 5, 3},
+		{"reaches total", 4, DomainListReq{Limit: 2}, nil, 4, 2},
+		{"empty page without total", 4, DomainListReq{Limit: 2}, noTotal, 4, 3},
+		{"page adds nothing", 4, DomainListReq{Limit: 2}, samePage, 2, 2},
+		{"start page", 5, DomainListReq{Page: 2, Limit: 2}, nil, 3, 2},
+		{"default limit", 5, DomainListReq{}, nil, 5, 1},
+		{"no domains", 0, DomainListReq{Limit: 2}, nil, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, calls := countingApi(nil)
+			for i := range tt.domains {
+				if _, err := api.DomainAdd(DomainAddReq{Domain: fmt.Sprintf("d%d.com", i)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.interceptor != nil {
+				api = Wrap(api, tt.interceptor)
+			}
+			var count int
+			for _, err := range AllDomains(context.Background(), api, tt.req) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				count++
+			}
+			if count != tt.wantCount || calls[OpDomainList] != tt.wantCalls {
+				t.Fatalf("got %d domains in %d calls, want %d in %d", count, calls[OpDomainList], tt.wantCount, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestAllRecordsStops(t *testing.T) {
+	api, calls := countingApi(nil)
+	domain, _ := api.DomainAdd(DomainAddReq{Domain: testDomain})
+	for i := range 5 {
+		if _, err := api.RecordAdd(RecordAddReq{DomainId: domain.Id, Record: fmt.Sprintf("r%d", i), Type: "A", Value: "1.1.1.1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := RecordListReq{DomainId: domain.Id, Limit: 2}
+
+	// 调用方提前停止时不再请求下一页
+	for range AllRecords(context.Background(), api, req) {
+		break
+	}
+	if calls[OpRecordList] != 1 {
+		t.Fatalf("calls after break = %d, want 1", calls[OpRecordList])
+	}
+
+	// 出错时产出错误并停止
+	fail := errors.New("fault")
+	api = Wrap(api, func(ctx context.Context, op Operation, req any, next Invoker) (resp any, err error) {
+		if r, ok := req.(RecordListReq); ok && r.Page == 2 {
+			return nil, fail
+		}
+		return next(ctx, op, req)
+	})
+	var count int
+	var errs []error
+	for _, err := range AllRecords(context.Background(), api, req) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		count++
+	}
+	if count != 2 || len(errs) != 1 || !errors.Is(errs[0], fail) {
+		t.Fatalf("got %d records and errors %v, want 2 records then the fault", count, errs)
+	}
+}
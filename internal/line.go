@@ -0,0 +1,246 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lineCacheTTL 线路随套餐变化, 很少变动
+const lineCacheTTL = time.Hour
+
+type (
+	// lineCache 按域名缓存服务商返回的线路列表, 零值可用
+	lineCache struct {
+		mu sync.Mutex
+		m  map[LineListReq]lineCacheEntry
+	}
+
+	lineCacheEntry struct {
+		lines  []LineListRespLine
+		expire time.Time
+	}
+)
+
+// get 未命中或已过期时调用 fetch, 失败不缓存
+func (c *lineCache) get(ctx context.Context, req LineListReq, fetch func(ctx context.Context) ([]LineListRespLine, error)) (resp LineListResp, err error) {
+	c.mu.Lock()
+	e, ok := c.m[req]
+	c.mu.Unlock()
+	if ok && time.Now().Before(e.expire) {
+		return LineListResp{slices.Clone(e.lines)}, nil
+	}
+	lines, err := fetch(ctx)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	c.mu.Lock()
+	if c.m == nil {
+		c.m = make(map[LineListReq]lineCacheEntry)
+	}
+	// 写入时清理过期条目, 已删除的域名不会一直占用内存
+	for k, v := range c.m {
+		if !now.Before(v.expire) {
+			delete(c.m, k)
+		}
+	}
+	c.m[req] = lineCacheEntry{lines, now.Add(lineCacheTTL)}
+	c.mu.Unlock()
+	return LineListResp{slices.Clone(lines)}, nil
+}
+
+// forget 删除域名的缓存线路, 域名删除后调用
+func (c *lineCache) forget(domainId, domain string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.m {
+		if (domainId != "" && k.DomainId == domainId) || (domain != "" && strings.EqualFold(k.Domain, domain)) {
+			delete(c.m, k)
+		}
+	}
+}
+
+// Children 返回 parent 的下级线路, parent 为空时返回顶级线路
+func (r LineListResp) Children(parent string) (lines []LineListRespLine) {
+	for _, l := range r.List {
+		if l.Parent == parent {
+			lines = append(lines, l)
+		}
+	}
+	return
+}
+
+// Find 按 Id 查找线路
+func (r LineListResp) Find(id string) (line LineListRespLine, ok bool) {
+	for _, l := range r.List {
+		if l.Id == id {
+			return l, true
+		}
+	}
+	return
+}
+
+// Line 与服务商无关的通用线路, 可用于 RecordAddReq.Line 等线路字段,
+// 由各服务商转换为对应的线路Id; 非通用线路的值视为服务商线路Id
+//
+//	运营商 => telecom
+//	省份 => cn.beijing / telecom.beijing
+//	国家或地区 => overseas.us
+type Line string
+
+const (
+	LineDefault   Line = "default"   // 默认
+	LineChina     Line = "cn"        // 境内
+	LineTelecom   Line = "telecom"   // 电信
+	LineUnicom    Line = "unicom"    // 联通
+	LineMobile    Line = "mobile"    // 移动
+	LineEducation Line = "education" // 教育网
+	LineOverseas  Line = "overseas"  // 境外
+)
+
+var (
+	lineIsps = map[Line]string{LineChina: "", LineTelecom: "电信", LineUnicom: "联通", LineMobile: "移动", LineEducation: "教育网"}
+
+	lineProvinces = map[string]string{
+		"beijing": "北京", "tianjin": "天津", "hebei": "河北", "shanxi": "山西", "neimenggu": "内蒙古",
+		"liaoning": "辽宁", "jilin": "吉林", "heilongjiang": "黑龙江", "shanghai": "上海", "jiangsu": "江苏",
+		"zhejiang": "浙江", "anhui": "安徽", "fujian": "福建", "jiangxi": "江西", "shandong": "山东",
+		"henan": "河南", "hubei": "湖北", "hunan": "湖南", "guangdong": "广东", "guangxi": "广西",
+		"hainan": "海南", "chongqing": "重庆", "sichuan": "四川", "guizhou": "贵州", "yunnan": "云南",
+		"xizang": "西藏", "shaanxi": "陕西", "gansu": "甘肃", "qinghai": "青海", "ningxia": "宁夏",
+		"xinjiang": "新疆",
+	}
+
+	lineCountries = map[string]string{
+		"hk": "香港", "mo": "澳门", "tw": "台湾", "us": "美国", "ca": "加拿大", "br": "巴西",
+		"gb": "英国", "de": "德国", "fr": "法国", "nl": "荷兰", "it": "意大利", "es": "西班牙", "ru": "俄罗斯",
+		"jp": "日本", "kr": "韩国", "sg": "新加坡", "my": "马来西亚", "th": "泰国", "vn": "越南",
+		"id": "印度尼西亚", "ph": "菲律宾", "in": "印度", "au": "澳大利亚",
+	}
+
+	// lineNames 线路名称 => 通用线路, 名称已去掉分隔符与 "中国" 前缀
+	lineNames = sync.OnceValue(func() map[string]Line {
+		names := map[string]Line{"默认": LineDefault, "境内": LineChina, "境外": LineOverseas}
+		for isp, name := range lineIsps {
+			if name != "" {
+				names[name] = isp
+			}
+			for p, pName := range lineProvinces {
+				names[pName+name] = ProvinceLine(isp, p)
+				names[name+pName] = ProvinceLine(isp, p)
+			}
+		}
+		for c, cName := range lineCountries {
+			names[cName] = CountryLine(c)
+		}
+		return names
+	})
+)
+
+// ProvinceLine 返回运营商在省份的线路, isp 为 LineChina 时为省份的所有运营商 => telecom.beijing
+func ProvinceLine(isp Line, province string) Line {
+	return Line(string(isp) + "." + strings.ToLower(province))
+}
+
+// CountryLine 返回国家或地区的线路, code 为 ISO 3166 两位代码 => overseas.us
+func CountryLine(code string) Line { return Line(string(LineOverseas) + "." + strings.ToLower(code)) }
+
+// Valid 是否为通用线路
+func (l Line) Valid() bool {
+	if l == LineDefault || l == LineOverseas {
+		return true
+	}
+	for _, v := range lineNames() {
+		if v == l {
+			return true
+		}
+	}
+	return false
+}
+
+// lineOfName 按线路名称识别通用线路, 无法识别时为空
+func lineOfName(name string) Line {
+	name = strings.NewReplacer("_", "", "-", "", " ", "", "·", "").Replace(name)
+	return lineNames()[strings.ReplaceAll(name, "中国", "")]
+}
+
+// lineMap 通用线路 => 服务商线路Id, 按顺序查找, 未列出的线路按线路列表中的名称识别
+type lineMap []struct {
+	line Line
+	id   string
+}
+
+// native 将通用线路转换为服务商线路Id, 其他值原样返回
+func (m lineMap) native(line string, catalog func() (LineListResp, error)) (id string, err error) {
+	l := Line(line)
+	for _, e := range m {
+		if e.line == l {
+			return e.id, nil
+		}
+	}
+	if !l.Valid() {
+		return line, nil
+	}
+	lines, err := catalog()
+	if errors.Is(err, ErrUnsupported) {
+		return "", &Error{Kind: ErrUnsupported, Err: fmt.Errorf("line %s is not available", line)}
+	} else if err != nil {
+		return
+	}
+	for _, c := range lines.List {
+		if lineOfName(c.Name) == l {
+			return c.Id, nil
+		}
+	}
+	return "", &Error{Kind: ErrUnsupported, Err: fmt.Errorf("line %s is not available", line)}
+}
+
+// canonical 将服务商线路Id转换为通用线路, 无对应或无法获取线路列表时为空
+func (m lineMap) canonical(id string, catalog func() (LineListResp, error)) Line {
+	if id == "" {
+		return ""
+	}
+	for _, e := range m {
+		if e.id == id {
+			return e.line
+		}
+	}
+	lines, err := catalog()
+	if err != nil {
+		return ""
+	}
+	if c, ok := lines.Find(id); ok {
+		return lineOfName(c.Name)
+	}
+	return ""
+}
+
+// lineCatalog 返回获取域名线路列表的函数, 仅在需要按名称识别时调用
+func lineCatalog(ctx context.Context, api ApiContext, domainId, domain string) func() (LineListResp, error) {
+	return func() (LineListResp, error) {
+		return api.LineListContext(ctx, LineListReq{DomainId: domainId, Domain: domain})
+	}
+}
+
+// canonicalize 为记录设置 CanonicalLine
+func (m lineMap) canonicalize(list []RecordListRespRecord, catalog func() (LineListResp, error)) {
+	for i := range list {
+		list[i].CanonicalLine = m.canonical(list[i].Line, catalog)
+	}
+}
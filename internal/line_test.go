@@ -0,0 +1,126 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLineCache(t *testing.T) {
+	var c lineCache
+	var fetches int
+	fetch := func(context.Context) ([]LineListRespLine, error) {
+		fetches++
+		return []LineListRespLine{{Id: "1", Name: "默认"}}, nil
+	}
+	get := func(req LineListReq) {
+		t.Helper()
+		if resp, err := c.get(context.Background(), req, fetch); err != nil || len(resp.List) != 1 {
+			t.Fatalf("get = %+v %v", resp, err)
+		}
+	}
+	a, b := LineListReq{DomainId: "1", Domain: "a.com"}, LineListReq{DomainId: "2", Domain: "b.com"}
+	get(a)
+	get(a)
+	if fetches != 1 {
+		t.Fatalf("fetches = %d, want a cached response", fetches)
+	}
+	if _, err := c.get(context.Background(), b, func(context.Context) ([]LineListRespLine, error) { return nil, errors.New("boom") }); err == nil {
+		t.Fatal("want fetch error")
+	}
+	if _, ok := c.m[b]; ok {
+		t.Fatal("errors should not be cached")
+	}
+
+	// 过期条目在下次写入时清理
+	c.m[a] = lineCacheEntry{c.m[a].lines, time.Now().Add(-time.Second)}
+	get(b)
+	if _, ok := c.m[a]; ok || len(c.m) != 1 {
+		t.Fatalf("entries = %v, want the expired entry evicted", c.m)
+	}
+	get(a)
+	if fetches != 3 {
+		t.Fatalf("fetches = %d, want 3", fetches)
+	}
+
+	c.forget("", "A.com")
+	c.forget("2", "")
+	if len(c.m) != 0 {
+		t.Fatalf("entries = %v, want none after forget", c.m)
+	}
+}
+
+func TestPqdnsLines(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Path+"?"+r.URL.RawQuery)
+		_, _ = w.Write([]byte(`{"total":0,"list":[]}`))
+	}))
+	defer srv.Close()
+	api := PqdnsApi(srv.URL, "user", "s&cret")
+	if _, err := api.LineList(LineListReq{DomainId: "1"}); !errors.Is(err, ErrUnsupported) || len(queries) != 0 {
+		t.Fatalf("LineList = %v after %d requests, want ErrUnsupported without a request", err, len(queries))
+	}
+
+	// 通用线路按 pqdnsLineMap 转换, 其他通用线路不可用, 查询参数转义
+	if _, err := api.RecordList(RecordListReq{DomainId: "1&x=2", Record: "a b", Value: "v=1", Line: "telecom", Page: 2, Limit: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.DomainList(DomainListReq{Domain: "a&b.com", Page: 1, Limit: 20}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"/api/ext/dns/record?domain_id=1%26x%3D2&host_record=a+b&limit=10&line_id=4&page=2&record_value=v%3D1&secret_key=s%26cret&user_name=user",
+		"/api/ext/dns/domain?domain=a%26b.com&limit=20&page=1&secret_key=s%26cret&user_name=user",
+	}
+	if !slices.Equal(queries, want) {
+		t.Fatalf("queries = %q, want %q", queries, want)
+	}
+	if _, err := api.RecordList(RecordListReq{DomainId: "1", Line: "telecom.beijing"}); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("province line err = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestLineMap(t *testing.T) {
+	m := lineMap{{LineDefault, "0"}, {LineTelecom, "10=0"}, {LineChina, "10=0"}}
+	catalog := func() (LineListResp, error) {
+		return LineListResp{[]LineListRespLine{{Id: "0", Name: "默认"}, {Id: "10=0", Name: "电信"}, {Id: "1001", Name: "电信_北京", Parent: "10=0"}}}, nil
+	}
+	for _, tt := range []struct {
+		line, id    string
+		unsupported bool
+	}{
+		{"default", "0", false},
+		{"telecom", "10=0", false},
+		{"telecom.beijing", "1001", false},
+		{"unicom", "", true},
+		{"10=5", "10=5", false},
+	} {
+		if id, err := m.native(tt.line, catalog); id != tt.id || (err != nil) != tt.unsupported || (err != nil && KindOf(err) != ErrUnsupported) {
+			t.Errorf("native(%q) = %q %v, want %q", tt.line, id, err, tt.id)
+		}
+	}
+	// 多个通用线路对应同一线路Id时取第一个
+	for id, want := range map[string]Line{"10=0": LineTelecom, "1001": ProvinceLine(LineTelecom, "beijing"), "9": "", "": ""} {
+		for range 10 {
+			if got := m.canonical(id, catalog); got != want {
+				t.Fatalf("canonical(%q) = %q, want %q", id, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import "context"
+
+type (
+	// Invoker 执行一次 Api 调用, req 与 resp 为对应方法的请求与响应类型, 无请求或响应时为 nil
+	//  Ping => nil, bool
+	//  LineList => LineListReq, LineListResp
+	//  LineDefault => nil, LineListRespLine
+	//  DomainList => DomainListReq, DomainListResp
+	//  DomainDelete => DomainDeleteReq, nil
+	//  RecordAdd => RecordAddReq, RecordAddResp
+	Invoker func(ctx context.Context, op Operation, req any) (resp any, err error)
+
+	// Interceptor 拦截一次 Api 调用, 调用 next 继续执行
+	Interceptor func(ctx context.Context, op Operation, req any, next Invoker) (resp any, err error)
+)
+
+// Wrap 使用 interceptors 包装 api, 第一个 interceptor 在最外层; Capabilities 不经过拦截
+func Wrap(api Api, interceptors ...Interceptor) Api {
+	if len(interceptors) == 0 {
+		return api
+	}
+	invoker := invokerOf(api)
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		invoker = func(ctx context.Context, op Operation, req any) (resp any, err error) {
+			return interceptor(ctx, op, req, next)
+		}
+	}
+	return BackgroundApi(&wrappedApi{api, invoker})
+}
+
+func invokerOf(api Api) Invoker {
+	return func(ctx context.Context, op Operation, req any) (resp any, err error) {
+		switch op {
+		case OpPing:
+			return api.PingContext(ctx), nil
+		case OpLineList:
+			return api.LineListContext(ctx, reqOf[LineListReq](req))
+		case OpLineDefault:
+			return api.LineDefaultContext(ctx), nil
+		case OpDomainList:
+			return api.DomainListContext(ctx, reqOf[DomainListReq](req))
+		case OpDomainAdd:
+			return api.DomainAddContext(ctx, reqOf[DomainAddReq](req))
+		case OpDomainDelete:
+			return nil, api.DomainDeleteContext(ctx, reqOf[DomainDeleteReq](req))
+		case OpDomainGet:
+			return api.DomainGetContext(ctx, reqOf[DomainGetReq](req))
+		case OpDomainEnable:
+			return nil, api.DomainEnableContext(ctx, reqOf[DomainEnableReq](req))
+		case OpDomainDisable:
+			return nil, api.DomainDisableContext(ctx, reqOf[DomainDisableReq](req))
+		case OpRecordList:
+			return api.RecordListContext(ctx, reqOf[RecordListReq](req))
+		case OpRecordAdd:
+			return api.RecordAddContext(ctx, reqOf[RecordAddReq](req))
+		case OpRecordUpdate:
+			return api.RecordUpdateContext(ctx, reqOf[RecordUpdateReq](req))
+		case OpRecordDelete:
+			return nil, api.RecordDeleteContext(ctx, reqOf[RecordDeleteReq](req))
+		case OpRecordEnable:
+			return nil, api.RecordEnableContext(ctx, reqOf[RecordEnableReq](req))
+		case OpRecordDisable:
+			return nil, api.RecordDisableContext(ctx, reqOf[RecordDisableReq](req))
+		}
+		return nil, ErrNotSupportedOperation
+	}
+}
+
+// reqOf 与 respOf 在类型不符时返回零值
+func reqOf[T any](req any) T {
+	t, _ := req.(T)
+	return t
+}
+
+func respOf[T any](resp any, err error) (T, error) {
+	t, _ := resp.(T)
+	return t, err
+}
+
+type wrappedApi struct {
+	api    Api
+	invoke Invoker
+}
+
+func (a *wrappedApi) Capabilities() (resp Capabilities) { return a.api.Capabilities() }
+
+func (a *wrappedApi) PingContext(ctx context.Context) (ok bool) {
+	ok, err := respOf[bool](a.invoke(ctx, OpPing, nil))
+	return ok && err == nil
+}
+
+func (a *wrappedApi) LineListContext(ctx context.Context, req LineListReq) (resp LineListResp, err error) {
+	return respOf[LineListResp](a.invoke(ctx, OpLineList, req))
+}
+
+func (a *wrappedApi) LineDefaultContext(ctx context.Context) (resp LineListRespLine) {
+	resp, _ = respOf[LineListRespLine](a.invoke(ctx, OpLineDefault, nil))
+	return
+}
+
+func (a *wrappedApi) DomainListContext(ctx context.Context, req DomainListReq) (resp DomainListResp, err error) {
+	return respOf[DomainListResp](a.invoke(ctx, OpDomainList, req))
+}
+
+func (a *wrappedApi) DomainAddContext(ctx context.Context, req DomainAddReq) (resp DomainAddResp, err error) {
+	return respOf[DomainAddResp](a.invoke(ctx, OpDomainAdd, req))
+}
+
+func (a *wrappedApi) DomainDeleteContext(ctx context.Context, req DomainDeleteReq) (err error) {
+	_, err = a.invoke(ctx, OpDomainDelete, req)
+	return
+}
+
+func (a *wrappedApi) DomainGetContext(ctx context.Context, req DomainGetReq) (resp DomainGetResp, err error) {
+	return respOf[DomainGetResp](a.invoke(ctx, OpDomainGet, req))
+}
+
+func (a *wrappedApi) DomainEnableContext(ctx context.Context, req DomainEnableReq) (err error) {
+	_, err = a.invoke(ctx, OpDomainEnable, req)
+	return
+}
+
+func (a *wrappedApi) DomainDisableContext(ctx context.Context, req DomainDisableReq) (err error) {
+	_, err = a.invoke(ctx, OpDomainDisable, req)
+	return
+}
+
+func (a *wrappedApi) RecordListContext(ctx context.Context, req RecordListReq) (resp RecordListResp, err error) {
+	return respOf[RecordListResp](a.invoke(ctx, OpRecordList, req))
+}
+
+func (a *wrappedApi) RecordAddContext(ctx context.Context, req RecordAddReq) (resp RecordAddResp, err error) {
+	return respOf[RecordAddResp](a.invoke(ctx, OpRecordAdd, req))
+}
+
+func (a *wrappedApi) RecordUpdateContext(ctx context.Context, req RecordUpdateReq) (resp RecordUpdateResp, err error) {
+	return respOf[RecordUpdateResp](a.invoke(ctx, OpRecordUpdate, req))
+}
+
+func (a *wrappedApi) RecordDeleteContext(ctx context.Context, req RecordDeleteReq) (err error) {
+	_, err = a.invoke(ctx, OpRecordDelete, req)
+	return
+}
+
+func (a *wrappedApi) RecordEnableContext(ctx context.Context, req RecordEnableReq) (err error) {
+	_, err = a.invoke(ctx, OpRecordEnable, req)
+	return
+}
+
+func (a *wrappedApi) RecordDisableContext(ctx context.Context, req RecordDisableReq) (err error) {
+	_, err = a.invoke(ctx, OpRecordDisable, req)
+	return
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestWrapOrder(t *testing.T) {
+	var calls []string
+	trace := func(name string) Interceptor {
+		return func(ctx context.Context, op Operation, req any, next Invoker) (resp any, err error) {
+			calls = append(calls, name+" before "+string(op))
+			resp, err = next(ctx, op, req)
+			calls = append(calls, name+" after "+string(op))
+			return
+		}
+	}
+	api := Wrap(MemoryApi(MemoryOpts{}), trace("outer"), trace("inner"))
+	if _, err := api.DomainList(DomainListReq{}); err != nil {
+		t.Fatal(err)
+	}
+	_ = api.Capabilities()
+	want := []string{"outer before DomainList", "inner before DomainList", "inner after DomainList", "outer after DomainList"}
+	if !slices.Equal(calls, want) {
+		t.Fatalf("calls = %q, want %q", calls, want)
+	}
+}
+
+func TestWrapOperations(t *testing.T) {
+	seen := make(map[Operation]string)
+	record := func(ctx context.Context, op Operation, req any, next Invoker) (resp any, err error) {
+		resp, err = next(ctx, op, req)
+		seen[op] = fmt.Sprintf("%T %T", req, resp)
+		return
+	}
+	api := Wrap(MemoryApi(MemoryOpts{}), record)
+	domain, err := api.DomainAdd(DomainAddReq{Domain: testDomain})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record0, err := api.RecordAdd(RecordAddReq{DomainId: domain.Id, Record: "www", Type: "A", Value: "192.0.2.1", TTL: 600})
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.Ping()
+	api.LineDefault()
+	for _, err = range []error{
+		func() error { _, err := api.LineList(LineListReq{}); return err }(),
+		func() error { _, err := api.DomainList(DomainListReq{}); return err }(),
+		func() error { _, err := api.DomainGet(DomainGetReq{DomainId: domain.Id}); return err }(),
+		api.DomainDisable(DomainDisableReq{DomainId: domain.Id}),
+		api.DomainEnable(DomainEnableReq{DomainId: domain.Id}),
+		func() error { _, err := api.RecordList(RecordListReq{DomainId: domain.Id}); return err }(),
+		func() error {
+			_, err := api.RecordUpdate(RecordUpdateReq{DomainId: domain.Id, RecordId: record0.Id, Record: "www", Type: "A", Value: "192.0.2.2", TTL: 600})
+			return err
+		}(),
+		api.RecordDisable(RecordDisableReq{DomainId: domain.Id, RecordId: record0.Id}),
+		api.RecordEnable(RecordEnableReq{DomainId: domain.Id, RecordId: record0.Id}),
+		api.RecordDelete(RecordDeleteReq{DomainId: domain.Id, RecordId: record0.Id}),
+		api.DomainDelete(DomainDeleteReq{DomainId: domain.Id, Domain: testDomain}),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := map[Operation]string{
+		OpPing:          "<nil> bool",
+		OpLineList:      "internal.LineListReq internal.LineListResp",
+		OpLineDefault:   "<nil> internal.LineListRespLine",
+		OpDomainList:    "internal.DomainListReq internal.DomainListResp",
+		OpDomainAdd:     "internal.DomainAddReq internal.DomainAddResp",
+		OpDomainDelete:  "internal.DomainDeleteReq <nil>",
+		OpDomainGet:     "internal.DomainGetReq internal.DomainGetResp",
+		OpDomainEnable:  "internal.DomainEnableReq <nil>",
+		OpDomainDisable: "internal.DomainDisableReq <nil>",
+		OpRecordList:    "internal.RecordListReq internal.RecordListResp",
+		OpRecordAdd:     "internal.RecordAddReq internal.RecordAddResp",
+		OpRecordUpdate:  "internal.RecordUpdateReq internal.RecordUpdateResp",
+		OpRecordDelete:  "internal.RecordDeleteReq <nil>",
+		OpRecordEnable:  "internal.RecordEnableReq <nil>",
+		OpRecordDisable: "internal.RecordDisableReq <nil>",
+	}
+	for _, op := range operations {
+		if seen[op] != want[op] {
+			t.Errorf("%s = %q, want %q", op, seen[op], want[op])
+		}
+	}
+}
+
+func TestWrapShortCircuit(t *testing.T) {
+	denied := errors.New("denied")
+	readOnly := func(ctx context.Context, op Operation, req any, next Invoker) (resp any, err error) {
+		if op == OpDomainAdd {
+			return nil, denied
+		}
+		if op == OpDomainList {
+			return DomainListResp{Total: 1, List: []DomainListRespDomain{{Id: "fake", Name: testDomain}}}, nil
+		}
+		return next(ctx, op, req)
+	}
+	api := Wrap(MemoryApi(MemoryOpts{}), readOnly)
+	if _, err := api.DomainAdd(DomainAddReq{Domain: testDomain}); !errors.Is(err, denied) {
+		t.Fatalf("DomainAdd err = %v, want denied", err)
+	}
+	resp, err := api.DomainList(DomainListReq{})
+	if err != nil || len(resp.List) != 1 || resp.List[0].Id != "fake" {
+		t.Fatalf("DomainList = %+v %v, want the interceptor response", resp, err)
+	}
+	if Wrap(api) != api {
+		t.Fatal("Wrap without interceptors should return api")
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type (
+	MigrateReq struct {
+		Domain         string            // 域名 => example.com
+		SourceDomainId string            // 源域名Id => xxxxxxxxxxxx
+		TargetDomainId string            // 目标域名Id, 为空时按域名查找, 不存在则添加 => xxxxxxxxxxxx
+		Lines          map[string]string // 源线路Id => 目标线路Id, 未配置时按通用线路转换, 无通用线路时按线路名称匹配 => {"10=0": "telecom"}
+	}
+
+	MigrateIssue struct {
+		Record  string `json:"record"`  // 主机记录 => www
+		Type    string `json:"type"`    // 类型 => A
+		Value   string `json:"value"`   // 记录值 => 1.1.1.1
+		Line    string `json:"line"`    // 源线路Id => 10=0
+		Skipped bool   `json:"skipped"` // 是否跳过, false表示已复制但有改动
+		Reason  string `json:"reason"`  // 原因 => 不支持的记录类型
+	}
+
+	MigrateReport struct {
+		DomainId      string         `json:"domain_id"`      // 目标域名Id => xxxxxxxxxxxx
+		DomainCreated bool           `json:"domain_created"` // 是否添加了目标域名
+		Source        uint           `json:"source"`         // 源记录数
+		Copied        uint           `json:"copied"`         // 复制的记录数
+		Existing      uint           `json:"existing"`       // 目标已存在的记录数
+		Target        uint           `json:"target"`         // 迁移后目标记录数
+		Verified      bool           `json:"verified"`       // 复制的记录是否均已在目标中找到
+		Issues        []MigrateIssue `json:"issues"`         // 跳过或改动的记录
+	}
+)
+
+// Migrate 将 src 中域名的记录复制到 dst, 完成后重新读取 dst 校验复制结果
+func Migrate(ctx context.Context, src, dst Api, req MigrateReq) (report MigrateReport, err error) {
+	if req.Domain == "" {
+		err = &Error{Kind: ErrInvalidInput, Err: errors.New("domain is required")}
+		return
+	}
+	if report.DomainId, report.DomainCreated, err = migrateDomain(ctx, dst, req); err != nil {
+		return
+	}
+	lines, err := migrateLines(ctx, src, dst, req, report.DomainId)
+	if err != nil {
+		return
+	}
+	caps := dst.Capabilities()
+	dstDef := dst.LineDefaultContext(ctx).Id
+	copied := make(map[string]struct{})
+	for r, err0 := range AllRecords(ctx, src, RecordListReq{DomainId: req.SourceDomainId, Domain: req.Domain}) {
+		if err = err0; err != nil {
+			return
+		}
+		report.Source++
+		record, typ := syncRecord(r.Record), strings.ToUpper(r.Type)
+		issue := func(skipped bool, reason string) {
+			report.Issues = append(report.Issues, MigrateIssue{record, typ, r.Value, r.Line, skipped, reason})
+		}
+		switch {
+		case typ == "SOA", typ == "NS" && record == "@":
+			issue(true, fmt.Sprintf("%s 记录由服务商管理", typ))
+			continue
+		case len(caps.RecordTypes) > 0 && !caps.SupportsRecordType(typ):
+			issue(true, "目标不支持的记录类型")
+			continue
+		case r.Status == "disable" && !caps.Supports(OpRecordDisable):
+			issue(true, "记录已暂停, 目标不支持暂停")
+			continue
+		}
+		line, ok := lines[r.Line]
+		_, override := req.Lines[r.Line]
+		canonical := !override && r.CanonicalLine != "" && r.CanonicalLine != LineDefault
+		switch {
+		case canonical && caps.Line:
+			// 通用线路由目标服务商转换为线路Id, 与 PlanSync 一致
+			line = string(r.CanonicalLine)
+		case canonical:
+			line = dstDef
+			issue(false, fmt.Sprintf("目标不支持线路, 线路 %s 已使用默认线路", r.Line))
+		case !ok:
+			line = dstDef
+			issue(false, fmt.Sprintf("线路 %s 在目标中不存在, 已使用默认线路", r.Line))
+		}
+		ttl := r.TTL
+		if caps.TTLMin > 0 && ttl < caps.TTLMin {
+			ttl = caps.TTLMin
+		}
+		if caps.TTLMax > 0 && ttl > caps.TTLMax {
+			ttl = caps.TTLMax
+		}
+		if ttl != r.TTL {
+			issue(false, fmt.Sprintf("TTL %d 超出目标范围, 已调整为 %d", r.TTL, ttl))
+		}
+		if r.Weight != 0 && !caps.Weight {
+			issue(false, "目标不支持权重, 已忽略")
+		}
+		if r.Remark != "" && !caps.Remark {
+			issue(false, "目标不支持备注, 已忽略")
+		}
+		if r.Proxied && !caps.Proxied {
+			issue(false, "目标不支持代理, 已忽略")
+		}
+		if len(r.Tags) > 0 && !caps.Tags {
+			issue(false, "目标不支持标签, 已忽略")
+		}
+		var proxied *bool
+		if r.Proxied {
+			proxied = &r.Proxied
+		}
+		if typ == "MX" && !caps.MXPriority {
+			issue(false, fmt.Sprintf("目标不支持MX优先级, 已忽略 %d", r.MX))
+		}
+		add := RecordAddReq{
+			DomainId: report.DomainId,
+			Domain:   req.Domain,
+			Record:   record,
+			Type:     typ,
+			Value:    r.Value,
+			Line:     line,
+			TTL:      ttl,
+			MX:       r.MX,
+			Weight:   r.Weight,
+			Remark:   r.Remark,
+			Proxied:  proxied,
+			Tags:     r.Tags,
+		}
+		resp, err0 := dst.RecordAddContext(ctx, add)
+		if canonical && caps.Line && errors.Is(err0, ErrUnsupported) {
+			line, add.Line = dstDef, dstDef
+			issue(false, fmt.Sprintf("线路 %s 在目标中不可用, 已使用默认线路", r.Line))
+			resp, err0 = dst.RecordAddContext(ctx, add)
+		}
+		if errors.Is(err0, ErrAlreadyExists) {
+			report.Existing++
+			continue
+		}
+		if err = err0; err != nil {
+			err = fmt.Errorf("%s %s %s: %w", record, typ, r.Value, err)
+			return
+		}
+		if r.Status == "disable" {
+			req0 := RecordDisableReq{RecordId: resp.Id, DomainId: report.DomainId, Domain: req.Domain}
+			if err = dst.RecordDisableContext(ctx, req0); err != nil {
+				return
+			}
+		}
+		report.Copied++
+		copied[syncKey(record, typ, line, r.Value)] = struct{}{}
+	}
+	for r, err0 := range AllRecords(ctx, dst, RecordListReq{DomainId: report.DomainId, Domain: req.Domain}) {
+		if err = err0; err != nil {
+			return
+		}
+		report.Target++
+		record, typ := syncRecord(r.Record), strings.ToUpper(r.Type)
+		delete(copied, syncKey(record, typ, r.Line, r.Value))
+		if r.CanonicalLine != "" {
+			delete(copied, syncKey(record, typ, string(r.CanonicalLine), r.Value))
+		}
+	}
+	report.Verified = len(copied) == 0
+	return
+}
+
+func migrateDomain(ctx context.Context, dst Api, req MigrateReq) (domainId string, created bool, err error) {
+	if req.TargetDomainId != "" {
+		return req.TargetDomainId, false, nil
+	}
+	for d, err0 := range AllDomains(ctx, dst, DomainListReq{Domain: req.Domain}) {
+		if err = err0; err != nil {
+			return
+		}
+		if strings.EqualFold(d.Name, req.Domain) {
+			return d.Id, false, nil
+		}
+	}
+	resp, err := dst.DomainAddContext(ctx, DomainAddReq{Domain: req.Domain})
+	if err != nil {
+		return
+	}
+	return resp.Id, true, nil
+}
+
+// migrateLines 返回 源线路Id => 目标线路Id, 默认线路互相对应, 其余按名称匹配, 用于没有通用线路的记录
+func migrateLines(ctx context.Context, src, dst Api, req MigrateReq, domainId string) (lines map[string]string, err error) {
+	// 不提供线路列表的服务商 (如 PQDNS) 只按默认线路与通用线路对应
+	dstLines, err := dst.LineListContext(ctx, LineListReq{DomainId: domainId, Domain: req.Domain})
+	if err != nil && !errors.Is(err, ErrUnsupported) {
+		return
+	}
+	srcLines, err := src.LineListContext(ctx, LineListReq{DomainId: req.SourceDomainId, Domain: req.Domain})
+	if err != nil && !errors.Is(err, ErrUnsupported) {
+		return
+	}
+	err = nil
+	byName := make(map[string]string)
+	for _, l := range dstLines.List {
+		byName[l.Name] = l.Id
+	}
+	lines = map[string]string{"": dst.LineDefaultContext(ctx).Id, src.LineDefaultContext(ctx).Id: dst.LineDefaultContext(ctx).Id}
+	for _, l := range srcLines.List {
+		if id, ok := byName[l.Name]; ok {
+			if _, def := lines[l.Id]; !def {
+				lines[l.Id] = id
+			}
+		}
+	}
+	for k, v := range req.Lines {
+		lines[k] = v
+	}
+	return
+}
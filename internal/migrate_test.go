@@ -0,0 +1,208 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	src, srcId := newTestApi(t,
+		RecordAddReq{Record: "www", Type: "A", Value: "192.0.2.1", TTL: 1},
+		RecordAddReq{Record: "big", Type: "A", Value: "192.0.2.2", TTL: 604800},
+		RecordAddReq{Record: "cn", Type: "A", Value: "192.0.2.3", TTL: 600, Line: "telecom"},
+		RecordAddReq{Record: "odd", Type: "A", Value: "192.0.2.4", TTL: 600, Line: "custom"},
+		RecordAddReq{Record: "lb", Type: "A", Value: "192.0.2.5", TTL: 600, Weight: 5},
+		RecordAddReq{Record: "@", Type: "NS", Value: "ns1.example.net", TTL: 600},
+		RecordAddReq{Record: "@", Type: "PTR", Value: "host.example.com", TTL: 600},
+		RecordAddReq{Record: "dup", Type: "A", Value: "192.0.2.6", TTL: 600},
+	)
+	dst, dstId := newTestApi(t, RecordAddReq{Record: "dup", Type: "A", Value: "192.0.2.6", TTL: 600})
+	caps := memoryCapabilities
+	caps.TTLMin, caps.TTLMax, caps.Weight = 600, 86400, false
+	caps.RecordTypes = slices.DeleteFunc(slices.Clone(caps.RecordTypes), func(s string) bool { return s == "PTR" })
+
+	report, err := Migrate(context.Background(), src, capsApi{dst, caps}, MigrateReq{Domain: testDomain, SourceDomainId: srcId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.DomainId != dstId || report.DomainCreated {
+		t.Fatalf("domain = %s created = %v, want existing %s", report.DomainId, report.DomainCreated, dstId)
+	}
+	if report.Source != 8 || report.Copied != 5 || report.Existing != 1 || report.Target != 6 || !report.Verified {
+		t.Fatalf("report = %+v", report)
+	}
+	var reasons []string
+	for _, i := range report.Issues {
+		reasons = append(reasons, i.Record+" "+i.Reason)
+	}
+	want := []string{
+		"www TTL 1 超出目标范围, 已调整为 600",
+		"big TTL 604800 超出目标范围, 已调整为 86400",
+		"odd 线路 custom 在目标中不存在, 已使用默认线路",
+		"lb 目标不支持权重, 已忽略",
+		"@ NS 记录由服务商管理",
+		"@ 目标不支持的记录类型",
+	}
+	if !slices.Equal(reasons, want) {
+		t.Fatalf("issues = %q, want %q", reasons, want)
+	}
+	wantRecords := []string{
+		"big A 192.0.2.2 default 86400",
+		"cn A 192.0.2.3 telecom 600",
+		"dup A 192.0.2.6 default 600",
+		"lb A 192.0.2.5 default 600",
+		"odd A 192.0.2.4 default 600",
+		"www A 192.0.2.1 default 600",
+	}
+	if got := testRecords(t, dst, dstId); !slices.Equal(got, wantRecords) {
+		t.Fatalf("records = %q, want %q", got, wantRecords)
+	}
+}
+
+func TestMigrateCreatesDomain(t *testing.T) {
+	src, srcId := newTestApi(t,
+		RecordAddReq{Record: "cn", Type: "A", Value: "192.0.2.1", TTL: 600, Line: "telecom"},
+		RecordAddReq{Record: "off", Type: "A", Value: "192.0.2.2", TTL: 600},
+	)
+	list, _ := src.RecordList(RecordListReq{DomainId: srcId, Record: "off"})
+	if err := src.RecordDisable(RecordDisableReq{DomainId: srcId, RecordId: list.List[0].Id}); err != nil {
+		t.Fatal(err)
+	}
+	dst := MemoryApi(MemoryOpts{})
+	report, err := Migrate(context.Background(), src, dst, MigrateReq{
+		Domain:         testDomain,
+		SourceDomainId: srcId,
+		Lines:          map[string]string{"telecom": "unicom"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.DomainCreated || report.Copied != 2 || !report.Verified || len(report.Issues) != 0 {
+		t.Fatalf("report = %+v", report)
+	}
+	resp, err := dst.RecordList(RecordListReq{DomainId: report.DomainId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range resp.List {
+		switch r.Record {
+		case "cn":
+			if r.Line != "unicom" {
+				t.Fatalf("cn line = %q, want override unicom", r.Line)
+			}
+		case "off":
+			if r.Status != "disable" {
+				t.Fatalf("off status = %q, want disable", r.Status)
+			}
+		}
+	}
+}
+
+// englishLines 返回英文线路名称, 按名称无法与源线路对应
+type englishLines struct{ Api }
+
+func (a englishLines) LineListContext(ctx context.Context, req LineListReq) (resp LineListResp, err error) {
+	if resp, err = a.Api.LineListContext(ctx, req); err != nil {
+		return
+	}
+	names := map[string]string{"默认": "Default", "境内": "China", "电信": "Telecom", "联通": "Unicom", "移动": "Mobile", "境外": "Overseas"}
+	for i, l := range resp.List {
+		if name, ok := names[l.Name]; ok {
+			resp.List[i].Name = name
+		}
+	}
+	return
+}
+
+func TestMigrateCanonicalLines(t *testing.T) {
+	src, srcId := newTestApi(t,
+		RecordAddReq{Record: "isp", Type: "A", Value: "192.0.2.1", TTL: 600, Line: "telecom"},
+		RecordAddReq{Record: "province", Type: "A", Value: "192.0.2.2", TTL: 600, Line: "cn_telecom_beijing"},
+		RecordAddReq{Record: "mobile", Type: "A", Value: "192.0.2.3", TTL: 600, Line: "mobile"},
+		RecordAddReq{Record: "abroad", Type: "A", Value: "192.0.2.4", TTL: 600, Line: "oversea"},
+	)
+	dst, dstId := newTestApi(t)
+	// 目标不提供移动线路
+	noMobile := Wrap(englishLines{dst}, func(ctx context.Context, op Operation, req any, next Invoker) (resp any, err error) {
+		if r, ok := req.(RecordAddReq); ok && r.Line == string(LineMobile) {
+			return nil, &Error{Kind: ErrUnsupported, Err: errors.New("line mobile is not available")}
+		}
+		return next(ctx, op, req)
+	})
+	report, err := Migrate(context.Background(), src, noMobile, MigrateReq{Domain: testDomain, SourceDomainId: srcId, TargetDomainId: dstId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Copied != 4 || !report.Verified {
+		t.Fatalf("report = %+v", report)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Record != "mobile" || report.Issues[0].Reason != "线路 mobile 在目标中不可用, 已使用默认线路" {
+		t.Fatalf("issues = %+v, want mobile falling back to default", report.Issues)
+	}
+	want := []string{
+		"abroad A 192.0.2.4 oversea 600",
+		"isp A 192.0.2.1 telecom 600",
+		"mobile A 192.0.2.3 default 600",
+		"province A 192.0.2.2 cn_telecom_beijing 600",
+	}
+	if got := testRecords(t, dst, dstId); !slices.Equal(got, want) {
+		t.Fatalf("records = %q, want %q", got, want)
+	}
+
+	// 目标不支持线路时使用默认线路
+	caps := memoryCapabilities
+	caps.Line = false
+	dst, dstId = newTestApi(t)
+	if report, err = Migrate(context.Background(), src, capsApi{dst, caps}, MigrateReq{Domain: testDomain, SourceDomainId: srcId, TargetDomainId: dstId}); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) != 4 || !report.Verified {
+		t.Fatalf("report = %+v, want every line reported", report)
+	}
+}
+
+func TestMigrateWithoutLineList(t *testing.T) {
+	src, srcId := newTestApi(t,
+		RecordAddReq{Record: "isp", Type: "A", Value: "192.0.2.1", TTL: 600, Line: "telecom"},
+		RecordAddReq{Record: "odd", Type: "A", Value: "192.0.2.2", TTL: 600, Line: "custom"},
+	)
+	dst, dstId := newTestApi(t)
+	// 与 PQDNS 一样不提供线路列表
+	noLineList := Wrap(dst, func(ctx context.Context, op Operation, req any, next Invoker) (resp any, err error) {
+		if op == OpLineList {
+			return nil, ErrNotSupportedOperation
+		}
+		return next(ctx, op, req)
+	})
+	report, err := Migrate(context.Background(), src, noLineList, MigrateReq{Domain: testDomain, SourceDomainId: srcId, TargetDomainId: dstId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Copied != 2 || !report.Verified || len(report.Issues) != 1 || report.Issues[0].Record != "odd" {
+		t.Fatalf("report = %+v, want odd moved to the default line", report)
+	}
+	want := []string{"isp A 192.0.2.1 telecom 600", "odd A 192.0.2.2 default 600"}
+	if got := testRecords(t, dst, dstId); !slices.Equal(got, want) {
+		t.Fatalf("records = %q, want %q", got, want)
+	}
+}
+
+func TestMigrateRequiresDomain(t *testing.T) {
+	api := MemoryApi(MemoryOpts{})
+	if _, err := Migrate(context.Background(), api, api, MigrateReq{}); KindOf(err) != ErrInvalidInput {
+		t.Fatalf("err = %v, want ErrInvalidInput", err)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+var (
+	limitersMu sync.Mutex
+	limiters   = make(map[string]*rate.Limiter)
+)
+
+type (
+	RateLimitOpts struct {
+		Key        string              // 共享令牌桶的键, 相同 Key 的拦截器共享配额, 为空时不共享 => dnspod:AKIDxxxx
+		Limit      Limit               // 账号限制, Rate 为0时使用 Capabilities.QPS
+		Operations map[Operation]Limit // 按操作覆盖, 覆盖的操作使用单独的令牌桶
+	}
+
+	Limit struct {
+		Rate  float64 // 每秒请求数, 0表示不限制 => 20
+		Burst int     // 突发请求数, 默认为 Rate 向上取整 => 20
+	}
+)
+
+// RateLimit 返回限流拦截器, 调用前等待令牌, ctx 结束或等待将超过 ctx 截止时间时返回错误.
+// 共享的令牌桶按首次创建时的配置生效
+func RateLimit(api Api, opts RateLimitOpts) Interceptor {
+	if opts.Limit.Rate == 0 {
+		opts.Limit.Rate = api.Capabilities().QPS
+	}
+	var private sync.Map
+	limiter := func(key string, l Limit) *rate.Limiter {
+		if opts.Key == "" {
+			lim, _ := private.LoadOrStore(key, l.limiter())
+			return lim.(*rate.Limiter)
+		}
+		return sharedLimiter(opts.Key+"\x00"+key, l)
+	}
+	return func(ctx context.Context, op Operation, req any, next Invoker) (resp any, err error) {
+		key, l := "", opts.Limit
+		if ol, ok := opts.Operations[op]; ok {
+			key, l = string(op), ol
+		}
+		if l.Rate > 0 {
+			if err = limiter(key, l).Wait(ctx); err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				return nil, &Error{Kind: ErrRateLimited, Err: err}
+			}
+		}
+		return next(ctx, op, req)
+	}
+}
+
+func sharedLimiter(key string, l Limit) *rate.Limiter {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	lim, ok := limiters[key]
+	if !ok {
+		lim = l.limiter()
+		limiters[key] = lim
+	}
+	return lim
+}
+
+func (l Limit) limiter() *rate.Limiter {
+	burst := l.Burst
+	if burst <= 0 {
+		burst = int(math.Ceil(l.Rate))
+	}
+	return rate.NewLimiter(rate.Limit(l.Rate), burst)
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// drained 在令牌耗尽前返回成功的调用次数, 之后的调用因超过截止时间而失败
+func drained(t *testing.T, api Api, op func(ctx context.Context) error, max int) (n int) {
+	t.Helper()
+	for n = 0; n < max; n++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+		err := op(ctx)
+		cancel()
+		if err != nil {
+			if KindOf(err) != ErrRateLimited {
+				t.Fatalf("err = %v, want ErrRateLimited", err)
+			}
+			return
+		}
+	}
+	return
+}
+
+func TestRateLimit(t *testing.T) {
+	list := func(api Api) func(ctx context.Context) error {
+		return func(ctx context.Context) error { _, err := api.DomainListContext(ctx, DomainListReq{}); return err }
+	}
+	add := func(api Api) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			_, err := api.DomainAddContext(ctx, DomainAddReq{Domain: time.Now().Format("150405.000000") + ".com"})
+			return err
+		}
+	}
+	tests := []struct {
+		name string
+		opts RateLimitOpts
+		caps Capabilities
+		run  func(t *testing.T, api Api, opts RateLimitOpts, caps Capabilities)
+	}{
+		{"burst", RateLimitOpts{Limit: Limit{Rate: 0.1, Burst: 3}}, memoryCapabilities, func(t *testing.T, api Api, _ RateLimitOpts, _ Capabilities) {
+			if n := drained(t, api, list(api), 10); n != 3 {
+				t.Fatalf("allowed %d calls, want burst 3", n)
+			}
+		}},
+		{"burst defaults to rate", RateLimitOpts{Limit: Limit{Rate: 1.5}}, memoryCapabilities, func(t *testing.T, api Api, _ RateLimitOpts, _ Capabilities) {
+			if n := drained(t, api, list(api), 10); n != 2 {
+				t.Fatalf("allowed %d calls, want 2", n)
+			}
+		}},
+		{"capabilities qps", RateLimitOpts{}, Capabilities{QPS: 0.1}, func(t *testing.T, api Api, _ RateLimitOpts, _ Capabilities) {
+			if n := drained(t, api, list(api), 10); n != 1 {
+				t.Fatalf("allowed %d calls, want 1", n)
+			}
+		}},
+		{"unlimited", RateLimitOpts{}, Capabilities{}, func(t *testing.T, api Api, _ RateLimitOpts, _ Capabilities) {
+			if n := drained(t, api, list(api), 50); n != 50 {
+				t.Fatalf("allowed %d calls, want 50", n)
+			}
+		}},
+		{"operation bucket", RateLimitOpts{Limit: Limit{Rate: 0.1, Burst: 1}, Operations: map[Operation]Limit{OpDomainAdd: {Rate: 0.1, Burst: 2}}}, memoryCapabilities, func(t *testing.T, api Api, _ RateLimitOpts, _ Capabilities) {
+			if n := drained(t, api, add(api), 10); n != 2 {
+				t.Fatalf("DomainAdd allowed %d calls, want 2", n)
+			}
+			if n := drained(t, api, list(api), 10); n != 1 {
+				t.Fatalf("DomainList allowed %d calls, want its own bucket of 1", n)
+			}
+		}},
+		{"shared key", RateLimitOpts{Key: "test:" + time.Now().String(), Limit: Limit{Rate: 0.1, Burst: 2}}, memoryCapabilities, func(t *testing.T, api Api, opts RateLimitOpts, caps Capabilities) {
+			other := Wrap(capsApi{MemoryApi(MemoryOpts{}), caps}, RateLimit(capsApi{MemoryApi(MemoryOpts{}), caps}, opts))
+			if n := drained(t, api, list(api), 1); n != 1 {
+				t.Fatal("first api should take a token")
+			}
+			if n := drained(t, other, list(other), 10); n != 1 {
+				t.Fatalf("second api allowed %d calls, want the 1 remaining shared token", n)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := capsApi{MemoryApi(MemoryOpts{}), tt.caps}
+			tt.run(t, Wrap(base, RateLimit(base, tt.opts)), tt.opts, tt.caps)
+		})
+	}
+}
+
+func TestRateLimitCanceled(t *testing.T) {
+	base := MemoryApi(MemoryOpts{})
+	api := Wrap(base, RateLimit(base, RateLimitOpts{Limit: Limit{Rate: 0.1, Burst: 1}}))
+	if _, err := api.DomainList(DomainListReq{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := api.DomainListContext(ctx, DomainListReq{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type (
+	// RecordData 结构化的记录值, String 返回各服务商通用的文本格式, 即 RecordListRespRecord.Value
+	RecordData interface {
+		Type() string
+		String() string
+	}
+
+	SRV struct {
+		Priority uint16 `json:"priority"` // 优先级 => 10
+		Weight   uint16 `json:"weight"`   // 权重 => 5
+		Port     uint16 `json:"port"`     // 端口 => 5060
+		Target   string `json:"target"`   // 目标主机, . 表示不提供服务 => sip.example.com
+	}
+
+	CAA struct {
+		Flags uint8  `json:"flags"` // 标志 => 0
+		Tag   string `json:"tag"`   // 标签 => issue / issuewild / iodef
+		Value string `json:"value"` // 值 => letsencrypt.org
+	}
+
+	// TXT 记录值不含引号, 超过255字节时由服务商或 Chunks 拆分
+	TXT struct {
+		Value string `json:"value"` // 值 => v=spf1 include:example.com ~all
+	}
+
+	SVCB struct {
+		Priority uint16     `json:"priority"` // 优先级, 0表示别名模式 => 1
+		Target   string     `json:"target"`   // 目标主机, . 表示记录所在域名 => .
+		Params   []SvcParam `json:"params"`   // 参数 => [alpn="h2,h3"]
+	}
+
+	HTTPS SVCB
+
+	SvcParam struct {
+		Key   string `json:"key"`   // 参数名 => alpn
+		Value string `json:"value"` // 参数值, 不含引号 => h2,h3
+	}
+)
+
+func (d SRV) Type() string { return "SRV" }
+
+func (d SRV) String() string {
+	return fmt.Sprintf("%d %d %d %s", d.Priority, d.Weight, d.Port, dataTarget(d.Target))
+}
+
+func (d CAA) Type() string { return "CAA" }
+
+func (d CAA) String() string {
+	return fmt.Sprintf("%d %s %s", d.Flags, strings.ToLower(d.Tag), dataQuote(d.Value))
+}
+
+func (d TXT) Type() string { return "TXT" }
+
+func (d TXT) String() string { return d.Value }
+
+// Chunks 按255字节拆分, 即区域文件中的多个字符串
+func (d TXT) Chunks() (chunks []string) {
+	for v := d.Value; len(v) > 0; v = v[min(len(v), 255):] {
+		chunks = append(chunks, v[:min(len(v), 255)])
+	}
+	return
+}
+
+func (d SVCB) Type() string { return "SVCB" }
+
+func (d SVCB) String() string {
+	return strings.TrimSpace(fmt.Sprintf("%d %s %s", d.Priority, dataTarget(d.Target), d.ParamsString()))
+}
+
+// ParamsString 返回以空格分隔的参数 => alpn="h2,h3" ipv4hint="192.0.2.1"
+func (d SVCB) ParamsString() string {
+	var params []string
+	for _, p := range d.Params {
+		params = append(params, p.String())
+	}
+	return strings.Join(params, " ")
+}
+
+func (d HTTPS) Type() string { return "HTTPS" }
+
+func (d HTTPS) String() string { return SVCB(d).String() }
+
+func (d HTTPS) ParamsString() string { return SVCB(d).ParamsString() }
+
+func (p SvcParam) String() string {
+	if p.Value == "" {
+		return p.Key
+	}
+	return p.Key + "=" + dataQuote(p.Value)
+}
+
+// ParseRecordData 解析 SRV、CAA、TXT、SVCB、HTTPS 记录值, 其他类型返回 ErrUnsupported
+func ParseRecordData(typ, value string) (data RecordData, err error) {
+	switch strings.ToUpper(typ) {
+	case "SRV":
+		data, err = parseSRV(value)
+	case "CAA":
+		data, err = parseCAA(value)
+	case "TXT":
+		data = TXT{value}
+	case "SVCB":
+		data, err = parseSVCB(value)
+	case "HTTPS":
+		var d SVCB
+		d, err = parseSVCB(value)
+		data = HTTPS(d)
+	default:
+		return nil, &Error{Kind: ErrUnsupported, Err: fmt.Errorf("no structured data for %s records", typ)}
+	}
+	if err != nil {
+		return nil, &Error{Kind: ErrInvalidInput, Err: fmt.Errorf("invalid %s value %q: %w", strings.ToUpper(typ), value, err)}
+	}
+	return
+}
+
+// Data 将 Value 解析为结构化的记录值
+func (r RecordListRespRecord) Data() (data RecordData, err error) {
+	return ParseRecordData(r.Type, r.Value)
+}
+
+// recordValue Data 不为空时以 Data 为准
+func recordValue(typ, value string, data RecordData) (string, string) {
+	if data == nil {
+		return typ, value
+	}
+	return data.Type(), data.String()
+}
+
+func parseSRV(value string) (d SRV, err error) {
+	fields := strings.Fields(value)
+	if len(fields) != 4 {
+		return d, fmt.Errorf("want 4 fields, got %d", len(fields))
+	}
+	var nums [3]uint64
+	for i := range nums {
+		if nums[i], err = strconv.ParseUint(fields[i], 10, 16); err != nil {
+			return
+		}
+	}
+	return SRV{uint16(nums[0]), uint16(nums[1]), uint16(nums[2]), dataTarget(fields[3])}, nil
+}
+
+func parseCAA(value string) (d CAA, err error) {
+	fields := strings.SplitN(strings.TrimSpace(value), " ", 3)
+	if len(fields) != 3 {
+		return d, fmt.Errorf("want 3 fields, got %d", len(fields))
+	}
+	flags, err := strconv.ParseUint(fields[0], 10, 8)
+	if err != nil {
+		return
+	}
+	return CAA{uint8(flags), strings.ToLower(fields[1]), dataUnquote(strings.TrimSpace(fields[2]))}, nil
+}
+
+func parseSVCB(value string) (d SVCB, err error) {
+	fields := dataFields(value)
+	if len(fields) < 2 {
+		return d, fmt.Errorf("want at least 2 fields, got %d", len(fields))
+	}
+	priority, err := strconv.ParseUint(fields[0], 10, 16)
+	if err != nil {
+		return
+	}
+	d = SVCB{Priority: uint16(priority), Target: dataTarget(fields[1])}
+	for _, f := range fields[2:] {
+		k, v, _ := strings.Cut(f, "=")
+		d.Params = append(d.Params, SvcParam{strings.ToLower(k), dataUnquote(v)})
+	}
+	return
+}
+
+// dataTarget 去掉主机名末尾的点, 单独的 . 保留
+func dataTarget(target string) string {
+	if target == "." || target == "" {
+		return "."
+	}
+	return strings.TrimSuffix(target, ".")
+}
+
+// dataQuote 按区域文件的字符串格式加引号, 转义 " 与 \, 不可打印字符转为 \DDD
+func dataQuote(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&sb, "\\%03d", c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// dataUnquote 去掉引号并还原 \X 与 \DDD 转义, 不带引号时原样返回
+func dataUnquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	s = s[1 : len(s)-1]
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) {
+			if i+4 <= len(s) {
+				if n, err := strconv.ParseUint(s[i+1:i+4], 10, 8); err == nil {
+					sb.WriteByte(byte(n))
+					i += 3
+					continue
+				}
+			}
+			i++
+			c = s[i]
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// dataFields 按空格拆分, 引号内的空格不拆分
+func dataFields(s string) (fields []string) {
+	var sb strings.Builder
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && quoted && i+1 < len(s):
+			sb.WriteByte(c)
+			i++
+			sb.WriteByte(s[i])
+		case c == '"':
+			quoted = !quoted
+			sb.WriteByte(c)
+		case (c == ' ' || c == '\t') && !quoted:
+			if sb.Len() > 0 {
+				fields = append(fields, sb.String())
+				sb.Reset()
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	if sb.Len() > 0 {
+		fields = append(fields, sb.String())
+	}
+	return
+}
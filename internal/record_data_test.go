@@ -0,0 +1,164 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alibabacloud-go/tea/tea"
+	"github.com/cloudflare/cloudflare-go"
+)
+
+func TestParseRecordData(t *testing.T) {
+	tests := []struct {
+		typ, value string
+		want       RecordData
+		str        string    // String 的结果
+		kind       ErrorKind // 非空时期望错误
+	}{
+		{"SRV", "10 5 5060 sip.example.com.", SRV{10, 5, 5060, "sip.example.com"}, "10 5 5060 sip.example.com", ""},
+		{"srv", "0 0 0 .", SRV{0, 0, 0, "."}, "0 0 0 .", ""},
+		{"SRV", "10 5 5060", nil, "", ErrInvalidInput},
+		{"SRV", "10 5 70000 sip.example.com", nil, "", ErrInvalidInput},
+		{"SRV", "a 5 5060 sip.example.com", nil, "", ErrInvalidInput},
+		{"CAA", `0 issue "letsencrypt.org"`, CAA{0, "issue", "letsencrypt.org"}, `0 issue "letsencrypt.org"`, ""},
+		{"CAA", `128 ISSUEWILD ";"`, CAA{128, "issuewild", ";"}, `128 issuewild ";"`, ""},
+		{"CAA", "0 iodef mailto:security@example.com", CAA{0, "iodef", "mailto:security@example.com"}, `0 iodef "mailto:security@example.com"`, ""},
+		{"CAA", `0 issue "say \"hi\" \\ \009"`, CAA{0, "issue", "say \"hi\" \\ \t"}, `0 issue "say \"hi\" \\ \009"`, ""},
+		{"CAA", `0 issue "ca.example.net; account=a b"`, CAA{0, "issue", "ca.example.net; account=a b"}, `0 issue "ca.example.net; account=a b"`, ""},
+		{"CAA", "0 issue", nil, "", ErrInvalidInput},
+		{"CAA", `256 issue "ca"`, nil, "", ErrInvalidInput},
+		{"TXT", "v=spf1 -all", TXT{"v=spf1 -all"}, "v=spf1 -all", ""},
+		{"TXT", "", TXT{""}, "", ""},
+		{"SVCB", `1 svc.example.com. alpn="h2,h3" port=8443`, SVCB{1, "svc.example.com", []SvcParam{{"alpn", "h2,h3"}, {"port", "8443"}}}, `1 svc.example.com alpn="h2,h3" port="8443"`, ""},
+		{"SVCB", "0 target.example.com", SVCB{0, "target.example.com", nil}, "0 target.example.com", ""},
+		{"HTTPS", `1 . ALPN=h3 no-default-alpn`, HTTPS{1, ".", []SvcParam{{"alpn", "h3"}, {"no-default-alpn", ""}}}, `1 . alpn="h3" no-default-alpn`, ""},
+		{"HTTPS", `1 . ech="a b"`, HTTPS{1, ".", []SvcParam{{"ech", "a b"}}}, `1 . ech="a b"`, ""},
+		{"HTTPS", "1", nil, "", ErrInvalidInput},
+		{"SVCB", "x .", nil, "", ErrInvalidInput},
+		{"A", "192.0.2.1", nil, "", ErrUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ+" "+tt.value, func(t *testing.T) {
+			d, err := ParseRecordData(tt.typ, tt.value)
+			if tt.kind != "" {
+				if KindOf(err) != tt.kind || d != nil {
+					t.Fatalf("ParseRecordData = %#v, %v, want %s", d, err, tt.kind)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(d, tt.want) {
+				t.Fatalf("ParseRecordData = %#v, %v, want %#v", d, err, tt.want)
+			}
+			if d.Type() != strings.ToUpper(tt.typ) || d.String() != tt.str {
+				t.Fatalf("Type, String = %s, %s, want %s, %s", d.Type(), d.String(), strings.ToUpper(tt.typ), tt.str)
+			}
+			// String 的结果可再次解析为相同的值
+			if again, err := ParseRecordData(tt.typ, d.String()); err != nil || !reflect.DeepEqual(again, d) {
+				t.Fatalf("reparse = %#v, %v, want %#v", again, err, d)
+			}
+		})
+	}
+}
+
+func TestDataQuote(t *testing.T) {
+	tests := []struct{ raw, quoted string }{
+		{"", `""`},
+		{"letsencrypt.org", `"letsencrypt.org"`},
+		{`a"b\c`, `"a\"b\\c"`},
+		{"tab\tnl\n", `"tab\009nl\010"`},
+		{"\x7f\xff", `"\127\255"`},
+		{"中", `"\228\184\173"`},
+	}
+	for _, tt := range tests {
+		if got := dataQuote(tt.raw); got != tt.quoted {
+			t.Errorf("dataQuote(%q) = %s, want %s", tt.raw, got, tt.quoted)
+		}
+		if got := dataUnquote(tt.quoted); got != tt.raw {
+			t.Errorf("dataUnquote(%s) = %q, want %q", tt.quoted, got, tt.raw)
+		}
+	}
+	for in, want := range map[string]string{
+		`plain`:      "plain",
+		`"`:          `"`,
+		`"a\x"`:      "ax",
+		`"\12"`:      "12",
+		`"\300x"`:    "300x",
+		`"trail\"`:   `trail\`,
+		`"\065\066"`: "AB",
+	} {
+		if got := dataUnquote(in); got != want {
+			t.Errorf("dataUnquote(%s) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTXTChunks(t *testing.T) {
+	long := strings.Repeat("a", 255) + strings.Repeat("b", 10)
+	if got := (TXT{long}).Chunks(); len(got) != 2 || len(got[0]) != 255 || got[1] != strings.Repeat("b", 10) {
+		t.Fatalf("Chunks = %q", got)
+	}
+	if got := (TXT{}).Chunks(); got != nil {
+		t.Fatalf("empty Chunks = %q, want nil", got)
+	}
+}
+
+func TestCloudflareData(t *testing.T) {
+	tests := []struct {
+		typ, value string
+		mx         uint16
+		content    string
+		data       any
+		priority   uint16
+	}{
+		{"A", "192.0.2.1", 0, "192.0.2.1", nil, 1}, // 未设置的优先级为1
+		{"MX", "mail.example.com", 10, "mail.example.com", nil, 10},
+		{"SRV", "10 5 5060 sip.example.com", 0, "", map[string]any{"priority": uint16(10), "weight": uint16(5), "port": uint16(5060), "target": "sip.example.com"}, 10},
+		{"CAA", `0 issue "letsencrypt.org"`, 0, "", map[string]any{"flags": uint8(0), "tag": "issue", "value": "letsencrypt.org"}, 1},
+		{"HTTPS", `1 . alpn="h2,h3"`, 0, "", map[string]any{"priority": uint16(1), "target": ".", "value": `alpn="h2,h3"`}, 1},
+		{"SVCB", `2 svc.example.com port="8443"`, 0, "", map[string]any{"priority": uint16(2), "target": "svc.example.com", "value": `port="8443"`}, 2},
+		{"SRV", "malformed", 0, "malformed", nil, 1}, // 无法解析时原样提交, 由服务商返回错误
+		{"TXT", "v=spf1 -all", 0, "v=spf1 -all", nil, 1},
+	}
+	for _, tt := range tests {
+		content, data, priority := cloudflareData(tt.typ, tt.value, tt.mx)
+		if content != tt.content || !reflect.DeepEqual(data, tt.data) || priority != tt.priority {
+			t.Errorf("cloudflareData(%s %q) = %q, %#v, %d", tt.typ, tt.value, content, data, priority)
+		}
+	}
+}
+
+func TestCloudflareValue(t *testing.T) {
+	tests := []struct {
+		record cloudflare.DNSRecord
+		want   string
+	}{
+		{cloudflare.DNSRecord{Type: "A", Content: "192.0.2.1"}, "192.0.2.1"},
+		{cloudflare.DNSRecord{Type: "SRV", Data: map[string]any{"priority": 10.0, "weight": 5.0, "port": 5060.0, "target": "sip.example.com"}}, "10 5 5060 sip.example.com"},
+		{cloudflare.DNSRecord{Type: "SRV", Content: "5 5060 sip.example.com", Priority: tea.Uint16(10)}, "10 5 5060 sip.example.com"},
+		{cloudflare.DNSRecord{Type: "SRV", Content: "10 5 5060 sip.example.com"}, "10 5 5060 sip.example.com"},
+		{cloudflare.DNSRecord{Type: "CAA", Data: map[string]any{"flags": 0.0, "tag": "issue", "value": `a"b`}}, `0 issue "a\"b"`},
+		{cloudflare.DNSRecord{Type: "CAA", Content: `0 issue "letsencrypt.org"`}, `0 issue "letsencrypt.org"`},
+		{cloudflare.DNSRecord{Type: "HTTPS", Data: map[string]any{"priority": 1.0, "target": ".", "value": `alpn="h2,h3"`}}, `1 . alpn="h2,h3"`},
+		{cloudflare.DNSRecord{Type: "SVCB", Data: map[string]any{"priority": "bad"}, Content: "raw"}, "raw"},
+		{cloudflare.DNSRecord{Type: "TXT", Content: `"v=spf1 " "-all"`}, "v=spf1 -all"},
+		{cloudflare.DNSRecord{Type: "TXT", Content: `"say \"hi\""`}, `say "hi"`},
+		{cloudflare.DNSRecord{Type: "TXT", Content: "unquoted text"}, "unquoted text"},
+	}
+	for _, tt := range tests {
+		if got := cloudflareValue(tt.record); got != tt.want {
+			t.Errorf("cloudflareValue(%s %q %v) = %q, want %q", tt.record.Type, tt.record.Content, tt.record.Data, got, tt.want)
+		}
+	}
+}
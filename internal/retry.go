@@ -0,0 +1,116 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	defaultRetryAttempts  = 3
+	defaultRetryBaseDelay = time.Millisecond * 200
+	defaultRetryMaxDelay  = time.Second * 10
+)
+
+// idempotentOperations 默认重试的操作, 新增类操作重试可能产生重复记录
+var idempotentOperations = []Operation{
+	OpPing,
+	OpLineList,
+	OpLineDefault,
+	OpDomainList,
+	OpDomainDelete,
+	OpDomainGet,
+	OpDomainEnable,
+	OpDomainDisable,
+	OpRecordList,
+	OpRecordUpdate,
+	OpRecordDelete,
+	OpRecordEnable,
+	OpRecordDisable,
+}
+
+type RetryOpts struct {
+	MaxAttempts int                                                             // 最大尝试次数(含首次), 默认3
+	BaseDelay   time.Duration                                                   // 首次重试的退避上限, 之后每次翻倍, 默认200ms
+	MaxDelay    time.Duration                                                   // 单次退避上限, 默认10s
+	Operations  []Operation                                                     // 重试的操作, 默认为查询、修改、删除、启停
+	Retryable   func(err error) bool                                            // 是否重试, 默认为限流与服务不可用
+	OnRetry     func(op Operation, attempt int, err error, delay time.Duration) // 每次重试前调用
+}
+
+// Retry 返回重试拦截器, 退避时间为 [0, min(MaxDelay, BaseDelay*2^n)) 的随机值,
+// 错误带有 RetryAfter 时至少等待该时长; ctx 在等待期间结束时返回最后一次的错误
+func Retry(opts RetryOpts) Interceptor {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultRetryAttempts
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = defaultRetryBaseDelay
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = defaultRetryMaxDelay
+	}
+	if opts.Operations == nil {
+		opts.Operations = idempotentOperations
+	}
+	if opts.Retryable == nil {
+		opts.Retryable = retryable
+	}
+	ops := Capabilities{Operations: opts.Operations}
+	return func(ctx context.Context, op Operation, req any, next Invoker) (resp any, err error) {
+		if !ops.Supports(op) {
+			return next(ctx, op, req)
+		}
+		for attempt := 1; ; attempt++ {
+			if resp, err = next(ctx, op, req); err == nil || attempt >= opts.MaxAttempts || !opts.Retryable(err) {
+				return
+			}
+			delay := backoff(opts.BaseDelay, opts.MaxDelay, attempt)
+			var e *Error
+			if errors.As(err, &e) && e.RetryAfter > delay {
+				delay = e.RetryAfter
+			}
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+				return
+			}
+			if opts.OnRetry != nil {
+				opts.OnRetry(op, attempt, err, delay)
+			}
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+func retryable(err error) bool {
+	switch KindOf(err) {
+	case ErrRateLimited, ErrProviderUnavailable:
+		return true
+	}
+	return false
+}
+
+func backoff(base, max time.Duration, attempt int) time.Duration {
+	ceil := base << (attempt - 1)
+	if ceil <= 0 || ceil > max {
+		ceil = max
+	}
+	return rand.N(ceil)
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// failing 返回前 n 次调用失败的内存实现
+func failing(n int, err error) (api Api, calls *int) {
+	calls = new(int)
+	api = MemoryApi(MemoryOpts{Fault: func(op Operation) error {
+		if *calls++; *calls <= n {
+			return err
+		}
+		return nil
+	}})
+	return
+}
+
+func TestRetry(t *testing.T) {
+	limited := &Error{Kind: ErrRateLimited, Err: errors.New("too many requests")}
+	tests := []struct {
+		name      string
+		failures  int
+		err       error
+		op        func(api Api) error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"recovers", 2, limited, domainList, 3, false},
+		{"gives up", 5, limited, domainList, 3, true},
+		{"unavailable", 1, &Error{Kind: ErrProviderUnavailable}, domainList, 2, false},
+		{"not retryable", 5, &Error{Kind: ErrNotFound}, domainList, 1, true},
+		{"unknown error", 5, errors.New("boom"), domainList, 1, true},
+		{"not idempotent", 5, limited, func(api Api) error {
+			_, err := api.DomainAdd(DomainAddReq{Domain: testDomain})
+			return err
+		}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, calls := failing(tt.failures, tt.err)
+			var retries int
+			api = Wrap(api, Retry(RetryOpts{BaseDelay: time.Millisecond, OnRetry: func(Operation, int, error, time.Duration) { retries++ }}))
+			err := tt.op(api)
+			if (err != nil) != tt.wantErr || *calls != tt.wantCalls || retries != tt.wantCalls-1 {
+				t.Fatalf("err = %v, calls = %d, retries = %d, want error %v after %d calls", err, *calls, retries, tt.wantErr, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func domainList(api Api) error {
+	_, err := api.DomainList(DomainListReq{})
+	return err
+}
+
+func TestRetryAfter(t *testing.T) {
+	api, _ := failing(1, &Error{Kind: ErrRateLimited, RetryAfter: time.Millisecond * 30})
+	var delays []time.Duration
+	api = Wrap(api, Retry(RetryOpts{BaseDelay: time.Millisecond, OnRetry: func(_ Operation, _ int, _ error, d time.Duration) { delays = append(delays, d) }}))
+	start := time.Now()
+	if err := domainList(api); err != nil {
+		t.Fatal(err)
+	}
+	if len(delays) != 1 || delays[0] != time.Millisecond*30 {
+		t.Fatalf("delays = %v, want [30ms]", delays)
+	}
+	if elapsed := time.Since(start); elapsed < time.Millisecond*30 {
+		t.Fatalf("elapsed = %v, want at least RetryAfter", elapsed)
+	}
+}
+
+func TestRetryDeadline(t *testing.T) {
+	api, calls := failing(5, &Error{Kind: ErrRateLimited, RetryAfter: time.Hour})
+	api = Wrap(api, Retry(RetryOpts{}))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := api.DomainListContext(ctx, DomainListReq{}); KindOf(err) != ErrRateLimited || *calls != 1 {
+		t.Fatalf("err = %v, calls = %d, want the rate limit error without waiting", err, *calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base, max := time.Millisecond*100, time.Second
+	for attempt, ceil := range map[int]time.Duration{1: base, 2: base * 2, 4: base * 8, 5: max, 64: max} {
+		for range 100 {
+			if d := backoff(base, max, attempt); d < 0 || d >= ceil {
+				t.Fatalf("backoff(%d) = %v, want [0, %v)", attempt, d, ceil)
+			}
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	SyncAdd    SyncAction = "add"
+	SyncUpdate SyncAction = "update"
+	SyncDelete SyncAction = "delete"
+)
+
+type (
+	SyncAction string // 同步动作 => add
+
+	SyncRecord struct {
+		Record  string   `json:"record"`  // 主机记录 => www
+		Type    string   `json:"type"`    // 类型 => A
+		Value   string   `json:"value"`   // 记录值 => 1.1.1.1
+		Line    string   `json:"line"`    // 线路Id或通用线路, 为空时使用默认线路 => telecom
+		TTL     uint     `json:"ttl"`     // TTL, 0表示不比较 => 600
+		MX      uint16   `json:"mx"`      // MX优先级, 0表示不比较 => 10
+		Weight  uint     `json:"weight"`  // 权重, 0表示不比较 => 100
+		Remark  string   `json:"remark"`  // 备注 => managed by dnsdk
+		Proxied *bool    `json:"proxied"` // 是否开启代理, nil表示不比较 => true
+		Tags    []string `json:"tags"`    // 标签, nil表示不比较 => [env:prod]
+	}
+
+	SyncReq struct {
+		DomainId string                            // 域名Id => xxxxxxxxxxxx
+		Domain   string                            // 域名 => example.com
+		Records  []SyncRecord                      // 期望的全部记录
+		Managed  func(r RecordListRespRecord) bool // 现有记录是否由同步管理, nil表示全部; 未管理的记录不会被修改或删除
+		NoDelete bool                              // 不删除期望之外的记录
+	}
+
+	SyncChange struct {
+		Action  SyncAction           `json:"action"`  // 动作 => update
+		Current RecordListRespRecord `json:"current"` // 现有记录, update/delete 时有效
+		Desired SyncRecord           `json:"desired"` // 期望记录, add/update 时有效
+	}
+
+	SyncPlan struct {
+		DomainId  string                 `json:"domain_id"` // 域名Id => xxxxxxxxxxxx
+		Domain    string                 `json:"domain"`    // 域名 => example.com
+		Changes   []SyncChange           `json:"changes"`   // 按 delete、update、add 顺序排列
+		Unmanaged []RecordListRespRecord `json:"unmanaged"` // 未管理而跳过的记录
+	}
+)
+
+// PlanSync 读取现有记录并与 req.Records 比较, 按 主机记录+类型+线路+记录值 匹配;
+// 同一 主机记录+类型+线路 下未匹配的记录优先转为修改, 其余为新增或删除
+func PlanSync(ctx context.Context, api Api, req SyncReq) (plan SyncPlan, err error) {
+	plan = SyncPlan{DomainId: req.DomainId, Domain: req.Domain}
+	defLine := api.LineDefaultContext(ctx).Id
+	caps := api.Capabilities()
+
+	desired := make([]SyncRecord, 0, len(req.Records))
+	seen := make(map[string]struct{})
+	for _, r := range req.Records {
+		r.Record, r.Type = syncRecord(r.Record), strings.ToUpper(r.Type)
+		if r.Line == "" {
+			r.Line = defLine
+		}
+		key := syncKey(r.Record, r.Type, r.Line, r.Value)
+		if _, dup := seen[key]; dup {
+			err = &Error{Kind: ErrInvalidInput, Err: fmt.Errorf("重复的期望记录: %s %s %s", r.Record, r.Type, r.Value)}
+			return
+		}
+		seen[key] = struct{}{}
+		desired = append(desired, r)
+	}
+
+	var current []RecordListRespRecord
+	for r, err0 := range AllRecords(ctx, api, RecordListReq{DomainId: req.DomainId, Domain: req.Domain}) {
+		if err = err0; err != nil {
+			return
+		}
+		if req.Managed != nil && !req.Managed(r) {
+			plan.Unmanaged = append(plan.Unmanaged, r)
+			continue
+		}
+		current = append(current, r)
+	}
+
+	var (
+		matched = make([]bool, len(current))
+		used    = make([]bool, len(desired))
+		updates []SyncChange
+		adds    []SyncChange
+		deletes []SyncChange
+	)
+	match := func(sameValue bool) {
+		for i, d := range desired {
+			if used[i] {
+				continue
+			}
+			for j, c := range current {
+				if matched[j] || syncRecord(c.Record) != d.Record || !strings.EqualFold(c.Type, d.Type) || !syncLineEqual(c, d.Line) {
+					continue
+				}
+				if sameValue && !syncValueEqual(d.Type, c.Value, d.Value) {
+					continue
+				}
+				used[i], matched[j] = true, true
+				if !sameValue || syncDiffers(caps, c, d) {
+					updates = append(updates, SyncChange{Action: SyncUpdate, Current: c, Desired: d})
+				}
+				break
+			}
+		}
+	}
+	match(true)
+	match(false)
+	for i, d := range desired {
+		if !used[i] {
+			adds = append(adds, SyncChange{Action: SyncAdd, Desired: d})
+		}
+	}
+	if !req.NoDelete {
+		for j, c := range current {
+			if !matched[j] {
+				deletes = append(deletes, SyncChange{Action: SyncDelete, Current: c})
+			}
+		}
+	}
+	plan.Changes = append(append(deletes, updates...), adds...)
+	return
+}
+
+// ApplySync 按顺序执行 plan, 遇到错误时停止并返回已执行的变更
+func ApplySync(ctx context.Context, api Api, plan SyncPlan) (applied []SyncChange, err error) {
+	for _, c := range plan.Changes {
+		switch c.Action {
+		case SyncAdd:
+			_, err = api.RecordAddContext(ctx, RecordAddReq{
+				DomainId: plan.DomainId,
+				Domain:   plan.Domain,
+				Record:   c.Desired.Record,
+				Type:     c.Desired.Type,
+				Value:    c.Desired.Value,
+				Line:     c.Desired.Line,
+				TTL:      c.Desired.TTL,
+				MX:       c.Desired.MX,
+				Weight:   c.Desired.Weight,
+				Remark:   c.Desired.Remark,
+				Proxied:  c.Desired.Proxied,
+				Tags:     c.Desired.Tags,
+			})
+		case SyncUpdate:
+			ttl, mx, weight := c.Desired.TTL, c.Desired.MX, c.Desired.Weight
+			if ttl == 0 {
+				ttl = c.Current.TTL
+			}
+			if mx == 0 {
+				mx = c.Current.MX
+			}
+			if weight == 0 {
+				weight = c.Current.Weight
+			}
+			var tags *[]string
+			if c.Desired.Tags != nil {
+				tags = &c.Desired.Tags
+			}
+			_, err = api.RecordUpdateContext(ctx, RecordUpdateReq{
+				RecordId: c.Current.Id,
+				DomainId: plan.DomainId,
+				Domain:   plan.Domain,
+				Record:   c.Desired.Record,
+				Type:     c.Desired.Type,
+				Value:    c.Desired.Value,
+				Line:     c.Desired.Line,
+				TTL:      ttl,
+				MX:       mx,
+				Weight:   weight,
+				Remark:   &c.Desired.Remark,
+				Proxied:  c.Desired.Proxied,
+				Tags:     tags,
+			})
+		case SyncDelete:
+			err = api.RecordDeleteContext(ctx, RecordDeleteReq{RecordId: c.Current.Id, DomainId: plan.DomainId})
+		}
+		if err != nil {
+			err = fmt.Errorf("%s: %w", c, err)
+			return
+		}
+		applied = append(applied, c)
+	}
+	return
+}
+
+// String 输出 dry-run 结果, 每行一个变更
+func (p SyncPlan) String() string {
+	var sb strings.Builder
+	for _, c := range p.Changes {
+		sb.WriteString(c.String())
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func (c SyncChange) String() string {
+	switch c.Action {
+	case SyncAdd:
+		return fmt.Sprintf("+ %s %s %s (line=%s ttl=%d)", c.Desired.Record, c.Desired.Type, c.Desired.Value, c.Desired.Line, c.Desired.TTL)
+	case SyncUpdate:
+		return fmt.Sprintf("~ %s %s %s -> %s (line=%s ttl=%d -> %d)", c.Desired.Record, c.Desired.Type, c.Current.Value, c.Desired.Value, c.Desired.Line, c.Current.TTL, c.Desired.TTL)
+	case SyncDelete:
+		return fmt.Sprintf("- %s %s %s (line=%s id=%s)", syncRecord(c.Current.Record), c.Current.Type, c.Current.Value, c.Current.Line, c.Current.Id)
+	}
+	return string(c.Action)
+}
+
+func syncRecord(record string) string {
+	if record == "" {
+		return "@"
+	}
+	return record
+}
+
+func syncKey(record, typ, line, value string) string {
+	return strings.Join([]string{record, typ, line, value}, "\x00")
+}
+
+// syncLineEqual 期望线路为通用线路时与 CanonicalLine 比较, 否则视为服务商线路Id
+func syncLineEqual(c RecordListRespRecord, line string) bool {
+	return c.Line == line || (c.CanonicalLine != "" && c.CanonicalLine == Line(line))
+}
+
+// syncValueEqual 主机名类记录值忽略大小写与末尾的点
+func syncValueEqual(typ, a, b string) bool {
+	switch strings.ToUpper(typ) {
+	case "CNAME", "NS", "MX", "PTR", "SRV":
+		return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+	case "CAA", "HTTPS", "SVCB":
+		x, err0 := ParseRecordData(typ, a)
+		y, err1 := ParseRecordData(typ, b)
+		if err0 == nil && err1 == nil {
+			return x.String() == y.String()
+		}
+	}
+	return a == b
+}
+
+// syncDiffers 仅比较服务商支持且期望值已设置的字段
+func syncDiffers(caps Capabilities, c RecordListRespRecord, d SyncRecord) bool {
+	return (d.TTL != 0 && d.TTL != c.TTL) ||
+		(caps.MXPriority && d.MX != 0 && d.MX != c.MX) ||
+		(caps.Weight && d.Weight != 0 && d.Weight != c.Weight) ||
+		(caps.Remark && d.Remark != c.Remark) ||
+		(caps.Proxied && d.Proxied != nil && *d.Proxied != c.Proxied) ||
+		(caps.Tags && d.Tags != nil && !slices.Equal(d.Tags, c.Tags))
+}
@@ -0,0 +1,225 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testDomain = "example.com"
+
+// newTestApi 返回已添加 testDomain 及 records 的内存实现
+func newTestApi(t *testing.T, records ...RecordAddReq) (api Api, domainId string) {
+	t.Helper()
+	api = MemoryApi(MemoryOpts{})
+	resp, err := api.DomainAdd(DomainAddReq{Domain: testDomain})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range records {
+		r.DomainId, r.Domain = resp.Id, testDomain
+		if _, err = api.RecordAdd(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return api, resp.Id
+}
+
+// testRecords 返回记录的 主机记录 类型 记录值 线路 TTL, 按字典序排列
+func testRecords(t *testing.T, api Api, domainId string) (lines []string) {
+	t.Helper()
+	resp, err := api.RecordList(RecordListReq{DomainId: domainId, Domain: testDomain})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range resp.List {
+		lines = append(lines, strings.Join([]string{r.Record, r.Type, r.Value, r.Line, strconv.FormatUint(uint64(r.TTL), 10)}, " "))
+	}
+	slices.Sort(lines)
+	return
+}
+
+func TestPlanSync(t *testing.T) {
+	existing := []RecordAddReq{
+		{Record: "www", Type: "A", Value: "192.0.2.1", TTL: 600},
+		{Record: "www", Type: "A", Value: "192.0.2.2", TTL: 600},
+		{Record: "api", Type: "CNAME", Value: "Target.example.net.", TTL: 600},
+		{Record: "old", Type: "TXT", Value: "stale", TTL: 600},
+		{Record: "keep", Type: "TXT", Value: "manual", TTL: 600, Remark: "manual"},
+	}
+	managed := func(r RecordListRespRecord) bool { return r.Remark != "manual" }
+	tests := []struct {
+		name     string
+		records  []SyncRecord
+		noDelete bool
+		want     []string
+	}{
+		{
+			name: "unchanged",
+			records: []SyncRecord{
+				{Record: "www", Type: "a", Value: "192.0.2.1"},
+				{Record: "www", Type: "A", Value: "192.0.2.2", TTL: 600},
+				{Record: "api", Type: "CNAME", Value: "target.example.net"},
+				{Record: "old", Type: "TXT", Value: "stale"},
+			},
+		},
+		{
+			name: "ttl update",
+			records: []SyncRecord{
+				{Record: "www", Type: "A", Value: "192.0.2.1", TTL: 300},
+				{Record: "www", Type: "A", Value: "192.0.2.2"},
+				{Record: "api", Type: "CNAME", Value: "target.example.net"},
+				{Record: "old", Type: "TXT", Value: "stale"},
+			},
+			want: []string{"~ www A 192.0.2.1 -> 192.0.2.1 (line=default ttl=600 -> 300)"},
+		},
+		{
+			name: "value change becomes update",
+			records: []SyncRecord{
+				{Record: "www", Type: "A", Value: "192.0.2.1"},
+				{Record: "www", Type: "A", Value: "192.0.2.3"},
+				{Record: "api", Type: "CNAME", Value: "target.example.net"},
+				{Record: "old", Type: "TXT", Value: "stale"},
+			},
+			want: []string{"~ www A 192.0.2.2 -> 192.0.2.3 (line=default ttl=600 -> 0)"},
+		},
+		{
+			name: "add and delete",
+			records: []SyncRecord{
+				{Record: "www", Type: "A", Value: "192.0.2.1"},
+				{Record: "www", Type: "A", Value: "192.0.2.2"},
+				{Record: "api", Type: "CNAME", Value: "target.example.net"},
+				{Record: "new", Type: "A", Value: "192.0.2.9", TTL: 600},
+			},
+			want: []string{"- old TXT stale", "+ new A 192.0.2.9 (line=default ttl=600)"},
+		},
+		{
+			name:     "no delete",
+			records:  []SyncRecord{{Record: "www", Type: "A", Value: "192.0.2.1"}},
+			noDelete: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, domainId := newTestApi(t, existing...)
+			plan, err := PlanSync(context.Background(), api, SyncReq{DomainId: domainId, Domain: testDomain, Records: tt.records, Managed: managed, NoDelete: tt.noDelete})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range plan.Changes {
+				s := c.String()
+				if c.Action == SyncDelete {
+					s = s[:strings.Index(s, " (")]
+				}
+				got = append(got, s)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("changes = %q, want %q", got, tt.want)
+			}
+			if len(plan.Unmanaged) != 1 || plan.Unmanaged[0].Record != "keep" {
+				t.Fatalf("unmanaged = %+v, want keep", plan.Unmanaged)
+			}
+		})
+	}
+}
+
+func TestPlanSyncCanonicalLine(t *testing.T) {
+	api, domainId := newTestApi(t,
+		RecordAddReq{Record: "www", Type: "A", Value: "192.0.2.1", Line: "oversea", TTL: 600},
+		RecordAddReq{Record: "www", Type: "A", Value: "192.0.2.2", Line: string(ProvinceLine(LineTelecom, "beijing")), TTL: 600},
+	)
+	tests := []struct {
+		name  string
+		lines [2]string
+		want  int
+	}{
+		{"native ids", [2]string{"oversea", "cn_telecom_beijing"}, 0},
+		{"canonical lines", [2]string{string(LineOverseas), "telecom.beijing"}, 0},
+		{"other line", [2]string{string(LineOverseas), string(LineTelecom)}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := PlanSync(context.Background(), api, SyncReq{DomainId: domainId, Domain: testDomain, Records: []SyncRecord{
+				{Record: "www", Type: "A", Value: "192.0.2.1", Line: tt.lines[0]},
+				{Record: "www", Type: "A", Value: "192.0.2.2", Line: tt.lines[1]},
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(plan.Changes) != tt.want {
+				t.Fatalf("changes = %v, want %d", plan.Changes, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanSyncDuplicate(t *testing.T) {
+	api, domainId := newTestApi(t)
+	records := []SyncRecord{{Record: "www", Type: "A", Value: "192.0.2.1"}, {Record: "www", Type: "a", Value: "192.0.2.1"}}
+	_, err := PlanSync(context.Background(), api, SyncReq{DomainId: domainId, Domain: testDomain, Records: records})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("err = %v, want ErrInvalidInput", err)
+	}
+}
+
+func TestApplySync(t *testing.T) {
+	api, domainId := newTestApi(t,
+		RecordAddReq{Record: "www", Type: "A", Value: "192.0.2.1", TTL: 600},
+		RecordAddReq{Record: "old", Type: "TXT", Value: "stale", TTL: 600},
+	)
+	req := SyncReq{DomainId: domainId, Domain: testDomain, Records: []SyncRecord{
+		{Record: "www", Type: "A", Value: "192.0.2.2"},
+		{Record: "@", Type: "MX", Value: "mail.example.com", TTL: 300, MX: 10},
+	}}
+	plan, err := PlanSync(context.Background(), api, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := ApplySync(context.Background(), api, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 3 {
+		t.Fatalf("applied %d changes, want 3", len(applied))
+	}
+	want := []string{"@ MX mail.example.com default 300", "www A 192.0.2.2 default 600"}
+	if got := testRecords(t, api, domainId); !slices.Equal(got, want) {
+		t.Fatalf("records = %q, want %q", got, want)
+	}
+	if plan, err = PlanSync(context.Background(), api, req); err != nil || len(plan.Changes) != 0 {
+		t.Fatalf("second plan = %v %v, want no changes", plan.Changes, err)
+	}
+}
+
+func TestApplySyncStopsOnError(t *testing.T) {
+	fault := errors.New("fault")
+	api := MemoryApi(MemoryOpts{Fault: func(op Operation) error {
+		if op == OpRecordAdd {
+			return fault
+		}
+		return nil
+	}})
+	plan := SyncPlan{Domain: testDomain, Changes: []SyncChange{
+		{Action: SyncAdd, Desired: SyncRecord{Record: "a", Type: "A", Value: "192.0.2.1"}},
+		{Action: SyncAdd, Desired: SyncRecord{Record: "b", Type: "A", Value: "192.0.2.2"}},
+	}}
+	applied, err := ApplySync(context.Background(), api, plan)
+	if !errors.Is(err, fault) || len(applied) != 0 {
+		t.Fatalf("applied = %v, err = %v, want fault before any change", applied, err)
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type (
+	FieldError struct {
+		Field  string `json:"field"`  // 字段 => value
+		Value  string `json:"value"`  // 字段值 => not-an-ip
+		Reason string `json:"reason"` // 原因 => not an IPv4 address
+	}
+
+	// FieldErrors 校验失败的字段, 包装在 Kind 为 ErrInvalidInput 的 *Error 中, 可通过 errors.As 获取
+	FieldErrors []FieldError
+)
+
+func (e FieldErrors) Error() string {
+	var parts []string
+	for _, fe := range e {
+		parts = append(parts, fmt.Sprintf("%s %q: %s", fe.Field, fe.Value, fe.Reason))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Validate 返回校验拦截器, RecordAdd 与 RecordUpdate 未通过校验时返回错误, 不调用服务商
+func Validate(api Api) Interceptor {
+	caps := api.Capabilities()
+	return func(ctx context.Context, op Operation, req any, next Invoker) (resp any, err error) {
+		switch r := req.(type) {
+		case RecordAddReq:
+			err = ValidateRecordAdd(caps, r)
+		case RecordUpdateReq:
+			err = ValidateRecordUpdate(caps, r)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, op, req)
+	}
+}
+
+// ValidateRecordAdd 按 caps 校验主机记录、类型、记录值与 TTL
+func ValidateRecordAdd(caps Capabilities, req RecordAddReq) (err error) {
+	req.Type, req.Value = recordValue(req.Type, req.Value, req.Data)
+	return validateRecord(caps, req.Domain, req.Record, req.Type, req.Value, req.TTL)
+}
+
+func ValidateRecordUpdate(caps Capabilities, req RecordUpdateReq) (err error) {
+	req.Type, req.Value = recordValue(req.Type, req.Value, req.Data)
+	return validateRecord(caps, req.Domain, req.Record, req.Type, req.Value, req.TTL)
+}
+
+func validateRecord(caps Capabilities, domain, record, typ, value string, ttl uint) (err error) {
+	var errs FieldErrors
+	add := func(field, value, reason string) { errs = append(errs, FieldError{field, value, reason}) }
+	record, typ, domain = syncRecord(record), strings.ToUpper(typ), strings.TrimSuffix(domain, ".")
+	if reason := validateRecordName(record, typ); reason != "" {
+		add("record", record, reason)
+	} else if domain != "" && record != "@" && len(record)+1+len(domain) > 253 {
+		add("record", record, "name exceeds 253 characters")
+	}
+	switch {
+	case typ == "":
+		add("type", typ, "type is required")
+	case len(caps.RecordTypes) > 0 && !caps.SupportsRecordType(typ):
+		add("type", typ, "not supported by provider")
+	case typ == "CNAME" && record == "@" && !caps.ApexCNAME:
+		add("record", record, "CNAME at zone apex conflicts with SOA and NS records")
+	}
+	if reason := validateValue(typ, value); reason != "" {
+		add("value", value, reason)
+	} else if typ == "CNAME" && domain != "" && strings.EqualFold(strings.TrimSuffix(value, "."), recordName(record, domain)) {
+		add("value", value, "CNAME points to itself")
+	}
+	if ttl != 0 && ((caps.TTLMin > 0 && ttl < caps.TTLMin) || (caps.TTLMax > 0 && ttl > caps.TTLMax)) {
+		add("ttl", strconv.FormatUint(uint64(ttl), 10), fmt.Sprintf("must be between %d and %d", caps.TTLMin, caps.TTLMax))
+	}
+	if len(errs) > 0 {
+		err = &Error{Kind: ErrInvalidInput, Err: errs}
+	}
+	return
+}
+
+// validateRecordName 主机记录为 @ 或以 . 分隔的标签, 通配符 * 只能是第一个标签
+func validateRecordName(record, typ string) (reason string) {
+	if record == "@" {
+		return
+	}
+	labels := strings.Split(record, ".")
+	for i, label := range labels {
+		if label == "*" {
+			if i == 0 {
+				continue
+			}
+			return "wildcard * must be the leftmost label"
+		}
+		if reason = validateLabel(label); reason != "" {
+			return
+		}
+	}
+	if typ == "SRV" && (len(labels) < 2 || !strings.HasPrefix(labels[0], "_") || !strings.HasPrefix(labels[1], "_")) {
+		return "SRV record must start with _service._proto"
+	}
+	return
+}
+
+// validateLabel 标签长度为1~63, 由字母、数字、- 与 _ 组成, 不能以 - 开头或结尾
+func validateLabel(label string) (reason string) {
+	switch {
+	case label == "":
+		return "empty label"
+	case len(label) > 63:
+		return fmt.Sprintf("label %q exceeds 63 characters", label)
+	case label[0] == '-' || label[len(label)-1] == '-':
+		return fmt.Sprintf("label %q starts or ends with a hyphen", label)
+	}
+	for _, r := range label {
+		if r != '-' && r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return fmt.Sprintf("label %q contains invalid character %q", label, r)
+		}
+	}
+	return
+}
+
+func validateHostname(host string) (reason string) {
+	if _, err := netip.ParseAddr(host); err == nil {
+		return "must be a hostname, not an IP address"
+	}
+	if host = strings.TrimSuffix(host, "."); len(host) > 253 {
+		return "hostname exceeds 253 characters"
+	}
+	for _, label := range strings.Split(host, ".") {
+		if reason = validateLabel(label); reason != "" {
+			return
+		}
+	}
+	return
+}
+
+func validateValue(typ, value string) (reason string) {
+	if value == "" {
+		return "value is required"
+	}
+	switch typ {
+	case "A":
+		if ip, err := netip.ParseAddr(value); err != nil || !ip.Is4() {
+			return "not an IPv4 address"
+		}
+	case "AAAA":
+		if ip, err := netip.ParseAddr(value); err != nil || !ip.Is6() || ip.Zone() != "" {
+			return "not an IPv6 address"
+		}
+	case "CNAME", "NS", "PTR":
+		return validateHostname(value)
+	case "MX":
+		fields := strings.Fields(value)
+		if len(fields) == 2 {
+			if _, err := strconv.ParseUint(fields[0], 10, 16); err != nil {
+				return "invalid MX priority"
+			}
+			fields = fields[1:]
+		}
+		if len(fields) != 1 {
+			return "must be a mail server hostname"
+		}
+		return validateHostname(fields[0])
+	case "TXT", "SPF":
+		if !utf8.ValidString(value) {
+			return "not valid UTF-8"
+		}
+	case "SRV":
+		fields := strings.Fields(value)
+		if len(fields) != 4 {
+			return "must be: priority weight port target"
+		}
+		for i, name := range []string{"priority", "weight", "port"} {
+			if _, err := strconv.ParseUint(fields[i], 10, 16); err != nil {
+				return "invalid SRV " + name
+			}
+		}
+		if fields[3] != "." {
+			return validateHostname(fields[3])
+		}
+	case "CAA":
+		fields := strings.SplitN(value, " ", 3)
+		if len(fields) != 3 {
+			return `must be: flag tag "value"`
+		}
+		if _, err := strconv.ParseUint(fields[0], 10, 8); err != nil {
+			return "invalid CAA flag"
+		}
+		tag, v := strings.ToLower(fields[1]), strings.Trim(fields[2], `"`)
+		if tag == "" || strings.IndexFunc(tag, func(r rune) bool { return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9') }) >= 0 {
+			return "invalid CAA tag"
+		}
+		if tag == "iodef" {
+			if u, err := url.Parse(v); err != nil || (u.Scheme != "mailto" && u.Scheme != "http" && u.Scheme != "https") {
+				return "CAA iodef must be a mailto, http or https URL"
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateRecordAdd(t *testing.T) {
+	long := strings.Repeat("a", 64)
+	tests := []struct {
+		name   string
+		req    RecordAddReq
+		caps   Capabilities
+		fields []string // 校验失败的字段, 为空表示通过
+	}{
+		{"a", RecordAddReq{Record: "www", Type: "A", Value: "192.0.2.1"}, memoryCapabilities, nil},
+		{"apex", RecordAddReq{Record: "@", Type: "A", Value: "192.0.2.1"}, memoryCapabilities, nil},
+		{"empty record is apex", RecordAddReq{Type: "TXT", Value: "hello"}, memoryCapabilities, nil},
+		{"wildcard", RecordAddReq{Record: "*.dev", Type: "A", Value: "192.0.2.1"}, memoryCapabilities, nil},
+		{"wildcard not leftmost", RecordAddReq{Record: "dev.*", Type: "A", Value: "192.0.2.1"}, memoryCapabilities, []string{"record"}},
+		{"label too long", RecordAddReq{Record: long, Type: "A", Value: "192.0.2.1"}, memoryCapabilities, []string{"record"}},
+		{"hyphen", RecordAddReq{Record: "-www", Type: "A", Value: "192.0.2.1"}, memoryCapabilities, []string{"record"}},
+		{"invalid character", RecordAddReq{Record: "w w", Type: "A", Value: "192.0.2.1"}, memoryCapabilities, []string{"record"}},
+		{"name too long", RecordAddReq{Domain: testDomain, Record: strings.TrimSuffix(strings.Repeat(long[:63]+".", 4), "."), Type: "A", Value: "192.0.2.1"}, memoryCapabilities, []string{"record"}},
+		{"lowercase type", RecordAddReq{Record: "www", Type: "a", Value: "192.0.2.1"}, memoryCapabilities, nil},
+		{"missing type", RecordAddReq{Record: "www", Value: "192.0.2.1"}, memoryCapabilities, []string{"type"}},
+		{"unsupported type", RecordAddReq{Record: "www", Type: "SPF", Value: "v=spf1 -all"}, memoryCapabilities, []string{"type"}},
+		{"any type without record types", RecordAddReq{Record: "www", Type: "SPF", Value: "v=spf1 -all"}, Capabilities{}, nil},
+		{"a with ipv6", RecordAddReq{Record: "www", Type: "A", Value: "2001:db8::1"}, memoryCapabilities, []string{"value"}},
+		{"aaaa", RecordAddReq{Record: "www", Type: "AAAA", Value: "2001:db8::1"}, memoryCapabilities, nil},
+		{"aaaa with zone", RecordAddReq{Record: "www", Type: "AAAA", Value: "fe80::1%eth0"}, memoryCapabilities, []string{"value"}},
+		{"missing value", RecordAddReq{Record: "www", Type: "A"}, memoryCapabilities, []string{"value"}},
+		{"cname", RecordAddReq{Record: "www", Type: "CNAME", Value: "example.net."}, memoryCapabilities, nil},
+		{"cname to ip", RecordAddReq{Record: "www", Type: "CNAME", Value: "192.0.2.1"}, memoryCapabilities, []string{"value"}},
+		{"cname to itself", RecordAddReq{Domain: testDomain, Record: "www", Type: "CNAME", Value: "WWW.example.com."}, memoryCapabilities, []string{"value"}},
+		{"apex cname", RecordAddReq{Record: "@", Type: "CNAME", Value: "example.net"}, memoryCapabilities, []string{"record"}},
+		{"apex cname flattening", RecordAddReq{Record: "@", Type: "CNAME", Value: "example.net"}, Capabilities{ApexCNAME: true}, nil},
+		{"mx", RecordAddReq{Record: "@", Type: "MX", Value: "mx.example.com"}, memoryCapabilities, nil},
+		{"mx with priority", RecordAddReq{Record: "@", Type: "MX", Value: "10 mx.example.com"}, memoryCapabilities, nil},
+		{"mx invalid priority", RecordAddReq{Record: "@", Type: "MX", Value: "high mx.example.com"}, memoryCapabilities, []string{"value"}},
+		{"srv", RecordAddReq{Record: "_sip._tcp", Type: "SRV", Value: "10 5 5060 sip.example.com"}, memoryCapabilities, nil},
+		{"srv no service", RecordAddReq{Record: "_sip._tcp", Type: "SRV", Value: "0 0 0 ."}, memoryCapabilities, nil},
+		{"srv name", RecordAddReq{Record: "sip", Type: "SRV", Value: "10 5 5060 sip.example.com"}, memoryCapabilities, []string{"record"}},
+		{"srv fields", RecordAddReq{Record: "_sip._tcp", Type: "SRV", Value: "10 5060 sip.example.com"}, memoryCapabilities, []string{"value"}},
+		{"srv data", RecordAddReq{Record: "_sip._tcp", Data: SRV{10, 5, 5060, "sip.example.com"}}, memoryCapabilities, nil},
+		{"caa", RecordAddReq{Record: "@", Type: "CAA", Value: `0 issue "letsencrypt.org"`}, memoryCapabilities, nil},
+		{"caa iodef", RecordAddReq{Record: "@", Data: CAA{0, "iodef", "ftp://example.com"}}, memoryCapabilities, []string{"value"}},
+		{"caa flag", RecordAddReq{Record: "@", Type: "CAA", Value: `256 issue "letsencrypt.org"`}, memoryCapabilities, []string{"value"}},
+		{"txt", RecordAddReq{Record: "@", Type: "TXT", Value: "v=spf1 -all"}, memoryCapabilities, nil},
+		{"txt invalid utf-8", RecordAddReq{Record: "@", Type: "TXT", Value: "\xff"}, memoryCapabilities, []string{"value"}},
+		{"ttl", RecordAddReq{Record: "www", Type: "A", Value: "192.0.2.1", TTL: 600}, memoryCapabilities, nil},
+		{"ttl too large", RecordAddReq{Record: "www", Type: "A", Value: "192.0.2.1", TTL: 604801}, memoryCapabilities, []string{"ttl"}},
+		{"ttl too small", RecordAddReq{Record: "www", Type: "A", Value: "192.0.2.1", TTL: 60}, Capabilities{TTLMin: 600, TTLMax: 86400}, []string{"ttl"}},
+		{"multiple fields", RecordAddReq{Record: "a..b", Value: "", TTL: 604801}, memoryCapabilities, []string{"record", "type", "value", "ttl"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRecordAdd(tt.caps, tt.req)
+			if len(tt.fields) == 0 {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				return
+			}
+			var fes FieldErrors
+			if KindOf(err) != ErrInvalidInput || !errors.As(err, &fes) {
+				t.Fatalf("err = %v, want FieldErrors", err)
+			}
+			var fields []string
+			for _, fe := range fes {
+				fields = append(fields, fe.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+				t.Fatalf("fields = %q, want %q (%v)", fields, tt.fields, err)
+			}
+		})
+	}
+}
+
+func TestValidateRecordUpdate(t *testing.T) {
+	if err := ValidateRecordUpdate(memoryCapabilities, RecordUpdateReq{Record: "www", Type: "A", Value: "192.0.2.1"}); err != nil {
+		t.Fatal(err)
+	}
+	err := ValidateRecordUpdate(memoryCapabilities, RecordUpdateReq{Record: "www", Type: "A", Value: "not-an-ip"})
+	if want := `value "not-an-ip": not an IPv4 address`; KindOf(err) != ErrInvalidInput || !strings.Contains(err.Error(), want) {
+		t.Fatalf("err = %v, want %s", err, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	base, calls := countingApi(nil)
+	api := Wrap(base, Validate(base))
+	domain, err := api.DomainAdd(DomainAddReq{Domain: testDomain})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = api.RecordAdd(RecordAddReq{DomainId: domain.Id, Record: "www", Type: "A", Value: "not-an-ip"}); KindOf(err) != ErrInvalidInput {
+		t.Fatalf("RecordAdd err = %v, want ErrInvalidInput", err)
+	}
+	if _, err = api.RecordUpdate(RecordUpdateReq{DomainId: domain.Id, RecordId: "1", Record: "@", Type: "CNAME", Value: "example.net"}); KindOf(err) != ErrInvalidInput {
+		t.Fatalf("RecordUpdate err = %v, want ErrInvalidInput", err)
+	}
+	if calls[OpRecordAdd] != 0 || calls[OpRecordUpdate] != 0 {
+		t.Fatalf("calls = %v, want invalid requests rejected before the provider", calls)
+	}
+	if _, err = api.RecordAdd(RecordAddReq{DomainId: domain.Id, Record: "www", Type: "A", Value: "192.0.2.1", TTL: 600}); err != nil || calls[OpRecordAdd] != 1 {
+		t.Fatalf("RecordAdd err = %v, calls = %d, want the valid request forwarded", err, calls[OpRecordAdd])
+	}
+}
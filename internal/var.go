@@ -12,15 +12,14 @@
 package internal
 
 import (
-	"errors"
+	"context"
+	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/alibabacloud-go/tea/tea"
 )
 
-var ErrNotSupportedOperation = errors.New("不支持的操作")
-
 func toUint(str string) uint {
 	i, _ := strconv.ParseUint(str, 10, 64)
 	return uint(i)
@@ -39,4 +38,84 @@ func dnsServer(dnsServers []*string) []string {
 	return dss
 }
 
+// mxPriority 返回 MX 优先级, 未设置时为1
+func mxPriority(mx uint16) uint16 {
+	if mx == 0 {
+		return 1
+	}
+	return mx
+}
+
+func recordName(record, domain string) string {
+	if domain == "" {
+		return ""
+	}
+	if record == "" || record == "@" {
+		return domain
+	}
+	return record + "." + domain
+}
+
+func pageOffset(page, limit uint) uint {
+	if page == 0 {
+		page = 1
+	}
+	return (page - 1) * limit
+}
+
+// pageOf 对已完整获取的列表做本地分页, limit 为0时返回全部
+func pageOf[T any](list []T, page, limit uint) []T {
+	if limit == 0 {
+		return list
+	}
+	offset := pageOffset(page, limit)
+	if offset >= uint(len(list)) {
+		return nil
+	}
+	if end := offset + limit; end < uint(len(list)) {
+		return list[offset:end]
+	}
+	return list[offset:]
+}
+
 func formatTime(t time.Time) string { return t.Format("2006-01-02 15:04:05") }
+
+func ping(ctx context.Context, url string) (ok bool) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// withContext 用于不支持 context 的 SDK 调用, ctx 结束时立即返回 ctx.Err()
+// fn 不会被中止, 会在后台继续执行直到返回 (请求超时由 runtime 按 ctx 的截止时间设置),
+// 因此修改类操作在返回 ctx.Err() 后仍可能已经生效
+func withContext[T any](ctx context.Context, fn func() (T, error)) (t T, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var (
+		t0   T
+		err0 error
+		done = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		t0, err0 = fn()
+	}()
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-done:
+		t, err = t0, err0
+	}
+	return
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import "testing"
+
+func TestMXPriority(t *testing.T) {
+	tests := []struct {
+		name   string
+		typ    string
+		add    uint16
+		update uint16
+		want   [2]uint16 // 添加与修改后的优先级
+	}{
+		{"explicit", "MX", 10, 20, [2]uint16{10, 20}},
+		{"default", "MX", 0, 0, [2]uint16{1, 1}},
+		{"update to default", "MX", 10, 0, [2]uint16{10, 1}},
+		{"ignored for other types", "A", 10, 20, [2]uint16{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, domainId := newTestApi(t)
+			value := "mail.example.com"
+			if tt.typ == "A" {
+				value = "192.0.2.1"
+			}
+			priority := func() uint16 {
+				resp, err := api.RecordList(RecordListReq{DomainId: domainId})
+				if err != nil || len(resp.List) != 1 {
+					t.Fatalf("RecordList = %+v %v", resp, err)
+				}
+				return resp.List[0].MX
+			}
+			add, err := api.RecordAdd(RecordAddReq{DomainId: domainId, Record: "@", Type: tt.typ, Value: value, MX: tt.add})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := priority(); got != tt.want[0] {
+				t.Fatalf("after RecordAdd MX = %d, want %d", got, tt.want[0])
+			}
+			if _, err = api.RecordUpdate(RecordUpdateReq{DomainId: domainId, RecordId: add.Id, Record: "@", Type: tt.typ, Value: value, MX: tt.update}); err != nil {
+				t.Fatal(err)
+			}
+			if got := priority(); got != tt.want[1] {
+				t.Fatalf("after RecordUpdate MX = %d, want %d", got, tt.want[1])
+			}
+		})
+	}
+}
+
+func TestSyncDiffersMX(t *testing.T) {
+	current := RecordListRespRecord{Record: "@", Type: "MX", Value: "mail.example.com", MX: 10, TTL: 600}
+	caps := Capabilities{MXPriority: true}
+	for _, tt := range []struct {
+		name string
+		caps Capabilities
+		mx   uint16
+		want bool
+	}{
+		{"same", caps, 10, false},
+		{"changed", caps, 20, true},
+		{"unset", caps, 0, false},
+		{"not supported", Capabilities{}, 20, false},
+	} {
+		if got := syncDiffers(tt.caps, current, SyncRecord{Record: "@", Type: "MX", Value: "mail.example.com", MX: tt.mx}); got != tt.want {
+			t.Errorf("%s: syncDiffers = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
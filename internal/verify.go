@@ -0,0 +1,214 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	defaultVerifyTimeout  = time.Minute * 2
+	defaultVerifyInterval = time.Second * 2
+	verifyQueryTimeout    = time.Second * 5
+)
+
+type (
+	VerifyReq struct {
+		DomainId    string        // 域名Id, 未配置 Nameservers 时用于查找权威服务器 => xxxxxxxxxxxx
+		Domain      string        // 域名 => example.com
+		Record      string        // 主机记录 => www
+		Type        string        // 类型 => A
+		Value       string        // 期望的记录值 => 1.1.1.1
+		Nameservers []string      // 权威服务器, 为空时使用 DomainListRespDomain.DnsServer => ns1.example.net / 127.0.0.1:5353
+		Resolvers   []string      // 递归服务器, 可选 => 8.8.8.8
+		Timeout     time.Duration // 等待时长, 默认2分钟
+		Interval    time.Duration // 查询间隔, 默认2秒
+	}
+
+	VerifyResult struct {
+		Server        string        `json:"server"`        // 服务器 => ns1.example.net:53
+		Authoritative bool          `json:"authoritative"` // 是否为权威服务器
+		Values        []string      `json:"values"`        // 返回的记录值 => ["1.1.1.1"]
+		Matched       bool          `json:"matched"`       // 是否包含期望值
+		Rtt           time.Duration `json:"rtt"`           // 查询耗时
+		Err           error         `json:"-"`             // 查询失败的原因
+	}
+
+	VerifyReport struct {
+		Propagated bool           `json:"propagated"` // 所有服务器均返回期望值
+		Attempts   uint           `json:"attempts"`   // 查询轮数
+		Results    []VerifyResult `json:"results"`    // 最后一轮各服务器的结果
+	}
+)
+
+// Verify 每隔 Interval 查询一轮权威服务器与递归服务器, 直到全部返回期望值;
+// 超过 Timeout 时返回最后一轮的结果与错误
+func Verify(ctx context.Context, api Api, req VerifyReq) (report VerifyReport, err error) {
+	if req.Timeout <= 0 {
+		req.Timeout = defaultVerifyTimeout
+	}
+	if req.Interval <= 0 {
+		req.Interval = defaultVerifyInterval
+	}
+	if req.Nameservers, err = verifyNameservers(ctx, api, req); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, req.Timeout)
+	defer cancel()
+	ticker := time.NewTicker(req.Interval)
+	defer ticker.Stop()
+	for {
+		round, err0 := verifyRound(ctx, req, report.Attempts+1)
+		if err = err0; err != nil || round.Propagated {
+			return round, err
+		}
+		// 超时中断的一轮只有超时错误, 保留上一轮的结果
+		if deadline, _ := ctx.Deadline(); time.Now().Before(deadline) || report.Attempts == 0 {
+			report = round
+		}
+		select {
+		case <-ctx.Done():
+			var pending []string
+			for _, r := range report.Results {
+				if !r.Matched {
+					pending = append(pending, r.Server)
+				}
+			}
+			err = fmt.Errorf("%s %s not propagated to %s: %w", verifyName(req), req.Type, strings.Join(pending, ", "), ctx.Err())
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// VerifyOnce 查询一轮并返回结果, 不等待
+func VerifyOnce(ctx context.Context, api Api, req VerifyReq) (report VerifyReport, err error) {
+	if req.Nameservers, err = verifyNameservers(ctx, api, req); err != nil {
+		return
+	}
+	return verifyRound(ctx, req, 1)
+}
+
+func verifyRound(ctx context.Context, req VerifyReq, attempts uint) (report VerifyReport, err error) {
+	qtype, ok := dns.StringToType[strings.ToUpper(req.Type)]
+	if !ok {
+		err = &Error{Kind: ErrInvalidInput, Err: fmt.Errorf("unknown record type %s", req.Type)}
+		return
+	}
+	report = VerifyReport{Attempts: attempts, Results: make([]VerifyResult, 0, len(req.Nameservers)+len(req.Resolvers))}
+	for _, s := range req.Nameservers {
+		report.Results = append(report.Results, VerifyResult{Server: verifyAddr(s), Authoritative: true})
+	}
+	for _, s := range req.Resolvers {
+		report.Results = append(report.Results, VerifyResult{Server: verifyAddr(s)})
+	}
+	var wg sync.WaitGroup
+	for i := range report.Results {
+		wg.Add(1)
+		go func(r *VerifyResult) {
+			defer wg.Done()
+			verifyQuery(ctx, r, dns.Fqdn(verifyName(req)), qtype, req.Value)
+		}(&report.Results[i])
+	}
+	wg.Wait()
+	report.Propagated = true
+	for _, r := range report.Results {
+		report.Propagated = report.Propagated && r.Matched
+	}
+	return
+}
+
+func verifyQuery(ctx context.Context, r *VerifyResult, name string, qtype uint16, value string) {
+	m := new(dns.Msg)
+	m.SetQuestion(name, qtype)
+	m.RecursionDesired = !r.Authoritative
+	ctx, cancel := context.WithTimeout(ctx, verifyQueryTimeout)
+	defer cancel()
+	resp, rtt, err := (&dns.Client{}).ExchangeContext(ctx, m, r.Server)
+	if err == nil && resp.Truncated {
+		resp, rtt, err = (&dns.Client{Net: "tcp"}).ExchangeContext(ctx, m, r.Server)
+	}
+	r.Rtt = rtt
+	switch {
+	case err != nil:
+		r.Err = err
+		return
+	case resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError:
+		r.Err = fmt.Errorf("rcode %s", dns.RcodeToString[resp.Rcode])
+		return
+	case r.Authoritative && !resp.Authoritative:
+		r.Err = errors.New("answer is not authoritative")
+		return
+	}
+	for _, rr := range resp.Answer {
+		if hdr := rr.Header(); hdr.Rrtype != qtype || !strings.EqualFold(hdr.Name, name) {
+			continue
+		}
+		v, _ := rrValue(rr)
+		r.Values = append(r.Values, v)
+		r.Matched = r.Matched || verifyValueEqual(dns.TypeToString[qtype], v, value)
+	}
+}
+
+// verifyNameservers 未配置 Nameservers 时返回域名的 DnsServer
+func verifyNameservers(ctx context.Context, api Api, req VerifyReq) (resp []string, err error) {
+	if resp = req.Nameservers; len(resp) == 0 {
+		for d, err0 := range AllDomains(ctx, api, DomainListReq{Domain: req.Domain}) {
+			if err = err0; err != nil {
+				return
+			}
+			if (req.DomainId != "" && d.Id == req.DomainId) || strings.EqualFold(d.Name, req.Domain) {
+				resp = d.DnsServer
+				break
+			}
+		}
+	}
+	if len(resp) == 0 && len(req.Resolvers) == 0 {
+		err = &Error{Kind: ErrInvalidInput, Err: fmt.Errorf("no nameservers found for %s", req.Domain)}
+	}
+	return
+}
+
+func verifyName(req VerifyReq) string {
+	domain := strings.TrimSuffix(req.Domain, ".")
+	if req.Record == "" || req.Record == "@" {
+		return domain
+	}
+	return req.Record + "." + domain
+}
+
+// verifyAddr 未指定端口时使用53
+func verifyAddr(server string) string {
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	return net.JoinHostPort(strings.Trim(server, "[]"), "53")
+}
+
+func verifyValueEqual(typ, a, b string) bool {
+	switch typ {
+	case "A", "AAAA":
+		x, err0 := netip.ParseAddr(a)
+		y, err1 := netip.ParseAddr(b)
+		return err0 == nil && err1 == nil && x == y
+	}
+	return syncValueEqual(typ, a, b)
+}
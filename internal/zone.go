@@ -0,0 +1,310 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+const defaultZoneTTL = 600
+
+type (
+	ZoneReq struct {
+		DomainId string // 域名Id => xxxxxxxxxxxx
+		Domain   string // 域名, 为空时按 DomainId 查询 => example.com
+		TTL      uint   // 导出时为 $TTL, 导入时为缺省 TTL, 默认600 => 600
+	}
+
+	ZoneIssue struct {
+		Record  string `json:"record"`  // 主机记录 => www
+		Type    string `json:"type"`    // 类型 => A
+		Value   string `json:"value"`   // 记录值 => 1.1.1.1
+		Skipped bool   `json:"skipped"` // 是否跳过, false表示已处理但有信息丢失
+		Reason  string `json:"reason"`  // 原因 => 线路无法在区域文件中表示
+	}
+
+	ZoneReport struct {
+		Count  uint        `json:"count"`  // 导出或导入的记录数
+		Issues []ZoneIssue `json:"issues"` // 无法完整表示的记录
+	}
+
+	zoneRecord struct {
+		Record string
+		Type   string
+		Value  string
+		TTL    uint
+		MX     uint16
+	}
+)
+
+// ExportZone 将域名的全部记录以 BIND 区域文件格式写入 w
+func ExportZone(ctx context.Context, api Api, w io.Writer, req ZoneReq) (report ZoneReport, err error) {
+	domain, err := zoneDomain(ctx, api, req)
+	if err != nil {
+		return
+	}
+	origin, ttl := dns.Fqdn(domain), req.TTL
+	if ttl == 0 {
+		ttl = defaultZoneTTL
+	}
+	defLine, caps := api.LineDefaultContext(ctx).Id, api.Capabilities()
+	bw := bufio.NewWriter(w)
+	_, _ = fmt.Fprintf(bw, "$ORIGIN %s\n$TTL %d\n", origin, ttl)
+	for r, err0 := range AllRecords(ctx, api, RecordListReq{DomainId: req.DomainId, Domain: domain}) {
+		if err = err0; err != nil {
+			return
+		}
+		record := syncRecord(r.Record)
+		issue := func(skipped bool, reason string) {
+			report.Issues = append(report.Issues, ZoneIssue{record, r.Type, r.Value, skipped, reason})
+		}
+		if r.Status == "disable" {
+			issue(true, "记录已暂停")
+			continue
+		}
+		if caps.Line && r.Line != "" && r.Line != defLine {
+			issue(true, fmt.Sprintf("线路 %s 无法在区域文件中表示", r.Line))
+			continue
+		}
+		line := fmt.Sprintf("%s\t%d\tIN\t%s\t%s", record, r.TTL, strings.ToUpper(r.Type), zoneValue(r))
+		if _, err0 := dns.NewRR(fmt.Sprintf("$ORIGIN %s\n%s", origin, line)); err0 != nil {
+			issue(true, err0.Error())
+			continue
+		}
+		if caps.Weight && r.Weight != 0 {
+			issue(false, fmt.Sprintf("权重 %d 无法在区域文件中表示", r.Weight))
+		}
+		if r.Remark != "" {
+			line += "\t; " + strings.ReplaceAll(r.Remark, "\n", " ")
+		}
+		_, _ = fmt.Fprintln(bw, line)
+		report.Count++
+	}
+	err = bw.Flush()
+	return
+}
+
+// ImportZone 解析区域文件并逐条添加记录, 已存在的记录跳过; SOA 与顶级 NS 由服务商管理, 不导入
+func ImportZone(ctx context.Context, api Api, r io.Reader, req ZoneReq) (report ZoneReport, err error) {
+	domain, err := zoneDomain(ctx, api, req)
+	if err != nil {
+		return
+	}
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = defaultZoneTTL
+	}
+	records, issues, err := parseZone(r, dns.Fqdn(domain), ttl)
+	if err != nil {
+		return
+	}
+	report.Issues = issues
+	caps := api.Capabilities()
+	for _, rc := range records {
+		issue := func(skipped bool, reason string) {
+			report.Issues = append(report.Issues, ZoneIssue{rc.Record, rc.Type, rc.Value, skipped, reason})
+		}
+		if len(caps.RecordTypes) > 0 && !caps.SupportsRecordType(rc.Type) {
+			issue(true, "不支持的记录类型")
+			continue
+		}
+		if caps.TTLMin > 0 && rc.TTL < caps.TTLMin {
+			issue(false, fmt.Sprintf("TTL %d 小于最小值, 已调整为 %d", rc.TTL, caps.TTLMin))
+			rc.TTL = caps.TTLMin
+		}
+		if caps.TTLMax > 0 && rc.TTL > caps.TTLMax {
+			issue(false, fmt.Sprintf("TTL %d 大于最大值, 已调整为 %d", rc.TTL, caps.TTLMax))
+			rc.TTL = caps.TTLMax
+		}
+		if rc.Type == "MX" && !caps.MXPriority {
+			issue(false, fmt.Sprintf("不支持MX优先级, 已忽略 %d", rc.MX))
+		}
+		_, err = api.RecordAddContext(ctx, RecordAddReq{
+			DomainId: req.DomainId,
+			Domain:   domain,
+			Record:   rc.Record,
+			Type:     rc.Type,
+			Value:    rc.Value,
+			TTL:      rc.TTL,
+			MX:       rc.MX,
+		})
+		if errors.Is(err, ErrAlreadyExists) {
+			err = nil
+			issue(true, "记录已存在")
+			continue
+		}
+		if err != nil {
+			err = fmt.Errorf("%s %s %s: %w", rc.Record, rc.Type, rc.Value, err)
+			return
+		}
+		report.Count++
+	}
+	return
+}
+
+func zoneDomain(ctx context.Context, api Api, req ZoneReq) (domain string, err error) {
+	if req.Domain != "" {
+		return strings.TrimSuffix(req.Domain, "."), nil
+	}
+	for d, err0 := range AllDomains(ctx, api, DomainListReq{}) {
+		if err = err0; err != nil {
+			return
+		}
+		if d.Id == req.DomainId {
+			return d.Name, nil
+		}
+	}
+	return "", &Error{Kind: ErrNotFound, Err: fmt.Errorf("域名 %s 不存在", req.DomainId)}
+}
+
+// zoneValue 将记录值转换为区域文件格式, 主机名补全末尾的点, TXT 加引号
+func zoneValue(r RecordListRespRecord) string {
+	switch v := r.Value; strings.ToUpper(r.Type) {
+	case "CNAME", "NS", "PTR", "DNAME":
+		return dns.Fqdn(v)
+	case "MX":
+		if strings.Contains(v, " ") {
+			return v
+		}
+		return fmt.Sprintf("%d %s", r.MX, dns.Fqdn(v))
+	case "SRV":
+		if fields := strings.Fields(v); len(fields) == 4 {
+			fields[3] = dns.Fqdn(fields[3])
+			return strings.Join(fields, " ")
+		}
+		return v
+	case "HTTPS", "SVCB":
+		if d, err := parseSVCB(v); err == nil && d.Target != "." {
+			d.Target = dns.Fqdn(d.Target)
+			return fmt.Sprintf("%d %s %s", d.Priority, d.Target, d.ParamsString())
+		}
+		return v
+	case "TXT", "SPF":
+		return zoneQuote(v)
+	default:
+		return v
+	}
+}
+
+// zoneQuote 按255字节拆分并转义 TXT 记录值
+func zoneQuote(v string) string {
+	if v == "" {
+		return `""`
+	}
+	var chunks []string
+	for len(v) > 0 {
+		n := min(len(v), 255)
+		chunk := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v[:n])
+		chunks = append(chunks, `"`+chunk+`"`)
+		v = v[n:]
+	}
+	return strings.Join(chunks, " ")
+}
+
+// zoneUnescape 还原 \X 与 \DDD 转义
+func zoneUnescape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		if i+3 < len(s) && isDigit(s[i+1]) && isDigit(s[i+2]) && isDigit(s[i+3]) {
+			sb.WriteByte((s[i+1]-'0')*100 + (s[i+2]-'0')*10 + (s[i+3] - '0'))
+			i += 3
+			continue
+		}
+		sb.WriteByte(s[i+1])
+		i++
+	}
+	return sb.String()
+}
+
+func isDigit(b byte) bool { return b >= '0' && b <= '9' }
+
+func parseZone(r io.Reader, origin string, ttl uint) (records []zoneRecord, issues []ZoneIssue, err error) {
+	zp := dns.NewZoneParser(r, origin, "")
+	zp.SetDefaultTTL(uint32(ttl))
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		hdr := rr.Header()
+		rc := zoneRecord{Type: dns.TypeToString[hdr.Rrtype], TTL: uint(hdr.Ttl)}
+		switch name := strings.ToLower(hdr.Name); {
+		case name == strings.ToLower(origin):
+			rc.Record = "@"
+		case dns.IsSubDomain(origin, name):
+			rc.Record = strings.TrimSuffix(hdr.Name[:len(hdr.Name)-len(origin)], ".")
+		default:
+			issues = append(issues, ZoneIssue{hdr.Name, rc.Type, "", true, "不属于 " + origin})
+			continue
+		}
+		if _, ok := rr.(*dns.SOA); ok {
+			issues = append(issues, ZoneIssue{rc.Record, rc.Type, "", true, "SOA 记录由服务商管理"})
+			continue
+		}
+		if rc.Value, rc.MX = rrValue(rr); rc.Type == "NS" && rc.Record == "@" {
+			issues = append(issues, ZoneIssue{rc.Record, rc.Type, rc.Value, true, "顶级 NS 记录由服务商管理"})
+			continue
+		}
+		records = append(records, rc)
+	}
+	if err = zp.Err(); err != nil {
+		err = &Error{Kind: ErrInvalidInput, Err: err}
+	}
+	return
+}
+
+// rrValue 返回 rr 对应的记录值, MX 优先级单独返回
+func rrValue(rr dns.RR) (value string, mx uint16) {
+	switch v := rr.(type) {
+	case *dns.NS:
+		return strings.TrimSuffix(v.Ns, "."), 0
+	case *dns.A:
+		return v.A.String(), 0
+	case *dns.AAAA:
+		return v.AAAA.String(), 0
+	case *dns.CNAME:
+		return strings.TrimSuffix(v.Target, "."), 0
+	case *dns.PTR:
+		return strings.TrimSuffix(v.Ptr, "."), 0
+	case *dns.MX:
+		return strings.TrimSuffix(v.Mx, "."), v.Preference
+	case *dns.TXT:
+		return zoneUnescape(strings.Join(v.Txt, "")), 0
+	case *dns.SPF:
+		return zoneUnescape(strings.Join(v.Txt, "")), 0
+	case *dns.SRV:
+		return fmt.Sprintf("%d %d %d %s", v.Priority, v.Weight, v.Port, strings.TrimSuffix(v.Target, ".")), 0
+	case *dns.CAA:
+		return CAA{v.Flag, v.Tag, v.Value}.String(), 0
+	case *dns.SVCB:
+		return rrSVCB(v).String(), 0
+	case *dns.HTTPS:
+		return HTTPS(rrSVCB(&v.SVCB)).String(), 0
+	}
+	return strings.TrimPrefix(rr.String(), rr.Header().String()), 0
+}
+
+// rrSVCB 将 SVCB 记录转换为结构化记录值, 参数值不含引号
+func rrSVCB(v *dns.SVCB) (d SVCB) {
+	d = SVCB{Priority: v.Priority, Target: v.Target}
+	for _, kv := range v.Value {
+		d.Params = append(d.Params, SvcParam{kv.Key().String(), kv.String()})
+	}
+	return
+}
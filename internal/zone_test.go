@@ -0,0 +1,156 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// capsApi 替换 Api 的能力描述
+type capsApi struct {
+	Api
+	caps Capabilities
+}
+
+func (a capsApi) Capabilities() Capabilities { return a.caps }
+
+func TestZoneRoundTrip(t *testing.T) {
+	src, srcId := newTestApi(t,
+		RecordAddReq{Record: "@", Type: "A", Value: "192.0.2.1", TTL: 600},
+		RecordAddReq{Record: "www", Type: "CNAME", Value: "example.com", TTL: 300},
+		RecordAddReq{Record: "@", Type: "MX", Value: "mail.example.com", TTL: 600, MX: 10},
+		RecordAddReq{Record: "@", Type: "TXT", Value: `v=spf1 "quoted" ` + strings.Repeat("x", 300), TTL: 600},
+		RecordAddReq{Record: "_sip._tcp", Type: "SRV", Value: "10 5 5060 sip.example.com", TTL: 600},
+		RecordAddReq{Record: "@", Type: "CAA", Value: `0 issue "letsencrypt.org"`, TTL: 600},
+	)
+	var buf bytes.Buffer
+	report, err := ExportZone(context.Background(), src, &buf, ZoneReq{DomainId: srcId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Count != 6 || len(report.Issues) != 0 {
+		t.Fatalf("export report = %+v, want 6 records without issues", report)
+	}
+	if !strings.HasPrefix(buf.String(), "$ORIGIN example.com.\n$TTL 600\n") {
+		t.Fatalf("export header = %q", buf.String())
+	}
+
+	dst, dstId := newTestApi(t)
+	if report, err = ImportZone(context.Background(), dst, &buf, ZoneReq{DomainId: dstId, Domain: testDomain}); err != nil {
+		t.Fatal(err)
+	}
+	if report.Count != 6 || len(report.Issues) != 0 {
+		t.Fatalf("import report = %+v, want 6 records without issues", report)
+	}
+	if got, want := testRecords(t, dst, dstId), testRecords(t, src, srcId); !slices.Equal(got, want) {
+		t.Fatalf("imported = %q, want %q", got, want)
+	}
+}
+
+func TestExportZoneIssues(t *testing.T) {
+	api, domainId := newTestApi(t,
+		RecordAddReq{Record: "www", Type: "A", Value: "192.0.2.1", TTL: 600, Remark: "web"},
+		RecordAddReq{Record: "cn", Type: "A", Value: "192.0.2.2", TTL: 600, Line: "telecom"},
+		RecordAddReq{Record: "lb", Type: "A", Value: "192.0.2.3", TTL: 600, Weight: 5},
+		RecordAddReq{Record: "off", Type: "A", Value: "192.0.2.4", TTL: 600},
+	)
+	list, _ := api.RecordList(RecordListReq{DomainId: domainId, Record: "off"})
+	if err := api.RecordDisable(RecordDisableReq{DomainId: domainId, RecordId: list.List[0].Id}); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	report, err := ExportZone(context.Background(), api, &buf, ZoneReq{Domain: testDomain, TTL: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Count != 2 {
+		t.Fatalf("count = %d, want 2\n%s", report.Count, buf.String())
+	}
+	issues := map[string]bool{}
+	for _, i := range report.Issues {
+		issues[i.Record] = i.Skipped
+	}
+	if want := map[string]bool{"cn": true, "off": true, "lb": false}; !maps.Equal(issues, want) {
+		t.Fatalf("issues = %v, want %v", issues, want)
+	}
+	if out := buf.String(); !strings.Contains(out, "$TTL 300\n") || !strings.Contains(out, "www\t600\tIN\tA\t192.0.2.1\t; web\n") {
+		t.Fatalf("export = %q", out)
+	}
+}
+
+func TestImportZone(t *testing.T) {
+	zone := `$ORIGIN example.com.
+$TTL 3600
+@	IN	SOA	ns1.example.net. admin.example.com. 1 7200 3600 1209600 300
+@	IN	NS	ns1.example.net.
+sub	IN	NS	ns1.example.net.
+www	30	IN	A	192.0.2.1
+api.example.com.	IN	A	192.0.2.2
+big	999999	IN	A	192.0.2.3
+@	IN	MX	20 mail.example.com.
+other.example.org.	IN	A	192.0.2.4
+@	IN	PTR	host.example.com.
+dup	IN	A	192.0.2.5
+`
+	api, domainId := newTestApi(t, RecordAddReq{Record: "dup", Type: "A", Value: "192.0.2.5", TTL: 3600})
+	caps := memoryCapabilities
+	caps.TTLMin, caps.TTLMax, caps.MXPriority = 60, 86400, false
+	caps.RecordTypes = slices.DeleteFunc(slices.Clone(caps.RecordTypes), func(s string) bool { return s == "PTR" })
+	report, err := ImportZone(context.Background(), capsApi{api, caps}, strings.NewReader(zone), ZoneReq{Domain: testDomain})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Count != 5 {
+		t.Fatalf("count = %d, want 5", report.Count)
+	}
+	var reasons []string
+	for _, i := range report.Issues {
+		reasons = append(reasons, i.Record+" "+i.Type+" "+i.Reason)
+	}
+	want := []string{
+		"@ SOA SOA 记录由服务商管理",
+		"@ NS 顶级 NS 记录由服务商管理",
+		"other.example.org. A 不属于 example.com.",
+		"www A TTL 30 小于最小值, 已调整为 60",
+		"big A TTL 999999 大于最大值, 已调整为 86400",
+		"@ MX 不支持MX优先级, 已忽略 20",
+		"@ PTR 不支持的记录类型",
+		"dup A 记录已存在",
+	}
+	if !slices.Equal(reasons, want) {
+		t.Fatalf("issues = %q, want %q", reasons, want)
+	}
+	wantRecords := []string{
+		"@ MX mail.example.com default 3600",
+		"api A 192.0.2.2 default 3600",
+		"big A 192.0.2.3 default 86400",
+		"dup A 192.0.2.5 default 3600",
+		"sub NS ns1.example.net default 3600",
+		"www A 192.0.2.1 default 60",
+	}
+	if got := testRecords(t, api, domainId); !slices.Equal(got, wantRecords) {
+		t.Fatalf("records = %q, want %q", got, wantRecords)
+	}
+}
+
+func TestImportZoneInvalid(t *testing.T) {
+	api, _ := newTestApi(t)
+	_, err := ImportZone(context.Background(), api, strings.NewReader("www IN A not-an-ip\n"), ZoneReq{Domain: testDomain})
+	if KindOf(err) != ErrInvalidInput {
+		t.Fatalf("err = %v, want ErrInvalidInput", err)
+	}
+}
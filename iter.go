@@ -0,0 +1,35 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdk
+
+import (
+	"context"
+	"iter"
+
+	"github.com/go-the-way/dnsdk/internal"
+)
+
+func AllDomains(api Api, req DomainListReq) iter.Seq2[DomainListRespDomain, error] {
+	return internal.AllDomains(context.Background(), api, req)
+}
+
+func AllDomainsContext(ctx context.Context, api Api, req DomainListReq) iter.Seq2[DomainListRespDomain, error] {
+	return internal.AllDomains(ctx, api, req)
+}
+
+func AllRecords(api Api, req RecordListReq) iter.Seq2[RecordListRespRecord, error] {
+	return internal.AllRecords(context.Background(), api, req)
+}
+
+func AllRecordsContext(ctx context.Context, api Api, req RecordListReq) iter.Seq2[RecordListRespRecord, error] {
+	return internal.AllRecords(ctx, api, req)
+}
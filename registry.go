@@ -0,0 +1,76 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdk
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	providersMu sync.RWMutex
+	providers   = make(map[ApiType]provider)
+)
+
+type (
+	// ProviderFactory 由配置 R 创建 Api, R 为服务商自定义的配置类型
+	ProviderFactory[R any] func(opts R) (a Api, err error)
+
+	provider func(opts any) (a Api, err error)
+)
+
+// RegisterProvider 注册服务商, 注册后可通过 GetSupportApi(t, Supporter(at, fn)) 获取 Api.
+// 重复注册同一 ApiType 或 factory 为 nil 时 panic
+func RegisterProvider[R any](at ApiType, factory ProviderFactory[R]) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	if factory == nil {
+		panic("dnsdk: RegisterProvider factory is nil")
+	}
+	if _, dup := providers[at]; dup {
+		panic("dnsdk: RegisterProvider called twice for " + string(at))
+	}
+	providers[at] = func(opts any) (a Api, err error) {
+		r, ok := opts.(R)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s opts definition: %T", at, opts)
+		}
+		return factory(r)
+	}
+}
+
+// Providers 返回已注册的服务商, 按名称排序
+func Providers() (ats []ApiType) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	for at := range providers {
+		ats = append(ats, at)
+	}
+	sort.Slice(ats, func(i, j int) bool { return ats[i] < ats[j] })
+	return
+}
+
+func providerOf(at ApiType) (p provider, ok bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok = providers[at]
+	return
+}
+
+func init() {
+	RegisterProvider(ApiTypeAlidns, newAlidnsApi)
+	RegisterProvider(ApiTypeCloudflare, newCloudflareApi)
+	RegisterProvider(ApiTypeDnspod, newDnspodApi)
+	RegisterProvider(ApiTypePqdns, newPqdnsApi)
+	RegisterProvider(ApiTypeMemory, newMemoryApi)
+}
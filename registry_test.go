@@ -0,0 +1,109 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdk
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// registerTest 注册测试服务商, 测试结束后移除
+func registerTest[R any](t *testing.T, at ApiType, factory ProviderFactory[R]) {
+	t.Helper()
+	RegisterProvider(at, factory)
+	t.Cleanup(func() {
+		providersMu.Lock()
+		delete(providers, at)
+		providersMu.Unlock()
+	})
+}
+
+func wantPanic(t *testing.T, want string, fn func()) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), want) {
+			t.Fatalf("panic = %v, want %q", r, want)
+		}
+	}()
+	fn()
+}
+
+func TestProviders(t *testing.T) {
+	builtin := []ApiType{ApiTypeAlidns, ApiTypeCloudflare, ApiTypeDnspod, ApiTypeMemory, ApiTypePqdns}
+	if got := Providers(); !slices.Equal(got, builtin) {
+		t.Fatalf("Providers() = %v, want %v", got, builtin)
+	}
+	for _, at := range builtin {
+		if _, ok := providerOf(at); !ok {
+			t.Fatalf("%s is not registered", at)
+		}
+	}
+	api, err := GetSupportApi(NewMemorySupportOpts(), MemorySupporter(func(o *MemorySupportOpts) *MemorySupportOpts { return o }))
+	if err != nil || !api.Capabilities().Supports(OpRecordAdd) {
+		t.Fatalf("memory api = %v, %v", api, err)
+	}
+}
+
+func TestRegisterProvider(t *testing.T) {
+	type testOpts struct{ name string }
+	var got testOpts
+	registerTest(t, "registry-test", func(opts testOpts) (Api, error) {
+		got = opts
+		return newMemoryApi(NewMemorySupportOpts())
+	})
+	if !slices.Contains(Providers(), "registry-test") {
+		t.Fatalf("Providers() = %v, want registry-test", Providers())
+	}
+	// Supporter 将调用方的配置转换为服务商配置
+	api, err := GetSupportApi("example", Supporter("registry-test", func(name string) testOpts { return testOpts{name} }))
+	if err != nil || api == nil || got.name != "example" {
+		t.Fatalf("GetSupportApi = %v, %v, opts %+v", api, err, got)
+	}
+	if _, err = GetSupportApi(1, Supporter("registry-test", func(int) string { return "wrong" })); err == nil || !strings.Contains(err.Error(), "invalid registry-test opts") {
+		t.Fatalf("wrong opts type err = %v", err)
+	}
+	if _, err = GetSupportApi(1, Supporter("registry-unknown", func(int) int { return 0 })); err == nil {
+		t.Fatal("unknown provider should fail")
+	}
+
+	wantPanic(t, "called twice for registry-test", func() {
+		RegisterProvider("registry-test", func(testOpts) (Api, error) { return nil, nil })
+	})
+	wantPanic(t, "called twice for alidns", func() { RegisterProvider(ApiTypeAlidns, newAlidnsApi) })
+	wantPanic(t, "factory is nil", func() { RegisterProvider[testOpts]("registry-nil", nil) })
+	if _, ok := providerOf("registry-nil"); ok {
+		t.Fatal("nil factory should not be registered")
+	}
+}
+
+func TestSplitEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint, scheme, host string
+	}{
+		{"http://127.0.0.1:8080", "HTTP", "127.0.0.1:8080"},
+		{"https://alidns.aliyuncs.com/", "HTTPS", "alidns.aliyuncs.com"},
+		{"dnspod.tencentcloudapi.com", "", "dnspod.tencentcloudapi.com"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		if scheme, host := splitEndpoint(tt.endpoint); scheme != tt.scheme || host != tt.host {
+			t.Errorf("splitEndpoint(%q) = %q, %q, want %q, %q", tt.endpoint, scheme, host, tt.scheme, tt.host)
+		}
+	}
+	if o := NewAlidnsSupportOpts("id", "secret").Endpoint("http://127.0.0.1:8080"); o.protocol != "HTTP" || o.endpoint != "127.0.0.1:8080" {
+		t.Fatalf("alidns opts = %+v", o)
+	}
+	if o := NewDnspodSupportOpt("id", "secret").Endpoint("https://127.0.0.1:8443"); o.scheme != "HTTPS" || o.endpoint != "127.0.0.1:8443" {
+		t.Fatalf("dnspod opts = %+v", o)
+	}
+}
@@ -16,6 +16,7 @@ const (
 	ApiTypeCloudflare ApiType = "cloudflare"
 	ApiTypeDnspod     ApiType = "dnspod"
 	ApiTypePqdns      ApiType = "pqdns"
+	ApiTypeMemory     ApiType = "memory"
 )
 
 type (
@@ -31,5 +32,10 @@ type (
 	}
 )
 
+// Supporter 返回 at 的 supporter, 用于通过 RegisterProvider 注册的服务商
+func Supporter[T, R any](at ApiType, supportFunc SupportFunc[T, R]) supporter[T, R] {
+	return &defaultSupporter[T, R]{ApiType: at, SupportFunc: supportFunc}
+}
+
 func (d *defaultSupporter[T, R]) Type() (at ApiType) { return d.ApiType }
 func (d *defaultSupporter[T, R]) Support(t T) (r R)  { return d.SupportFunc(t) }
@@ -21,8 +21,15 @@ type AlidnsSupportOpts struct {
 	accessKeyId     string
 	accessKeySecret string
 	endpoint        string
+	protocol        string
 }
 
 func NewAlidnsSupportOpts(accessKeyId string, accessKeySecret string) *AlidnsSupportOpts {
-	return &AlidnsSupportOpts{accessKeyId, accessKeySecret, alidnsEndpoint}
+	return &AlidnsSupportOpts{accessKeyId, accessKeySecret, alidnsEndpoint, ""}
+}
+
+// Endpoint 自定义接口地址 => dnsdktest 服务的 srv.URL
+func (o *AlidnsSupportOpts) Endpoint(endpoint string) *AlidnsSupportOpts {
+	o.protocol, o.endpoint = splitEndpoint(endpoint)
+	return o
 }
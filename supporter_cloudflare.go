@@ -15,8 +15,26 @@ func CloudflareSupporter[T any](supportFunc SupportFunc[T, *CloudflareSupportOpt
 	return &defaultSupporter[T, *CloudflareSupportOpts]{ApiType: ApiTypeCloudflare, SupportFunc: supportFunc}
 }
 
-type CloudflareSupportOpts struct{ email, apiKey string }
+type CloudflareSupportOpts struct{ email, apiKey, apiToken, accountId, baseUrl string }
 
+// NewCloudflareSupportOpts 使用 Global API Key 认证
 func NewCloudflareSupportOpts(email string, apiKey string) *CloudflareSupportOpts {
-	return &CloudflareSupportOpts{email, apiKey}
+	return &CloudflareSupportOpts{email: email, apiKey: apiKey}
+}
+
+// NewCloudflareTokenSupportOpts 使用 API Token 认证, 需要 Zone:Read 与 DNS:Edit 权限, 添加域名还需要 Zone:Edit
+func NewCloudflareTokenSupportOpts(apiToken string) *CloudflareSupportOpts {
+	return &CloudflareSupportOpts{apiToken: apiToken}
+}
+
+// Account 指定账号Id, 域名列表仅返回该账号下的域名, 添加域名时归属该账号 => 023e105f4ecef8ad9ca31a8372d0c353
+func (o *CloudflareSupportOpts) Account(accountId string) *CloudflareSupportOpts {
+	o.accountId = accountId
+	return o
+}
+
+// Endpoint 自定义接口地址, 替换 https://api.cloudflare.com/client/v4 => dnsdktest.NewCloudflareServer 的 srv.URL
+func (o *CloudflareSupportOpts) Endpoint(baseUrl string) *CloudflareSupportOpts {
+	o.baseUrl = baseUrl
+	return o
 }
@@ -15,8 +15,14 @@ func DnspodSupporter[T any](supportFunc SupportFunc[T, *DnspodSupportOpts]) supp
 	return &defaultSupporter[T, *DnspodSupportOpts]{ApiType: ApiTypeDnspod, SupportFunc: supportFunc}
 }
 
-type DnspodSupportOpts struct{ secretId, secretKey string }
+type DnspodSupportOpts struct{ secretId, secretKey, endpoint, scheme string }
 
 func NewDnspodSupportOpt(secretId string, secretKey string) *DnspodSupportOpts {
-	return &DnspodSupportOpts{secretId, secretKey}
+	return &DnspodSupportOpts{secretId, secretKey, "", ""}
+}
+
+// Endpoint 自定义接口地址 => dnsdktest 服务的 srv.URL
+func (o *DnspodSupportOpts) Endpoint(endpoint string) *DnspodSupportOpts {
+	o.scheme, o.endpoint = splitEndpoint(endpoint)
+	return o
 }
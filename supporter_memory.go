@@ -0,0 +1,56 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdk
+
+import "time"
+
+func MemorySupporter[T any](supportFunc SupportFunc[T, *MemorySupportOpts]) supporter[T, *MemorySupportOpts] {
+	return &defaultSupporter[T, *MemorySupportOpts]{ApiType: ApiTypeMemory, SupportFunc: supportFunc}
+}
+
+type MemorySupportOpts struct {
+	latency time.Duration
+	faults  map[Operation]error
+	fault   func(op Operation) error
+}
+
+func NewMemorySupportOpts() *MemorySupportOpts {
+	return &MemorySupportOpts{faults: make(map[Operation]error)}
+}
+
+// Latency 每次调用前等待 d, ctx 结束时提前返回
+func (o *MemorySupportOpts) Latency(d time.Duration) *MemorySupportOpts {
+	o.latency = d
+	return o
+}
+
+// Fault 使 op 操作总是返回 err
+func (o *MemorySupportOpts) Fault(op Operation, err error) *MemorySupportOpts {
+	o.faults[op] = err
+	return o
+}
+
+// FaultFunc 在每次调用前执行 fn, 返回非nil时该次调用返回此错误
+func (o *MemorySupportOpts) FaultFunc(fn func(op Operation) error) *MemorySupportOpts {
+	o.fault = fn
+	return o
+}
+
+func (o *MemorySupportOpts) faultOf(op Operation) error {
+	if err, ok := o.faults[op]; ok {
+		return err
+	}
+	if o.fault != nil {
+		return o.fault(op)
+	}
+	return nil
+}
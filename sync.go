@@ -0,0 +1,40 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdk
+
+import (
+	"context"
+
+	"github.com/go-the-way/dnsdk/internal"
+)
+
+const (
+	SyncAdd    = internal.SyncAdd
+	SyncUpdate = internal.SyncUpdate
+	SyncDelete = internal.SyncDelete
+)
+
+func PlanSync(api Api, req SyncReq) (plan SyncPlan, err error) {
+	return internal.PlanSync(context.Background(), api, req)
+}
+
+func PlanSyncContext(ctx context.Context, api Api, req SyncReq) (plan SyncPlan, err error) {
+	return internal.PlanSync(ctx, api, req)
+}
+
+func ApplySync(api Api, plan SyncPlan) (applied []SyncChange, err error) {
+	return internal.ApplySync(context.Background(), api, plan)
+}
+
+func ApplySyncContext(ctx context.Context, api Api, plan SyncPlan) (applied []SyncChange, err error) {
+	return internal.ApplySync(ctx, api, plan)
+}
@@ -14,11 +14,61 @@ package dnsdk
 import "github.com/go-the-way/dnsdk/internal"
 
 type (
-	Api = internal.Api
+	Api        = internal.Api
+	ApiContext = internal.ApiContext
 
-	DomainListReq   = internal.DomainListReq
-	DomainAddReq    = internal.DomainAddReq
-	DomainDeleteReq = internal.DomainDeleteReq
+	Invoker     = internal.Invoker
+	Interceptor = internal.Interceptor
+	RetryOpts   = internal.RetryOpts
+
+	RateLimitOpts = internal.RateLimitOpts
+	Limit         = internal.Limit
+
+	CacheOpts  = internal.CacheOpts
+	CacheStore = internal.CacheStore
+
+	Operation    = internal.Operation
+	Capabilities = internal.Capabilities
+
+	Error     = internal.Error
+	ErrorKind = internal.ErrorKind
+
+	SyncAction = internal.SyncAction
+	SyncRecord = internal.SyncRecord
+	SyncReq    = internal.SyncReq
+	SyncChange = internal.SyncChange
+	SyncPlan   = internal.SyncPlan
+
+	ZoneReq    = internal.ZoneReq
+	ZoneIssue  = internal.ZoneIssue
+	ZoneReport = internal.ZoneReport
+
+	MigrateReq    = internal.MigrateReq
+	MigrateIssue  = internal.MigrateIssue
+	MigrateReport = internal.MigrateReport
+
+	FieldError  = internal.FieldError
+	FieldErrors = internal.FieldErrors
+
+	RecordData = internal.RecordData
+	SRV        = internal.SRV
+	CAA        = internal.CAA
+	TXT        = internal.TXT
+	SVCB       = internal.SVCB
+	HTTPS      = internal.HTTPS
+	SvcParam   = internal.SvcParam
+
+	VerifyReq    = internal.VerifyReq
+	VerifyResult = internal.VerifyResult
+	VerifyReport = internal.VerifyReport
+
+	LineListReq      = internal.LineListReq
+	DomainListReq    = internal.DomainListReq
+	DomainAddReq     = internal.DomainAddReq
+	DomainDeleteReq  = internal.DomainDeleteReq
+	DomainGetReq     = internal.DomainGetReq
+	DomainEnableReq  = internal.DomainEnableReq
+	DomainDisableReq = internal.DomainDisableReq
 
 	RecordListReq    = internal.RecordListReq
 	RecordAddReq     = internal.RecordAddReq
@@ -31,6 +81,7 @@ type (
 	LineListRespLine     = internal.LineListRespLine
 	DomainListResp       = internal.DomainListResp
 	DomainListRespDomain = internal.DomainListRespDomain
+	DomainGetResp        = internal.DomainGetResp
 	DomainAddResp        = internal.DomainAddResp
 
 	RecordListResp       = internal.RecordListResp
@@ -13,6 +13,7 @@ package dnsdk
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/alibabacloud-go/tea/tea"
 	"github.com/cloudflare/cloudflare-go"
@@ -29,43 +30,50 @@ func GetSupportApi[T, R any](t T, supporter0 supporter[T, R]) (a Api, err error)
 	if supporter0 == nil {
 		return nil, errors.New("nil supporter error")
 	}
-	switch at := supporter0.Type(); at {
-	default:
+	at := supporter0.Type()
+	p, ok := providerOf(at)
+	if !ok {
 		return nil, errors.New("not supported:" + string(at))
-	case ApiTypeAlidns:
-		spr, ok := supporter0.(supporter[T, *AlidnsSupportOpts])
-		if !ok {
-			return nil, errors.New("invalid alidns opts definition")
-		}
-		return newAlidnsApi(spr.Support(t))
-	case ApiTypeCloudflare:
-		spr, ok := supporter0.(supporter[T, *CloudflareSupportOpts])
-		if !ok {
-			return nil, errors.New("invalid cloudflare opts definition")
-		}
-		return newCloudflareApi(spr.Support(t))
-	case ApiTypeDnspod:
-		spr, ok := supporter0.(supporter[T, *DnspodSupportOpts])
-		if !ok {
-			return nil, errors.New("invalid dnspod opts definition")
-		}
-		return newDnspodApi(spr.Support(t))
-	case ApiTypePqdns:
-		spr, ok := supporter0.(supporter[T, *PqdnsSupportOpts])
-		if !ok {
-			return nil, errors.New("invalid pqdns opts definition")
-		}
-		return newPqdnsApi(spr.Support(t))
 	}
-	return
+	return p(supporter0.Support(t))
+}
+
+func BackgroundApi(a ApiContext) Api { return internal.BackgroundApi(a) }
+
+func Wrap(api Api, interceptors ...Interceptor) Api { return internal.Wrap(api, interceptors...) }
+
+func Retry(opts RetryOpts) Interceptor { return internal.Retry(opts) }
+
+func RateLimit(api Api, opts RateLimitOpts) Interceptor { return internal.RateLimit(api, opts) }
+
+func Cache(opts CacheOpts) Interceptor { return internal.Cache(opts) }
+
+func NewMemoryCacheStore() CacheStore { return internal.NewMemoryCacheStore() }
+
+func Validate(api Api) Interceptor { return internal.Validate(api) }
+
+func ValidateRecordAdd(caps Capabilities, req RecordAddReq) (err error) {
+	return internal.ValidateRecordAdd(caps, req)
+}
+
+func ValidateRecordUpdate(caps Capabilities, req RecordUpdateReq) (err error) {
+	return internal.ValidateRecordUpdate(caps, req)
+}
+
+func ParseRecordData(typ, value string) (data RecordData, err error) {
+	return internal.ParseRecordData(typ, value)
 }
 
 func newAlidnsApi(opts *AlidnsSupportOpts) (a Api, err error) {
-	client, err0 := alidns.NewClient(&openapi.Config{
+	config := &openapi.Config{
 		AccessKeyId:     tea.String(opts.accessKeyId),
 		AccessKeySecret: tea.String(opts.accessKeySecret),
 		Endpoint:        tea.String(opts.endpoint),
-	})
+	}
+	if opts.protocol != "" {
+		config.Protocol = tea.String(opts.protocol)
+	}
+	client, err0 := alidns.NewClient(config)
 
 	if err = err0; err != nil {
 		return
@@ -75,24 +83,53 @@ func newAlidnsApi(opts *AlidnsSupportOpts) (a Api, err error) {
 }
 
 func newCloudflareApi(opts *CloudflareSupportOpts) (a Api, err error) {
-	cApi, err0 := cloudflare.New(opts.apiKey, opts.email)
-	if err = err0; err != nil {
+	options := []cloudflare.Option{cloudflare.HTTPClient(internal.CloudflareHTTPClient())}
+	if opts.baseUrl != "" {
+		options = append(options, cloudflare.BaseURL(opts.baseUrl))
+	}
+	var cApi *cloudflare.API
+	if opts.apiToken != "" {
+		cApi, err = cloudflare.NewWithAPIToken(opts.apiToken, options...)
+	} else {
+		cApi, err = cloudflare.New(opts.apiKey, opts.email, options...)
+	}
+	if err != nil {
 		return
 	}
-	a = internal.CloudflareApi(cApi)
+	a = internal.CloudflareApi(cApi, opts.accountId)
 	return
 }
 
 func newDnspodApi(opts *DnspodSupportOpts) (a Api, err error) {
-	client, err0 := dnspod.NewClient(common.NewCredential(opts.secretId, opts.secretKey), "", profile.NewClientProfile())
+	cpf := profile.NewClientProfile()
+	if opts.endpoint != "" {
+		cpf.HttpProfile.Endpoint = opts.endpoint
+	}
+	if opts.scheme != "" {
+		cpf.HttpProfile.Scheme = opts.scheme
+	}
+	client, err0 := dnspod.NewClient(common.NewCredential(opts.secretId, opts.secretKey), "", cpf)
 	if err = err0; err != nil {
 		return
 	}
-	a = internal.DnspodApi(client)
+	a = internal.DnspodApi(client, cpf.HttpProfile.Scheme, cpf.HttpProfile.Endpoint)
 	return
 }
 
+// splitEndpoint 拆分 http://127.0.0.1:8080 为 HTTP 与 127.0.0.1:8080, 无协议时 scheme 为空
+func splitEndpoint(endpoint string) (scheme, host string) {
+	if i := strings.Index(endpoint, "://"); i >= 0 {
+		return strings.ToUpper(endpoint[:i]), strings.TrimSuffix(endpoint[i+3:], "/")
+	}
+	return "", endpoint
+}
+
 func newPqdnsApi(opts *PqdnsSupportOpts) (a Api, err error) {
 	a = internal.PqdnsApi(opts.baseUrl, opts.username, opts.secretKey)
 	return
 }
+
+func newMemoryApi(opts *MemorySupportOpts) (a Api, err error) {
+	a = internal.MemoryApi(internal.MemoryOpts{Latency: opts.latency, Fault: opts.faultOf})
+	return
+}
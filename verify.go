@@ -0,0 +1,34 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdk
+
+import (
+	"context"
+
+	"github.com/go-the-way/dnsdk/internal"
+)
+
+func Verify(api Api, req VerifyReq) (report VerifyReport, err error) {
+	return internal.Verify(context.Background(), api, req)
+}
+
+func VerifyContext(ctx context.Context, api Api, req VerifyReq) (report VerifyReport, err error) {
+	return internal.Verify(ctx, api, req)
+}
+
+func VerifyOnce(api Api, req VerifyReq) (report VerifyReport, err error) {
+	return internal.VerifyOnce(context.Background(), api, req)
+}
+
+func VerifyOnceContext(ctx context.Context, api Api, req VerifyReq) (report VerifyReport, err error) {
+	return internal.VerifyOnce(ctx, api, req)
+}
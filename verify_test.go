@@ -0,0 +1,126 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdk_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-the-way/dnsdk"
+	"github.com/go-the-way/dnsdk/dnsdktest"
+)
+
+const verifyDomain = "example.com"
+
+// verifyServer 返回已添加 verifyDomain 及 records 的内存实现与其本地权威服务器
+func verifyServer(t *testing.T, records ...dnsdk.RecordAddReq) (api dnsdk.Api, srv *dnsdktest.DNSServer) {
+	t.Helper()
+	api, err := dnsdk.GetSupportApi(dnsdk.NewMemorySupportOpts(), dnsdk.MemorySupporter(func(o *dnsdk.MemorySupportOpts) *dnsdk.MemorySupportOpts { return o }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = api.DomainAdd(dnsdk.DomainAddReq{Domain: verifyDomain}); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range records {
+		r.Domain = verifyDomain
+		if _, err = api.RecordAdd(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv = dnsdktest.NewDNSServer(api)
+	t.Cleanup(srv.Close)
+	return
+}
+
+func TestVerifyOnce(t *testing.T) {
+	api, srv := verifyServer(t,
+		dnsdk.RecordAddReq{Record: "www", Type: "A", Value: "192.0.2.1", TTL: 600},
+		dnsdk.RecordAddReq{Record: "www", Type: "A", Value: "192.0.2.2", TTL: 600},
+		dnsdk.RecordAddReq{Record: "@", Type: "TXT", Value: "v=spf1 -all", TTL: 600},
+		dnsdk.RecordAddReq{Record: "mail", Type: "MX", Value: "mx.example.com", MX: 10, TTL: 600},
+		dnsdk.RecordAddReq{Record: "alias", Type: "CNAME", Value: "Target.Example.net", TTL: 600},
+	)
+	tests := []struct {
+		name       string
+		record     string
+		typ        string
+		value      string
+		propagated bool
+		values     []string
+	}{
+		{"a", "www", "A", "192.0.2.2", true, []string{"192.0.2.1", "192.0.2.2"}},
+		{"txt at apex", "@", "TXT", "v=spf1 -all", true, []string{"v=spf1 -all"}},
+		{"mx", "mail", "MX", "MX.example.com.", true, []string{"mx.example.com"}},
+		{"cname case", "alias", "CNAME", "target.example.net", true, []string{"Target.Example.net"}},
+		{"wrong value", "www", "A", "192.0.2.3", false, []string{"192.0.2.1", "192.0.2.2"}},
+		{"missing record", "none", "A", "192.0.2.1", false, nil},
+		{"missing type", "www", "AAAA", "2001:db8::1", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := dnsdk.VerifyOnce(api, dnsdk.VerifyReq{Domain: verifyDomain, Record: tt.record, Type: tt.typ, Value: tt.value, Nameservers: []string{srv.Addr}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Propagated != tt.propagated || report.Attempts != 1 || len(report.Results) != 1 {
+				t.Fatalf("report = %+v, want propagated %v", report, tt.propagated)
+			}
+			r := report.Results[0]
+			slices.Sort(r.Values)
+			if r.Server != srv.Addr || !r.Authoritative || r.Err != nil || r.Matched != tt.propagated || !slices.Equal(r.Values, tt.values) {
+				t.Fatalf("result = %+v, want values %q", r, tt.values)
+			}
+		})
+	}
+}
+
+func TestVerifyStaleNameserver(t *testing.T) {
+	api, fresh := verifyServer(t, dnsdk.RecordAddReq{Record: "www", Type: "A", Value: "192.0.2.2", TTL: 600})
+	_, stale := verifyServer(t, dnsdk.RecordAddReq{Record: "www", Type: "A", Value: "192.0.2.1", TTL: 600})
+	req := dnsdk.VerifyReq{Domain: verifyDomain, Record: "www", Type: "A", Value: "192.0.2.2", Nameservers: []string{fresh.Addr, stale.Addr}, Timeout: time.Millisecond * 200, Interval: time.Millisecond * 20}
+	report, err := dnsdk.Verify(api, req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	if report.Propagated || report.Attempts < 2 || len(report.Results) != 2 {
+		t.Fatalf("report = %+v, want several rounds without propagation", report)
+	}
+	if !report.Results[0].Matched || report.Results[1].Matched || report.Results[1].Values[0] != "192.0.2.1" {
+		t.Fatalf("results = %+v, want only the fresh nameserver matched", report.Results)
+	}
+}
+
+func TestVerifyPropagates(t *testing.T) {
+	api, srv := verifyServer(t)
+	go func() {
+		time.Sleep(time.Millisecond * 50)
+		_, _ = api.RecordAdd(dnsdk.RecordAddReq{Domain: verifyDomain, Record: "www", Type: "A", Value: "192.0.2.1", TTL: 600})
+	}()
+	report, err := dnsdk.Verify(api, dnsdk.VerifyReq{Domain: verifyDomain, Record: "www", Type: "A", Value: "192.0.2.1", Nameservers: []string{srv.Addr}, Timeout: time.Second * 5, Interval: time.Millisecond * 10})
+	if err != nil || !report.Propagated || report.Attempts < 2 {
+		t.Fatalf("report = %+v %v, want propagation after several rounds", report, err)
+	}
+}
+
+func TestVerifyInvalid(t *testing.T) {
+	api, srv := verifyServer(t)
+	if _, err := dnsdk.VerifyOnce(api, dnsdk.VerifyReq{Domain: verifyDomain, Type: "BOGUS", Nameservers: []string{srv.Addr}}); !errors.Is(err, dnsdk.ErrInvalidInput) {
+		t.Fatalf("unknown type err = %v, want ErrInvalidInput", err)
+	}
+	if _, err := dnsdk.VerifyOnce(api, dnsdk.VerifyReq{Domain: "example.org", Type: "A"}); !errors.Is(err, dnsdk.ErrInvalidInput) {
+		t.Fatalf("unknown domain err = %v, want ErrInvalidInput", err)
+	}
+}
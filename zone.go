@@ -0,0 +1,35 @@
+// Copyright 2024 dnsdk Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsdk
+
+import (
+	"context"
+	"io"
+
+	"github.com/go-the-way/dnsdk/internal"
+)
+
+func ExportZone(api Api, w io.Writer, req ZoneReq) (report ZoneReport, err error) {
+	return internal.ExportZone(context.Background(), api, w, req)
+}
+
+func ExportZoneContext(ctx context.Context, api Api, w io.Writer, req ZoneReq) (report ZoneReport, err error) {
+	return internal.ExportZone(ctx, api, w, req)
+}
+
+func ImportZone(api Api, r io.Reader, req ZoneReq) (report ZoneReport, err error) {
+	return internal.ImportZone(context.Background(), api, r, req)
+}
+
+func ImportZoneContext(ctx context.Context, api Api, r io.Reader, req ZoneReq) (report ZoneReport, err error) {
+	return internal.ImportZone(ctx, api, r, req)
+}